@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/services"
+)
+
+// backfillPageSize is how many users are read per page.
+const backfillPageSize = 100
+
+func main() {
+	userID := flag.String("user", "", "rebuild only this user's streak")
+	flag.Parse()
+
+	// Load config
+	cfg := appconfig.Load()
+
+	// Initialize storage backend
+	store, err := repository.Open(cfg)
+	if err != nil {
+		log.Fatalf("failed to initialize storage: %v", err)
+	}
+
+	ctx := context.Background()
+	streaks := services.NewStreakService(store, store)
+
+	if *userID != "" {
+		streak, err := streaks.Rebuild(ctx, *userID)
+		if err != nil {
+			log.Fatalf("failed to rebuild streak for %s: %v", *userID, err)
+		}
+		fmt.Printf("%s: current %d (%s to %s), longest %d (%s to %s)\n", *userID,
+			streak.CurrentLength, streak.CurrentStart, streak.CurrentEnd,
+			streak.LongestLength, streak.LongestStart, streak.LongestEnd)
+		return
+	}
+
+	// Rebuilding is idempotent, so an interrupted backfill can be rerun.
+	rebuilt, failed := 0, 0
+	cursor := ""
+	for {
+		users, next, err := store.ListUsersPage(ctx, backfillPageSize, cursor)
+		if err != nil {
+			log.Fatalf("failed to list users: %v", err)
+		}
+		for _, user := range users {
+			if _, err := streaks.Rebuild(ctx, user.ID); err != nil {
+				log.Printf("failed to rebuild streak for %s: %v", user.ID, err)
+				failed++
+				continue
+			}
+			rebuilt++
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	fmt.Printf("Rebuilt streaks for %d users (%d failed).\n", rebuilt, failed)
+	if failed > 0 {
+		log.Fatal("backfill incomplete; rerun to retry the failed users")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+func main() {
+	status := flag.Bool("status", false, "print the applied and expected schema versions without migrating")
+	flag.Parse()
+
+	// Load config
+	cfg := appconfig.Load()
+
+	// Initialize storage backend
+	store, err := repository.Open(cfg)
+	if err != nil {
+		log.Fatalf("failed to initialize storage: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	before, err := store.AppliedSchemaVersion(ctx)
+	if err != nil {
+		log.Fatalf("failed to read schema version: %v", err)
+	}
+
+	if *status {
+		fmt.Printf("backend: %s\napplied schema version: %d\nexpected schema version: %d\n",
+			cfg.StorageBackend, before, repository.SchemaVersion)
+		return
+	}
+
+	if err := store.Migrate(ctx); err != nil {
+		log.Fatalf("migration failed: %v", err)
+	}
+
+	after, err := store.AppliedSchemaVersion(ctx)
+	if err != nil {
+		log.Fatalf("failed to read schema version: %v", err)
+	}
+	if after == before {
+		fmt.Printf("Schema already at version %d, nothing to do.\n", after)
+		return
+	}
+	fmt.Printf("Migrated %s schema from version %d to %d.\n", cfg.StorageBackend, before, after)
+}
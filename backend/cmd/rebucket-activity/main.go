@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/services"
+)
+
+// rebucketPageSize is how many users are read per page.
+const rebucketPageSize = 100
+
+func main() {
+	userID := flag.String("user", "", "rebucket only this user's sessions")
+	flag.Parse()
+
+	// Load config
+	cfg := appconfig.Load()
+
+	// Initialize storage backend
+	store, err := repository.Open(cfg)
+	if err != nil {
+		log.Fatalf("failed to initialize storage: %v", err)
+	}
+
+	ctx := context.Background()
+	// Rebucketing records no sessions, so the session policy does not apply.
+	sessions := services.NewSessionService(store, store, store, store, services.SessionPolicy{})
+
+	if *userID != "" {
+		moved, skipped, err := sessions.RebucketSessions(ctx, *userID)
+		if err != nil {
+			log.Fatalf("failed to rebucket sessions for %s: %v", *userID, err)
+		}
+		fmt.Printf("%s: %d sessions moved, %d skipped\n", *userID, moved, skipped)
+		return
+	}
+
+	// Rebucketed sessions are dated and left alone on a rerun, so an
+	// interrupted run can be restarted. Users without a timezone already
+	// count days in UTC and are skipped.
+	users, failed, moved, skipped := 0, 0, 0, 0
+	cursor := ""
+	for {
+		page, next, err := store.ListUsersPage(ctx, rebucketPageSize, cursor)
+		if err != nil {
+			log.Fatalf("failed to list users: %v", err)
+		}
+		for _, user := range page {
+			if user.Timezone == "" {
+				continue
+			}
+			m, s, err := sessions.RebucketSessions(ctx, user.ID)
+			moved, skipped = moved+m, skipped+s
+			if err != nil {
+				log.Printf("failed to rebucket sessions for %s: %v", user.ID, err)
+				failed++
+				continue
+			}
+			users++
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	fmt.Printf("Rebucketed sessions for %d users (%d failed): %d moved, %d skipped.\n", users, failed, moved, skipped)
+	if failed > 0 {
+		log.Fatal("rebucket incomplete; rerun to retry the failed users")
+	}
+}
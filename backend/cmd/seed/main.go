@@ -2,38 +2,28 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
-	"github.com/Brian-w-m/DevVerse/backend/src/database"
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
 )
 
-type User struct {
-	ID    string `dynamodbav:"ID"`
-	Name  string `dynamodbav:"Name"`
-	Email string `dynamodbav:"Email"`
-	Score int    `dynamodbav:"Score"`
-}
-
 func main() {
 	// Load config
 	cfg := appconfig.Load()
 
-	// Initialize DynamoDB client
-	dynamodbClient, err := database.NewDynamoDBClient(cfg)
+	// Initialize storage backend
+	store, err := repository.Open(cfg)
 	if err != nil {
-		log.Fatalf("failed to initialize DynamoDB client: %v", err)
+		log.Fatalf("failed to initialize storage: %v", err)
 	}
 
 	ctx := context.Background()
 
 	// Seed users
-	users := []User{
+	users := []models.User{
 		{
 			ID:    "dev-user-001",
 			Name:  "Developer",
@@ -67,23 +57,13 @@ func main() {
 	}
 
 	for _, user := range users {
-		item, err := attributevalue.MarshalMap(user)
-		if err != nil {
-			log.Printf("failed to marshal user %s: %v", user.ID, err)
-			continue
-		}
-
-		_, err = dynamodbClient.PutItem(ctx, &dynamodb.PutItemInput{
-			TableName: &cfg.DynamoDBTable,
-			Item:      item,
-		})
-		if err != nil {
-			log.Printf("failed to put item for user %s: %v", user.ID, err)
+		if err := store.PutUser(ctx, user); err != nil {
+			log.Printf("failed to put user %s: %v", user.ID, err)
 			continue
 		}
 
 		log.Printf("✓ Seeded user: %s (%s)", user.Name, user.Email)
 	}
 
-	fmt.Println("\nSeeding complete! Users added to DynamoDB.")
+	fmt.Printf("\nSeeding complete! Users added to %s storage.\n", cfg.StorageBackend)
 }
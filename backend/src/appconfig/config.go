@@ -1,19 +1,61 @@
 package appconfig
 
 import (
+	"errors"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
 	Port     int
 	LogLevel string
+	StorageBackend   string
+	SQLitePath       string
 	AWSRegion        string
 	DynamoDBEndpoint string
 	DynamoDBTable    string
 	JWTSecret        string
+	JWTKeysFile      string
 	SessionsTable    string
 	DailyActivityTable string
+	SchemaTable        string
+	IdempotencyTable   string
+	IdempotencyTTLHours int
+	RefreshTokensTable string
+	RevokedTokensTable string
+	PersonalTokensTable string
+	IdentitiesTable     string
+	UserMergesTable     string
+	StreaksTable        string
+	LanguageActivityTable string
+	ScoreLedgerTable      string
+	PeriodActivityTable   string
+	AccessTokenTTLMinutes int
+	RefreshTokenTTLHours  int
+	LastSeenIntervalMinutes int
+	SessionMaxAgeHours      int  // older sessions are rejected; 0 accepts any age
+	SessionMaxFutureMinutes int  // sessions ending further ahead are rejected; 0 disables the check
+	SessionMaxDurationHours int  // at most 48
+	SessionAllowUntimed     bool // credit sessions without timestamps to today instead of rejecting them
+	AutoMigrate        bool
+
+	GitHubClientID       string
+	GitHubClientSecret   string
+	GitHubRedirectURL    string // this server's /auth/github/callback URL
+	GitHubOAuthBaseURL   string
+	GitHubAPIBaseURL     string
+	OAuthSuccessRedirect string // where the callback sends the browser with the token; JSON response if empty
+	OAuthStateSecret     string // signs the OAuth state cookie; defaults to JWTSecret
+
+	GitLabBaseURL    string // enables POST /auth/gitlab when set
+	GitLabClientID   string // if set, GitLab tokens must belong to this OAuth application
+	OIDCIssuerURL    string // enables POST /auth/<OIDCProviderName> when set
+	OIDCProviderName string
+	OIDCClientID     string // OIDC tokens must be issued to this client
+	OIDCClientSecret string // authenticates token introspection
+
+	AdminGitHubIDs []string // GitHub user IDs granted the admin role when they log in
 }
 
 func getEnv(key, def string) string {
@@ -32,17 +74,93 @@ func getEnvInt(key string, def int) int {
 	return def
 }
 
+func getEnvBool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
 func Load() Config {
+	// GITHUB_ENTERPRISE_URL sets both GitHub endpoints for a GitHub Enterprise
+	// Server; GITHUB_OAUTH_BASE_URL and GITHUB_API_BASE_URL still override.
+	githubOAuthBaseURL, githubAPIBaseURL := DefaultGitHubOAuthBaseURL, DefaultGitHubAPIBaseURL
+	if ghe := strings.TrimRight(os.Getenv("GITHUB_ENTERPRISE_URL"), "/"); ghe != "" {
+		githubOAuthBaseURL, githubAPIBaseURL = ghe, ghe+"/api/v3"
+	}
+
 	return Config{
 		Port:             getEnvInt("PORT", DefaultPort),
 		LogLevel:         getEnv("LOG_LEVEL", DefaultLogLevel),
+		StorageBackend:   getEnv("STORAGE_BACKEND", DefaultStorageBackend),
+		SQLitePath:       getEnv("SQLITE_PATH", DefaultSQLitePath),
 		AWSRegion:        getEnv("AWS_REGION", DefaultAWSRegion),
 		DynamoDBEndpoint: getEnv("DYNAMODB_ENDPOINT", ""),
 		DynamoDBTable:    getEnv("DYNAMODB_TABLE", DefaultDynamoDBTable),
 		JWTSecret:        getEnv("JWT_SECRET", ""),
+		JWTKeysFile:      getEnv("JWT_KEYS_FILE", ""),
 		SessionsTable:      getEnv("SESSIONS_TABLE", DefaultSessionsTable),
 		DailyActivityTable: getEnv("DAILY_ACTIVITY_TABLE", DefaultDailyActivityTable),
+		SchemaTable:        getEnv("SCHEMA_TABLE", DefaultSchemaTable),
+		IdempotencyTable:   getEnv("IDEMPOTENCY_TABLE", DefaultIdempotencyTable),
+		IdempotencyTTLHours: getEnvInt("IDEMPOTENCY_TTL_HOURS", DefaultIdempotencyTTLHours),
+		RefreshTokensTable: getEnv("REFRESH_TOKENS_TABLE", DefaultRefreshTokensTable),
+		RevokedTokensTable: getEnv("REVOKED_TOKENS_TABLE", DefaultRevokedTokensTable),
+		PersonalTokensTable: getEnv("PERSONAL_TOKENS_TABLE", DefaultPersonalTokensTable),
+		IdentitiesTable:     getEnv("IDENTITIES_TABLE", DefaultIdentitiesTable),
+		UserMergesTable:     getEnv("USER_MERGES_TABLE", DefaultUserMergesTable),
+		StreaksTable:        getEnv("STREAKS_TABLE", DefaultStreaksTable),
+		LanguageActivityTable: getEnv("LANGUAGE_ACTIVITY_TABLE", DefaultLanguageActivityTable),
+		ScoreLedgerTable:      getEnv("SCORE_LEDGER_TABLE", DefaultScoreLedgerTable),
+		PeriodActivityTable:   getEnv("PERIOD_ACTIVITY_TABLE", DefaultPeriodActivityTable),
+		AccessTokenTTLMinutes: getEnvInt("ACCESS_TOKEN_TTL_MINUTES", DefaultAccessTokenTTLMinutes),
+		RefreshTokenTTLHours:  getEnvInt("REFRESH_TOKEN_TTL_HOURS", DefaultRefreshTokenTTLHours),
+		LastSeenIntervalMinutes: getEnvInt("LAST_SEEN_INTERVAL_MINUTES", DefaultLastSeenIntervalMinutes),
+		SessionMaxAgeHours:      getEnvInt("SESSION_MAX_AGE_HOURS", DefaultSessionMaxAgeHours),
+		SessionMaxFutureMinutes: getEnvInt("SESSION_MAX_FUTURE_MINUTES", DefaultSessionMaxFutureMinutes),
+		SessionMaxDurationHours: getEnvInt("SESSION_MAX_DURATION_HOURS", DefaultSessionMaxDurationHours),
+		SessionAllowUntimed:     getEnvBool("SESSION_ALLOW_UNTIMED", false),
+
+		GitHubClientID:       getEnv("GITHUB_CLIENT_ID", ""),
+		GitHubClientSecret:   getEnv("GITHUB_CLIENT_SECRET", ""),
+		GitHubRedirectURL:    getEnv("GITHUB_REDIRECT_URL", ""),
+		GitHubOAuthBaseURL:   getEnv("GITHUB_OAUTH_BASE_URL", githubOAuthBaseURL),
+		GitHubAPIBaseURL:     getEnv("GITHUB_API_BASE_URL", githubAPIBaseURL),
+		OAuthSuccessRedirect: getEnv("OAUTH_SUCCESS_REDIRECT", ""),
+		OAuthStateSecret:     getEnv("OAUTH_STATE_SECRET", getEnv("JWT_SECRET", "")),
+
+		GitLabBaseURL:    getEnv("GITLAB_BASE_URL", ""),
+		GitLabClientID:   getEnv("GITLAB_CLIENT_ID", ""),
+		OIDCIssuerURL:    getEnv("OIDC_ISSUER_URL", ""),
+		OIDCProviderName: getEnv("OIDC_PROVIDER_NAME", DefaultOIDCProviderName),
+		OIDCClientID:     getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+
+		AdminGitHubIDs: getEnvList("ADMIN_GITHUB_IDS"),
+		AutoMigrate:        getEnvBool("AUTO_MIGRATE", false),
 	}
 }
 
-
+// Validate reports a configuration the server cannot run with.
+func (c Config) Validate() error {
+	if c.GitHubClientID != "" && c.GitHubRedirectURL != "" && c.OAuthStateSecret == "" {
+		return errors.New("OAUTH_STATE_SECRET (or JWT_SECRET) must be set to sign the GitHub OAuth state")
+	}
+	if c.OIDCIssuerURL != "" && (c.OIDCClientID == "" || c.OIDCClientSecret == "") {
+		return errors.New("OIDC_CLIENT_ID and OIDC_CLIENT_SECRET must be set to verify OIDC tokens")
+	}
+	return nil
+}
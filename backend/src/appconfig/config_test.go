@@ -0,0 +1,49 @@
+package appconfig
+
+import "testing"
+
+func TestValidateRequiresOAuthStateSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"no GitHub OAuth", Config{}, false},
+		{"GitHub OAuth with secret", Config{GitHubClientID: "id", GitHubRedirectURL: "https://x/cb", OAuthStateSecret: "s"}, false},
+		{"GitHub OAuth without secret", Config{GitHubClientID: "id", GitHubRedirectURL: "https://x/cb"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateRequiresOIDCClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"OIDC with client", Config{OIDCIssuerURL: "https://id", OIDCClientID: "id", OIDCClientSecret: "s"}, false},
+		{"OIDC without client ID", Config{OIDCIssuerURL: "https://id", OIDCClientSecret: "s"}, true},
+		{"OIDC without client secret", Config{OIDCIssuerURL: "https://id", OIDCClientID: "id"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOAuthStateSecretDefaultsToJWTSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "jwt")
+	t.Setenv("JWT_KEYS_FILE", "keys.json")
+	if got := Load().OAuthStateSecret; got != "jwt" {
+		t.Errorf("OAuthStateSecret = %q, want jwt", got)
+	}
+	t.Setenv("OAUTH_STATE_SECRET", "state")
+	if got := Load().OAuthStateSecret; got != "state" {
+		t.Errorf("OAuthStateSecret = %q, want state", got)
+	}
+}
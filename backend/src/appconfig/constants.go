@@ -6,12 +6,53 @@ const (
 	DefaultAWSRegion     = "ap-southeast-2"
 	DefaultDynamoDBTable = "Users"
 
+	// Storage backends selectable via STORAGE_BACKEND.
+	StorageBackendDynamoDB = "dynamodb"
+	StorageBackendSQLite   = "sqlite"
+	StorageBackendMemory   = "memory"
+	DefaultStorageBackend  = StorageBackendDynamoDB
+	DefaultSQLitePath      = "devverse.db"
+
 	// TODO 1.5 #2: Add table name constants for the two new DynamoDB tables and expose
 	// them as environment-variable-backed fields on Config (like DynamoDBTable is today).
 	// Also add the CREATE TABLE commands for Sessions and DailyActivity to the Docker
 	// Compose init script and to the manual setup instructions in README.md.
 
 	DefaultSessionsTable      = "Sessions"       // PK: UserID, SK: SessionID
-	DefaultDailyActivityTable = "DailyActivity"  // PK: UserID, SK: Date (YYYY-MM-DD)
-)
+	DefaultDailyActivityTable = "DailyActivity"  // PK: UserID, SK: Date (YYYY-MM-DD); GSI DateIndex (Date, UserID)
+	DefaultSchemaTable        = "SchemaVersion"  // PK: ID; records the applied migration version
+	DefaultIdempotencyTable   = "IdempotencyKeys" // PK: UserID, SK: Key
+	DefaultRefreshTokensTable = "RefreshTokens"   // PK: TokenHash
+	DefaultRevokedTokensTable = "RevokedTokens"   // PK: ID (access token jti or refresh family)
+	DefaultPersonalTokensTable = "PersonalAccessTokens" // PK: TokenHash; GSI UserIndex (UserID, ID)
+	DefaultIdentitiesTable     = "Identities"           // PK: ID (provider:subject); GSI UserIndex (UserID, ID)
+	DefaultUserMergesTable     = "UserMerges"           // PK: SourceID
+	DefaultStreaksTable        = "Streaks"              // PK: UserID
+	DefaultLanguageActivityTable = "LanguageActivity"   // PK: UserID, SK: DateLanguage; GSI DateIndex (Date, UserID)
+	DefaultScoreLedgerTable      = "ScoreLedger"        // PK: UserID, SK: EntryID
+	DefaultPeriodActivityTable   = "PeriodActivity"     // PK: UserID, SK: Period; GSI PeriodIndex (Period, UserID)
+
+	// How long a stored Idempotency-Key response is replayed for.
+	DefaultIdempotencyTTLHours = 24
+
+	// Access tokens keep the original 24h lifetime until every client refreshes;
+	// refresh tokens rotate on each use and expire after 30 days.
+	DefaultAccessTokenTTLMinutes = 24 * 60
+	DefaultRefreshTokenTTLHours  = 30 * 24
 
+	// A user's LastSeenAt is written at most this often.
+	DefaultLastSeenIntervalMinutes = 5
+
+	// Sessions queued offline by the extension are accepted for a week;
+	// timestamps may run slightly ahead of the server's clock.
+	DefaultSessionMaxAgeHours      = 7 * 24
+	DefaultSessionMaxFutureMinutes = 10
+	DefaultSessionMaxDurationHours = 24
+
+	// GitHub endpoints; override to point at GitHub Enterprise or a fake server.
+	DefaultGitHubOAuthBaseURL = "https://github.com"
+	DefaultGitHubAPIBaseURL   = "https://api.github.com"
+
+	// Name of the generic OIDC provider in /auth/:provider and user IDs.
+	DefaultOIDCProviderName = "oidc"
+)
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -13,7 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
-	"github.com/Brian-w-m/DevVerse/backend/src/database"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
 	"github.com/Brian-w-m/DevVerse/backend/src/routes"
 	"github.com/Brian-w-m/DevVerse/backend/src/utils"
 )
@@ -21,18 +23,58 @@ import (
 func main() {
 	// Load config
 	cfg := appconfig.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	autoMigrate := flag.Bool("auto-migrate", cfg.AutoMigrate, "apply pending schema migrations before serving")
+	flag.Parse()
 
 	// Setup logger
 	logger := utils.NewLogger(cfg.LogLevel)
 	logger.Info("starting server")
 
-	// Initialise DynamoDB client
-	dynamodbClient, err := database.NewDynamoDBClient(cfg)
+	// Initialise storage backend
+	store, err := repository.Open(cfg)
+	if err != nil {
+		log.Fatalf("failed to initialise storage: %v", err)
+	}
+	if cfg.StorageBackend == appconfig.StorageBackendDynamoDB {
+		logger.Infof("DynamoDB client initialised (region: %s, endpoint: %s, table: %s)",
+			cfg.AWSRegion, cfg.DynamoDBEndpoint, cfg.DynamoDBTable)
+	} else {
+		logger.Infof("%s storage backend initialised", cfg.StorageBackend)
+	}
+	if closer, ok := store.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if *autoMigrate {
+		migrateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		err := store.Migrate(migrateCtx)
+		cancel()
+		if err != nil {
+			log.Fatalf("failed to migrate schema: %v", err)
+		}
+		logger.Infof("schema migrated to version %d", repository.SchemaVersion)
+	}
+
+	// Load JWT signing keys; SIGHUP re-reads the key file so keys can be
+	// rotated without a restart
+	keys, err := utils.LoadKeyring(cfg.JWTKeysFile, cfg.JWTSecret)
 	if err != nil {
-		log.Fatalf("failed to initialise DynamoDB client: %v", err)
+		log.Fatalf("failed to load JWT keys: %v", err)
 	}
-	logger.Infof("DynamoDB client initialised (region: %s, endpoint: %s, table: %s)",
-		cfg.AWSRegion, cfg.DynamoDBEndpoint, cfg.DynamoDBTable)
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			if err := keys.Reload(); err != nil {
+				logger.Errorf("failed to reload JWT keys, keeping current keys: %v", err)
+				continue
+			}
+			logger.Info("reloaded JWT keys")
+		}
+	}()
 
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
@@ -45,8 +87,9 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Idempotency-Key, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Next-Cursor, Idempotent-Replayed")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -57,7 +100,7 @@ func main() {
 	})
 
 	// Register routes
-	routes.Register(r, dynamodbClient, cfg, logger)
+	routes.Register(r, store, keys, cfg, logger)
 
 	addr := fmt.Sprintf(":%d", cfg.Port)
 
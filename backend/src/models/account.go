@@ -0,0 +1,53 @@
+package models
+
+// IdentityLink attaches a login identity to a user, so one user can sign in
+// with several providers. ID is the provider-namespaced identity, e.g.
+// "github:42" or "gitlab:7".
+// Stored in the Identities DynamoDB table (PK: ID, GSI UserIndex: UserID, ID).
+type IdentityLink struct {
+	ID       string `json:"id"       dynamodbav:"ID"`
+	Provider string `json:"provider" dynamodbav:"Provider"`
+	Subject  string `json:"subject"  dynamodbav:"Subject"` // the provider's user ID
+	UserID   string `json:"userId"   dynamodbav:"UserID"`
+	Login    string `json:"login,omitempty" dynamodbav:"Login,omitempty"`
+	LinkedAt int64  `json:"linkedAt" dynamodbav:"LinkedAt"` // Unix seconds
+}
+
+// Steps of a user merge, run in this order. UserMerge.Step names the next
+// step to run, so an interrupted merge resumes where it stopped.
+const (
+	MergeStepRevokeTokens = "revoke_tokens"
+	MergeStepIdentities   = "identities"
+	MergeStepSessions     = "sessions"
+	MergeStepActivity     = "activity"
+	MergeStepLanguages    = "languages"
+	MergeStepScore        = "score"
+	MergeStepDeleteSource = "delete_source"
+	MergeStepDone         = "done"
+)
+
+// MergeSteps lists the merge steps in order.
+var MergeSteps = []string{
+	MergeStepRevokeTokens,
+	MergeStepIdentities,
+	MergeStepSessions,
+	MergeStepActivity,
+	MergeStepLanguages,
+	MergeStepScore,
+	MergeStepDeleteSource,
+	MergeStepDone,
+}
+
+// UserMerge records the progress of folding SourceID into TargetID. The
+// record is kept after the merge completes so logins that still resolve to
+// the old ID are sent to the surviving account.
+// Stored in the UserMerges DynamoDB table (PK: SourceID).
+type UserMerge struct {
+	SourceID    string `json:"sourceId"              dynamodbav:"SourceID"`
+	TargetID    string `json:"targetId"              dynamodbav:"TargetID"`
+	RequestedBy string `json:"requestedBy"           dynamodbav:"RequestedBy"`
+	Step        string `json:"step"                  dynamodbav:"Step"`
+	StartedAt   int64  `json:"startedAt"             dynamodbav:"StartedAt"`             // Unix seconds
+	UpdatedAt   int64  `json:"updatedAt"             dynamodbav:"UpdatedAt"`             // Unix seconds
+	CompletedAt int64  `json:"completedAt,omitempty" dynamodbav:"CompletedAt,omitempty"` // Unix seconds; 0 while running
+}
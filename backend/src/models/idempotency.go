@@ -0,0 +1,14 @@
+package models
+
+// IdempotencyRecord remembers the response to a score-mutating request so that
+// a retry carrying the same Idempotency-Key is answered without being applied
+// twice. Stored in the IdempotencyKeys DynamoDB table (PK: UserID, SK: Key).
+type IdempotencyRecord struct {
+	UserID      string `dynamodbav:"UserID"`
+	Key         string `dynamodbav:"Key"`
+	RequestHash string `dynamodbav:"RequestHash"` // method, path and body of the original request
+	StatusCode  int    `dynamodbav:"StatusCode"`  // 0 while the original request is still in flight
+	ContentType string `dynamodbav:"ContentType"`
+	Body        string `dynamodbav:"Body"`
+	ExpiresAt   int64  `dynamodbav:"ExpiresAt"` // Unix seconds; DynamoDB TTL attribute
+}
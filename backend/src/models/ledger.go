@@ -0,0 +1,24 @@
+package models
+
+// Sources of a score change.
+const (
+	LedgerSourceSession    = "session"    // a recorded session; ReferenceID is its SessionID
+	LedgerSourceCorrection = "correction" // a session deleted or adjusted; ReferenceID is its SessionID
+	LedgerSourceManualAdd  = "manual_add" // points added through the score API
+	LedgerSourceAdminSet   = "admin_set"  // score overwritten by an admin
+	LedgerSourceBonus      = "bonus"      // points awarded outside sessions
+	LedgerSourceMerge      = "merge"      // score moved by an account merge; ReferenceID is the other user
+)
+
+// LedgerEntry records one change to a user's Score. Entries are written in
+// the same atomic write as the change and are never updated or deleted.
+// Stored in the ScoreLedger DynamoDB table (PK: UserID, SK: EntryID).
+type LedgerEntry struct {
+	UserID      string `json:"userId"                dynamodbav:"UserID"`
+	EntryID     string `json:"entryId"               dynamodbav:"EntryID"` // sorts by creation time
+	Delta       int    `json:"delta"                 dynamodbav:"Delta"`
+	Source      string `json:"source"                dynamodbav:"Source"`
+	ReferenceID string `json:"referenceId,omitempty" dynamodbav:"ReferenceID,omitempty"`
+	ActorID     string `json:"actorId,omitempty"     dynamodbav:"ActorID,omitempty"` // user who made the change
+	CreatedAt   int64  `json:"createdAt"             dynamodbav:"CreatedAt"`         // Unix seconds
+}
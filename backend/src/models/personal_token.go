@@ -0,0 +1,25 @@
+package models
+
+// Scopes a personal access token can be granted.
+const (
+	ScopeSessionsWrite = "sessions:write" // record sessions and add score
+	ScopeStatsRead     = "stats:read"     // read users, streaks and stats
+)
+
+// KnownScopes lists every scope a personal access token may request.
+var KnownScopes = []string{ScopeSessionsWrite, ScopeStatsRead}
+
+// PersonalAccessToken is a long-lived credential a user creates for scripts
+// and CI. Only a hash of the token is stored; the token itself is shown once
+// at creation. Stored in the PersonalAccessTokens DynamoDB table
+// (PK: TokenHash, GSI UserIndex: UserID, ID).
+type PersonalAccessToken struct {
+	TokenHash  string   `json:"-"                    dynamodbav:"TokenHash"` // hex SHA-256 of the token
+	ID         string   `json:"id"                   dynamodbav:"ID"`
+	UserID     string   `json:"userId"               dynamodbav:"UserID"`
+	Name       string   `json:"name"                 dynamodbav:"Name"`
+	Scopes     []string `json:"scopes"               dynamodbav:"Scopes"`
+	CreatedAt  int64    `json:"createdAt"            dynamodbav:"CreatedAt"`            // Unix seconds
+	ExpiresAt  int64    `json:"expiresAt,omitempty"  dynamodbav:"ExpiresAt,omitempty"`  // Unix seconds; 0 never expires
+	LastUsedAt int64    `json:"lastUsedAt,omitempty" dynamodbav:"LastUsedAt,omitempty"` // Unix seconds; updated at most once a minute
+}
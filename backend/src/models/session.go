@@ -3,12 +3,35 @@ package models
 // Session represents one completed coding session recorded by the VS Code extension.
 // Stored in the Sessions DynamoDB table (PK: UserID, SK: SessionID).
 type Session struct {
-	UserID            string         `json:"userId"            dynamodbav:"UserID"`
-	SessionID         string         `json:"sessionId"         dynamodbav:"SessionID"`
-	StartedAt         int64          `json:"startedAt"         dynamodbav:"StartedAt"`
-	EndedAt           int64          `json:"endedAt"           dynamodbav:"EndedAt"`
-	Points            int            `json:"points"            dynamodbav:"Points"`
-	LanguageBreakdown map[string]int `json:"languageBreakdown" dynamodbav:"LanguageBreakdown"`
+	UserID            string              `json:"userId"                dynamodbav:"UserID"`
+	SessionID         string              `json:"sessionId"             dynamodbav:"SessionID"`
+	StartedAt         int64               `json:"startedAt"             dynamodbav:"StartedAt"` // Unix seconds
+	EndedAt           int64               `json:"endedAt"               dynamodbav:"EndedAt"`   // Unix seconds
+	Points            int                 `json:"points"                dynamodbav:"Points"`
+	LanguageBreakdown map[string]int      `json:"languageBreakdown"     dynamodbav:"LanguageBreakdown"`
+	Date              string              `json:"date,omitempty"        dynamodbav:"Date,omitempty"`      // DailyActivity day the session is counted on; empty if recorded before sessions were dated
+	Days              []SessionDay        `json:"days,omitempty"        dynamodbav:"Days,omitempty"`      // how the points were split across days; empty if recorded before sessions were split
+	DeletedAt         int64               `json:"deletedAt,omitempty"   dynamodbav:"DeletedAt,omitempty"` // Unix seconds; a deleted session credits nothing but keeps its SessionID
+	Corrections       []SessionCorrection `json:"corrections,omitempty" dynamodbav:"Corrections,omitempty"`
+}
+
+// SessionCorrection records one change to a recorded session's points, or
+// its deletion, and why it was made.
+type SessionCorrection struct {
+	At        int64  `json:"at"        dynamodbav:"At"` // Unix seconds
+	By        string `json:"by"        dynamodbav:"By"` // user ID of whoever made the correction
+	Reason    string `json:"reason"    dynamodbav:"Reason"`
+	OldPoints int    `json:"oldPoints" dynamodbav:"OldPoints"`
+	NewPoints int    `json:"newPoints" dynamodbav:"NewPoints"` // 0 for a deletion
+}
+
+// SessionDay is the part of a session's points, and of its language
+// breakdown, credited to one DailyActivity day. Days[0] of a session is the
+// day it is counted on.
+type SessionDay struct {
+	Date      string         `json:"date"                dynamodbav:"Date"` // "YYYY-MM-DD" in the user's timezone
+	Points    int            `json:"points"              dynamodbav:"Points"`
+	Languages map[string]int `json:"languages,omitempty" dynamodbav:"Languages,omitempty"`
 }
 
 // DailyActivity is an aggregated per-user per-day record.
@@ -16,7 +39,35 @@ type Session struct {
 // Stored in the DailyActivity DynamoDB table (PK: UserID, SK: Date).
 type DailyActivity struct {
 	UserID       string `json:"userId"       dynamodbav:"UserID"`
-	Date         string `json:"date"         dynamodbav:"Date"`         // "YYYY-MM-DD" UTC
+	Date         string `json:"date"         dynamodbav:"Date"` // "YYYY-MM-DD" in the user's timezone
 	Points       int    `json:"points"       dynamodbav:"Points"`
 	SessionCount int    `json:"sessionCount" dynamodbav:"SessionCount"`
 }
+
+// PeriodActivity is a per-user rollup of DailyActivity points over a year, a
+// month or a week of a month, written together with the daily rows so that a
+// windowed leaderboard reads a few periods instead of every day.
+// Stored in the PeriodActivity DynamoDB table (PK: UserID, SK: Period;
+// GSI PeriodIndex: Period, UserID).
+type PeriodActivity struct {
+	UserID string `json:"userId" dynamodbav:"UserID"`
+	Period string `json:"period" dynamodbav:"Period"` // "Y2024", "M2024-09" or "W2024-09-2" (days 8-14)
+	Points int    `json:"points" dynamodbav:"Points"`
+}
+
+// LanguageTotalDate is the Date of the LanguageActivity rows that hold a
+// user's all-time points per language.
+const LanguageTotalDate = "all"
+
+// LanguageActivity is a per-user per-day per-language rollup of session
+// language breakdowns, written together with the session. Rows dated
+// LanguageTotalDate accumulate every day.
+// Stored in the LanguageActivity DynamoDB table (PK: UserID, SK: DateLanguage;
+// GSI DateIndex: Date, UserID).
+type LanguageActivity struct {
+	UserID       string `json:"userId"   dynamodbav:"UserID"`
+	DateLanguage string `json:"-"        dynamodbav:"DateLanguage"` // Date + "#" + Language
+	Date         string `json:"date"     dynamodbav:"Date"`         // "YYYY-MM-DD" in the user's timezone, or LanguageTotalDate
+	Language     string `json:"language" dynamodbav:"Language"`     // VS Code language ID, lower case
+	Points       int    `json:"points"   dynamodbav:"Points"`
+}
@@ -9,7 +9,10 @@ type UserStats struct {
 	EditsThisWeek   int    `json:"edits_this_week"`
 	CurrentStreak   int    `json:"current_streak"`
 	LongestStreak   int    `json:"longest_streak"`
-	LastActivityAt  string `json:"last_activity_at"`
+	LastActivityAt  string `json:"last_activity_at"` // RFC 3339; empty if the user has no activity
+	TotalSessions   int    `json:"total_sessions"`
+	TotalMinutes    int    `json:"total_minutes"`
+	TopLanguage     string `json:"top_language"`
 }
 
 type LeaderboardEntry struct {
@@ -30,3 +33,18 @@ type ActivityData struct {
 	Days          []ActivityDay `json:"days"`
 	TotalThisWeek int           `json:"total_this_week"`
 }
+
+// LanguageShare is the points earned in one language and their share of the
+// points earned in all languages.
+type LanguageShare struct {
+	Language string  `json:"language"`
+	Points   int     `json:"points"`
+	Share    float64 `json:"share"`
+}
+
+type LanguageStats struct {
+	From      string          `json:"from,omitempty"` // empty for all time
+	To        string          `json:"to,omitempty"`
+	Total     int             `json:"total"`
+	Languages []LanguageShare `json:"languages"`
+}
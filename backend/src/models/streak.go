@@ -0,0 +1,19 @@
+package models
+
+// Streak is a user's persisted streak state: the latest run of consecutive
+// days with points and the longest run ever. Dates are "YYYY-MM-DD". The
+// current run has lapsed once CurrentEnd is before yesterday; it is kept as
+// is until the next day with points replaces it.
+// Stored in the Streaks DynamoDB table (PK: UserID).
+type Streak struct {
+	UserID        string `json:"userId"        dynamodbav:"UserID"`
+	CurrentStart  string `json:"currentStart"  dynamodbav:"CurrentStart"`
+	CurrentEnd    string `json:"currentEnd"    dynamodbav:"CurrentEnd"`
+	CurrentLength int    `json:"currentLength" dynamodbav:"CurrentLength"`
+	LongestStart  string `json:"longestStart"  dynamodbav:"LongestStart"`
+	LongestEnd    string `json:"longestEnd"    dynamodbav:"LongestEnd"`
+	LongestLength int    `json:"longestLength" dynamodbav:"LongestLength"`
+	UpdatedAt     int64  `json:"updatedAt"     dynamodbav:"UpdatedAt"` // Unix seconds
+	// Version is bumped on every write so concurrent updates can be detected.
+	Version int `json:"-" dynamodbav:"Version"`
+}
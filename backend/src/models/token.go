@@ -0,0 +1,26 @@
+package models
+
+// RefreshToken is a long-lived, single-use credential exchanged at
+// POST /auth/refresh for a new access token and a new refresh token. Only a
+// hash of the token is stored. Tokens descended from the same login share a
+// FamilyID so that reuse of a rotated token can revoke the whole chain.
+// Stored in the RefreshTokens DynamoDB table (PK: TokenHash).
+type RefreshToken struct {
+	TokenHash string `dynamodbav:"TokenHash"` // hex SHA-256 of the token
+	UserID    string `dynamodbav:"UserID"`
+	FamilyID  string `dynamodbav:"FamilyID"`
+	CreatedAt int64  `dynamodbav:"CreatedAt"` // Unix seconds
+	ExpiresAt int64  `dynamodbav:"ExpiresAt"` // Unix seconds; DynamoDB TTL attribute
+	UsedAt    int64  `dynamodbav:"UsedAt"`    // Unix seconds; 0 until rotated
+}
+
+// RevokedToken marks an access token (by its jti claim), a refresh token
+// family (by FamilyID) or all of a user's tokens as revoked until ExpiresAt,
+// after which every token it covers has expired anyway. If IssuedBefore is
+// set, only tokens issued before it are revoked.
+// Stored in the RevokedTokens DynamoDB table (PK: ID).
+type RevokedToken struct {
+	ID           string `dynamodbav:"ID"`
+	ExpiresAt    int64  `dynamodbav:"ExpiresAt"`              // Unix seconds; DynamoDB TTL attribute
+	IssuedBefore int64  `dynamodbav:"IssuedBefore,omitempty"` // Unix seconds; 0 revokes every token
+}
@@ -1,8 +1,15 @@
 package models
 
 type User struct {
-	ID    string `json:"id" dynamodbav:"ID"`
-	Name  string `json:"name" dynamodbav:"Name"`
-	Email string `json:"email" dynamodbav:"Email"`
-	Score int    `json:"score" dynamodbav:"Score"`
+	ID          string   `json:"id" dynamodbav:"ID"`
+	Name        string   `json:"name" dynamodbav:"Name"`
+	Email       string   `json:"email" dynamodbav:"Email"`
+	Score       int      `json:"score" dynamodbav:"Score"`
+	Roles       []string `json:"roles,omitempty" dynamodbav:"Roles,omitempty"`
+	Banned      bool     `json:"banned,omitempty" dynamodbav:"Banned,omitempty"`
+	GithubLogin string   `json:"githubLogin,omitempty" dynamodbav:"GithubLogin,omitempty"`
+	AvatarURL   string   `json:"avatarUrl,omitempty" dynamodbav:"AvatarURL,omitempty"`
+	CreatedAt   int64    `json:"createdAt,omitempty" dynamodbav:"CreatedAt,omitempty"`   // Unix seconds
+	LastSeenAt  int64    `json:"lastSeenAt,omitempty" dynamodbav:"LastSeenAt,omitempty"` // Unix seconds; see services.LastSeenTracker
+	Timezone    string   `json:"timezone,omitempty" dynamodbav:"Timezone,omitempty"`     // IANA name, e.g. "Australia/Sydney"; empty means UTC
 }
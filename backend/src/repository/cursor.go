@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor turns the last key of a page into an opaque token for clients.
+func encodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(key) == 0 {
+		return "", ErrInvalidCursor
+	}
+	return string(key), nil
+}
+
+// scoreCursor identifies the last entry of a leaderboard page.
+type scoreCursor struct {
+	Position int // zero-based position of the entry
+	Score    int
+	ID       string
+}
+
+func encodeScoreCursor(c scoreCursor) string {
+	return encodeCursor(fmt.Sprintf("%d:%d:%s", c.Position, c.Score, c.ID))
+}
+
+func decodeScoreCursor(cursor string) (scoreCursor, error) {
+	key, err := decodeCursor(cursor)
+	if err != nil {
+		return scoreCursor{}, err
+	}
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) != 3 {
+		return scoreCursor{}, ErrInvalidCursor
+	}
+	position, err := strconv.Atoi(parts[0])
+	if err != nil || position < 0 {
+		return scoreCursor{}, ErrInvalidCursor
+	}
+	score, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return scoreCursor{}, ErrInvalidCursor
+	}
+	return scoreCursor{Position: position, Score: score, ID: parts[2]}, nil
+}
+
+// sessionCursor identifies the last session of a ListSessions page by its
+// sort value.
+type sessionCursor struct {
+	Value     int64
+	SessionID string
+}
+
+func encodeSessionCursor(c sessionCursor) string {
+	return encodeCursor(fmt.Sprintf("%d:%s", c.Value, c.SessionID))
+}
+
+func decodeSessionCursor(cursor string) (sessionCursor, error) {
+	key, err := decodeCursor(cursor)
+	if err != nil {
+		return sessionCursor{}, err
+	}
+	valuePart, sessionID, ok := strings.Cut(key, ":")
+	value, err := strconv.ParseInt(valuePart, 10, 64)
+	if !ok || err != nil || sessionID == "" {
+		return sessionCursor{}, ErrInvalidCursor
+	}
+	return sessionCursor{Value: value, SessionID: sessionID}, nil
+}
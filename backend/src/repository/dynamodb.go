@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBStore implements Store on top of the Users, Sessions and
+// DailyActivity DynamoDB tables.
+type DynamoDBStore struct {
+	client                *dynamodb.Client
+	usersTable            string
+	sessionsTable         string
+	dailyActivityTable    string
+	schemaTable           string
+	idempotencyTable      string
+	refreshTokensTable    string
+	revokedTokensTable    string
+	personalTokensTable   string
+	identitiesTable       string
+	userMergesTable       string
+	streaksTable          string
+	languageActivityTable string
+	scoreLedgerTable      string
+	periodActivityTable   string
+}
+
+func NewDynamoDBStore(client *dynamodb.Client, cfg appconfig.Config) *DynamoDBStore {
+	return &DynamoDBStore{
+		client:                client,
+		usersTable:            cfg.DynamoDBTable,
+		sessionsTable:         cfg.SessionsTable,
+		dailyActivityTable:    cfg.DailyActivityTable,
+		schemaTable:           cfg.SchemaTable,
+		idempotencyTable:      cfg.IdempotencyTable,
+		refreshTokensTable:    cfg.RefreshTokensTable,
+		revokedTokensTable:    cfg.RevokedTokensTable,
+		personalTokensTable:   cfg.PersonalTokensTable,
+		identitiesTable:       cfg.IdentitiesTable,
+		userMergesTable:       cfg.UserMergesTable,
+		streaksTable:          cfg.StreaksTable,
+		languageActivityTable: cfg.LanguageActivityTable,
+		scoreLedgerTable:      cfg.ScoreLedgerTable,
+		periodActivityTable:   cfg.PeriodActivityTable,
+	}
+}
+
+func (s *DynamoDBStore) tableExists(ctx context.Context, table string) (bool, error) {
+	_, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(table),
+	})
+	if err != nil {
+		var resourceNotFound *types.ResourceNotFoundException
+		if errors.As(err, &resourceNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to describe table: %w", err)
+	}
+	return true, nil
+}
+
+func (s *DynamoDBStore) HealthCheck(ctx context.Context) error {
+	exists, err := s.tableExists(ctx, s.usersTable)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("table %s does not exist", s.usersTable)
+	}
+	return nil
+}
+
+// batchGetItems reads keys from table with BatchGetItem, 100 keys per call,
+// retrying unprocessed keys. Missing items are skipped. projection may be
+// empty to read whole items.
+func (s *DynamoDBStore) batchGetItems(ctx context.Context, table string, keys []map[string]types.AttributeValue, projection string) ([]map[string]types.AttributeValue, error) {
+	const batchSize = 100
+	var items []map[string]types.AttributeValue
+	for start := 0; start < len(keys); start += batchSize {
+		end := start + batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		request := map[string]types.KeysAndAttributes{
+			table: {Keys: keys[start:end]},
+		}
+		if projection != "" {
+			request[table] = types.KeysAndAttributes{
+				Keys:                 keys[start:end],
+				ProjectionExpression: aws.String(projection),
+			}
+		}
+		for len(request) > 0 {
+			result, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+			if err != nil {
+				return nil, fmt.Errorf("failed to batch get items: %w", err)
+			}
+			items = append(items, result.Responses[table]...)
+			request = result.UnprocessedKeys
+		}
+	}
+	return items, nil
+}
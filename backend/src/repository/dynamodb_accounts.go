@@ -0,0 +1,412 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// identityUserIndex is the GSI on Identities keyed by (UserID, ID) used to
+// list a user's linked identities.
+const identityUserIndex = "UserIndex"
+
+// errMoveConflict is returned when a row changes while it is being moved. The
+// merge that hit it can simply be resumed.
+var errMoveConflict = errors.New("row changed while being moved")
+
+func identityKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}}
+}
+
+func (s *DynamoDBStore) GetIdentityLink(ctx context.Context, id string) (*models.IdentityLink, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.identitiesTable),
+		Key:            identityKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity link: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var link models.IdentityLink
+	if err := attributevalue.UnmarshalMap(result.Item, &link); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal identity link: %w", err)
+	}
+	return &link, nil
+}
+
+func (s *DynamoDBStore) CreateIdentityLink(ctx context.Context, link models.IdentityLink) (bool, error) {
+	item, err := attributevalue.MarshalMap(link)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal identity link: %w", err)
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.identitiesTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(ID)"),
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create identity link: %w", err)
+	}
+	return true, nil
+}
+
+// ListIdentityLinks reads UserIndex, which is eventually consistent.
+func (s *DynamoDBStore) ListIdentityLinks(ctx context.Context, userID string) ([]models.IdentityLink, error) {
+	var links []models.IdentityLink
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:              aws.String(s.identitiesTable),
+		IndexName:              aws.String(identityUserIndex),
+		KeyConditionExpression: aws.String("UserID = :userID"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userID": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query identity links: %w", err)
+		}
+		var pageLinks []models.IdentityLink
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageLinks); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal identity links: %w", err)
+		}
+		links = append(links, pageLinks...)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].ID < links[j].ID })
+	return links, nil
+}
+
+func (s *DynamoDBStore) DeleteIdentityLink(ctx context.Context, userID, id string) (bool, error) {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(s.identitiesTable),
+		Key:                 identityKey(id),
+		ConditionExpression: aws.String("UserID = :userID"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userID": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to delete identity link: %w", err)
+	}
+	return true, nil
+}
+
+func (s *DynamoDBStore) SetIdentityLinkUser(ctx context.Context, id, userID string) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.identitiesTable),
+		Key:                 identityKey(id),
+		UpdateExpression:    aws.String("SET UserID = :userID"),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userID": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return nil
+		}
+		return fmt.Errorf("failed to update identity link: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) CreateUserMerge(ctx context.Context, merge models.UserMerge) (bool, error) {
+	item, err := attributevalue.MarshalMap(merge)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal user merge: %w", err)
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.userMergesTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(SourceID)"),
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create user merge: %w", err)
+	}
+	return true, nil
+}
+
+func (s *DynamoDBStore) GetUserMerge(ctx context.Context, sourceID string) (*models.UserMerge, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.userMergesTable),
+		Key: map[string]types.AttributeValue{
+			"SourceID": &types.AttributeValueMemberS{Value: sourceID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user merge: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var merge models.UserMerge
+	if err := attributevalue.UnmarshalMap(result.Item, &merge); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user merge: %w", err)
+	}
+	return &merge, nil
+}
+
+func (s *DynamoDBStore) PutUserMerge(ctx context.Context, merge models.UserMerge) error {
+	item, err := attributevalue.MarshalMap(merge)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user merge: %w", err)
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.userMergesTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put user merge: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) MoveSession(ctx context.Context, session models.Session, targetID, targetSessionID string) (bool, error) {
+	moved := session
+	moved.UserID = targetID
+	moved.SessionID = targetSessionID
+	item, err := marshalSession(moved)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName:           aws.String(s.sessionsTable),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(SessionID)"),
+				},
+			},
+			{
+				Delete: &types.Delete{
+					TableName: aws.String(s.sessionsTable),
+					Key: map[string]types.AttributeValue{
+						"UserID":    &types.AttributeValueMemberS{Value: session.UserID},
+						"SessionID": &types.AttributeValueMemberS{Value: session.SessionID},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailure(err, 0) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to move session: %w", err)
+	}
+	return true, nil
+}
+
+// MoveDailyActivity deletes the source row only if it still holds the values
+// that were added to the target, so a concurrent increment is never lost.
+func (s *DynamoDBStore) MoveDailyActivity(ctx context.Context, userID, date, targetID string) error {
+	key := func(id string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"UserID": &types.AttributeValueMemberS{Value: id},
+			"Date":   &types.AttributeValueMemberS{Value: date},
+		}
+	}
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.dailyActivityTable),
+		Key:            key(userID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get daily activity: %w", err)
+	}
+	if result.Item == nil {
+		return nil
+	}
+	var row models.DailyActivity
+	if err := attributevalue.UnmarshalMap(result.Item, &row); err != nil {
+		return fmt.Errorf("failed to unmarshal daily activity: %w", err)
+	}
+
+	periods, err := s.periodActivityUpdates([]models.DailyActivity{
+		{UserID: userID, Date: date, Points: -row.Points},
+		{UserID: targetID, Date: date, Points: row.Points},
+	})
+	if err != nil {
+		return err
+	}
+	values := map[string]types.AttributeValue{
+		":points":   &types.AttributeValueMemberN{Value: strconv.Itoa(row.Points)},
+		":sessions": &types.AttributeValueMemberN{Value: strconv.Itoa(row.SessionCount)},
+	}
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: append([]types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName:                 aws.String(s.dailyActivityTable),
+					Key:                       key(targetID),
+					UpdateExpression:          aws.String("ADD Points :points, SessionCount :sessions"),
+					ExpressionAttributeValues: values,
+				},
+			},
+			{
+				Delete: &types.Delete{
+					TableName:                 aws.String(s.dailyActivityTable),
+					Key:                       key(userID),
+					ConditionExpression:       aws.String("Points = :points AND SessionCount = :sessions"),
+					ExpressionAttributeValues: values,
+				},
+			},
+		}, periods...),
+	})
+	if err != nil {
+		if isConditionFailure(err, 1) {
+			return fmt.Errorf("failed to move daily activity: %w", errMoveConflict)
+		}
+		return fmt.Errorf("failed to move daily activity: %w", err)
+	}
+	return nil
+}
+
+// MoveLanguageActivity deletes the source row only if its points are unchanged
+// since they were read.
+func (s *DynamoDBStore) MoveLanguageActivity(ctx context.Context, row models.LanguageActivity, targetID string) error {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.languageActivityTable),
+		Key:            languageActivityKey(row.UserID, row.DateLanguage),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get language activity: %w", err)
+	}
+	if result.Item == nil {
+		return nil
+	}
+	var stored models.LanguageActivity
+	if err := attributevalue.UnmarshalMap(result.Item, &stored); err != nil {
+		return fmt.Errorf("failed to unmarshal language activity: %w", err)
+	}
+
+	moved := stored
+	moved.UserID = targetID
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Update: s.languageActivityUpdate(moved)},
+			{
+				Delete: &types.Delete{
+					TableName:           aws.String(s.languageActivityTable),
+					Key:                 languageActivityKey(stored.UserID, stored.DateLanguage),
+					ConditionExpression: aws.String("Points = :points"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":points": &types.AttributeValueMemberN{Value: strconv.Itoa(stored.Points)},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailure(err, 1) {
+			return fmt.Errorf("failed to move language activity: %w", errMoveConflict)
+		}
+		return fmt.Errorf("failed to move language activity: %w", err)
+	}
+	return nil
+}
+
+// MoveUserScore zeroes the source only if its score is unchanged since it was
+// read, so a concurrent increment is never lost.
+func (s *DynamoDBStore) MoveUserScore(ctx context.Context, sourceID, targetID string, entry models.LedgerEntry) error {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.usersTable),
+		Key:            s.userKey(sourceID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if result.Item == nil {
+		return nil
+	}
+	var source models.User
+	if err := attributevalue.UnmarshalMap(result.Item, &source); err != nil {
+		return fmt.Errorf("failed to unmarshal user: %w", err)
+	}
+	if source.Score == 0 {
+		return nil
+	}
+	from, to := moveEntries(entry, sourceID, targetID, source.Score)
+	fromPut, err := s.ledgerPut(from)
+	if err != nil {
+		return err
+	}
+	toPut, err := s.ledgerPut(to)
+	if err != nil {
+		return err
+	}
+
+	score := &types.AttributeValueMemberN{Value: strconv.Itoa(source.Score)}
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName:        aws.String(s.usersTable),
+					Key:              s.userKey(targetID),
+					UpdateExpression: aws.String("ADD #score :score SET #board = :board"),
+					ExpressionAttributeNames: map[string]string{
+						"#score": "Score",
+						"#board": boardAttribute,
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":score": score,
+						":board": boardValue(),
+					},
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName:                aws.String(s.usersTable),
+					Key:                      s.userKey(sourceID),
+					UpdateExpression:         aws.String("SET #score = :zero"),
+					ConditionExpression:      aws.String("#score = :score"),
+					ExpressionAttributeNames: map[string]string{"#score": "Score"},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":score": score,
+						":zero":  &types.AttributeValueMemberN{Value: "0"},
+					},
+				},
+			},
+			{Put: fromPut},
+			{Put: toPut},
+		},
+	})
+	if err != nil {
+		if isConditionFailure(err, 1) {
+			return fmt.Errorf("failed to move user score: %w", errMoveConflict)
+		}
+		return fmt.Errorf("failed to move user score: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func (s *DynamoDBStore) AddDailyActivity(ctx context.Context, userID, date string, points, sessions int) error {
+	items, err := s.dailyActivityItems([]models.DailyActivity{{UserID: userID, Date: date, Points: points, SessionCount: sessions}})
+	if err != nil {
+		return err
+	}
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		return fmt.Errorf("failed to update daily activity: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) ListActivitySince(ctx context.Context, userID, since string) ([]models.DailyActivity, error) {
+	// Key conditions cannot compare against an empty string.
+	if since == "" {
+		return s.queryActivity(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(s.dailyActivityTable),
+			KeyConditionExpression: aws.String("UserID = :uid"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":uid": &types.AttributeValueMemberS{Value: userID},
+			},
+			ScanIndexForward: aws.Bool(true),
+		}, 0)
+	}
+	return s.queryActivity(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.dailyActivityTable),
+		KeyConditionExpression: aws.String("UserID = :uid AND #date >= :start"),
+		ExpressionAttributeNames: map[string]string{
+			"#date": "Date",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid":   &types.AttributeValueMemberS{Value: userID},
+			":start": &types.AttributeValueMemberS{Value: since},
+		},
+		ScanIndexForward: aws.Bool(true),
+	}, 0)
+}
+
+// queryActivity follows LastEvaluatedKey until the query is exhausted or, when
+// max > 0, max rows have been read.
+func (s *DynamoDBStore) queryActivity(ctx context.Context, input *dynamodb.QueryInput, max int) ([]models.DailyActivity, error) {
+	var activities []models.DailyActivity
+	paginator := dynamodb.NewQueryPaginator(s.client, input)
+	for paginator.HasMorePages() && (max <= 0 || len(activities) < max) {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query daily activity: %w", err)
+		}
+		var pageActivities []models.DailyActivity
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageActivities); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal daily activities: %w", err)
+		}
+		activities = append(activities, pageActivities...)
+	}
+	if max > 0 && len(activities) > max {
+		activities = activities[:max]
+	}
+	return activities, nil
+}
+
+func (s *DynamoDBStore) ListRecentActivity(ctx context.Context, userID string, limit int) ([]models.DailyActivity, error) {
+	return s.queryActivity(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.dailyActivityTable),
+		KeyConditionExpression: aws.String("UserID = :uid"),
+		ScanIndexForward:       aws.Bool(false),
+		Limit:                  aws.Int32(int32(limit)),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid": &types.AttributeValueMemberS{Value: userID},
+		},
+	}, limit)
+}
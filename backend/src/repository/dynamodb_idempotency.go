@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func idempotencyKey(userID, key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"UserID": &types.AttributeValueMemberS{Value: userID},
+		"Key":    &types.AttributeValueMemberS{Value: key},
+	}
+}
+
+func (s *DynamoDBStore) ClaimIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord, now int64) (bool, error) {
+	item, err := attributevalue.MarshalMap(rec)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	// TTL deletion is lazy, so an expired record may still be present.
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(s.idempotencyTable),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#key) OR #expiresAt <= :now"),
+		ExpressionAttributeNames: map[string]string{"#key": "Key", "#expiresAt": "ExpiresAt"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)},
+		},
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	return true, nil
+}
+
+func (s *DynamoDBStore) GetIdempotencyRecord(ctx context.Context, userID, key string, now int64) (*models.IdempotencyRecord, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.idempotencyTable),
+		Key:            idempotencyKey(userID, key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var rec models.IdempotencyRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &rec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+	}
+	if rec.ExpiresAt <= now {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+func (s *DynamoDBStore) PutIdempotencyRecord(ctx context.Context, rec models.IdempotencyRecord) error {
+	item, err := attributevalue.MarshalMap(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.idempotencyTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) DeleteIdempotencyRecord(ctx context.Context, userID, key string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.idempotencyTable),
+		Key:       idempotencyKey(userID, key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency record: %w", err)
+	}
+	return nil
+}
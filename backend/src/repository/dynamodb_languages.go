@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func languageActivityKey(userID, dateLanguage string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"UserID":       &types.AttributeValueMemberS{Value: userID},
+		"DateLanguage": &types.AttributeValueMemberS{Value: dateLanguage},
+	}
+}
+
+// languageActivityUpdate adds row's points to the stored row.
+func (s *DynamoDBStore) languageActivityUpdate(row models.LanguageActivity) *types.Update {
+	return &types.Update{
+		TableName:        aws.String(s.languageActivityTable),
+		Key:              languageActivityKey(row.UserID, row.DateLanguage),
+		UpdateExpression: aws.String("ADD Points :points SET #date = :date, #language = :language"),
+		ExpressionAttributeNames: map[string]string{
+			"#date":     "Date",
+			"#language": "Language",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":points":   &types.AttributeValueMemberN{Value: strconv.Itoa(row.Points)},
+			":date":     &types.AttributeValueMemberS{Value: row.Date},
+			":language": &types.AttributeValueMemberS{Value: row.Language},
+		},
+	}
+}
+
+// queryLanguageActivity follows LastEvaluatedKey until the query is exhausted.
+func (s *DynamoDBStore) queryLanguageActivity(ctx context.Context, input *dynamodb.QueryInput) ([]models.LanguageActivity, error) {
+	var activity []models.LanguageActivity
+	paginator := dynamodb.NewQueryPaginator(s.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query language activity: %w", err)
+		}
+		var rows []models.LanguageActivity
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &rows); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal language activity: %w", err)
+		}
+		activity = append(activity, rows...)
+	}
+	return activity, nil
+}
+
+// forEachLanguageDay queries DateIndex once per day in the range, or once
+// for the all-time rows, and passes every row to fn.
+func (s *DynamoDBStore) forEachLanguageDay(ctx context.Context, from, to string, fn func(models.LanguageActivity)) error {
+	from, to = languageDateRange(from, to)
+	dates := []string{from}
+	if from != models.LanguageTotalDate {
+		start, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return fmt.Errorf("invalid from date: %w", err)
+		}
+		end, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return fmt.Errorf("invalid to date: %w", err)
+		}
+		dates = dates[:0]
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			dates = append(dates, day.Format("2006-01-02"))
+		}
+	}
+
+	for _, date := range dates {
+		rows, err := s.queryLanguageActivity(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(s.languageActivityTable),
+			IndexName:              aws.String(activityDateIndex),
+			KeyConditionExpression: aws.String("#date = :date"),
+			ExpressionAttributeNames: map[string]string{
+				"#date": "Date",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":date": &types.AttributeValueMemberS{Value: date},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			fn(row)
+		}
+	}
+	return nil
+}
+
+// ListUserLanguageTotals reads the user's partition. "$" sorts just after
+// "#", so the key range covers every language of the first and last days.
+func (s *DynamoDBStore) ListUserLanguageTotals(ctx context.Context, userID, from, to string) ([]LanguageTotal, error) {
+	from, to = languageDateRange(from, to)
+	rows, err := s.queryLanguageActivity(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.languageActivityTable),
+		KeyConditionExpression: aws.String("UserID = :uid AND DateLanguage BETWEEN :from AND :to"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid":  &types.AttributeValueMemberS{Value: userID},
+			":from": &types.AttributeValueMemberS{Value: from + "#"},
+			":to":   &types.AttributeValueMemberS{Value: to + "$"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	points := make(map[string]int)
+	for _, row := range rows {
+		points[row.Language] += row.Points
+	}
+	return sortedLanguageTotals(points), nil
+}
+
+func (s *DynamoDBStore) ListLanguageTotals(ctx context.Context, from, to string) ([]LanguageTotal, error) {
+	points := make(map[string]int)
+	err := s.forEachLanguageDay(ctx, from, to, func(row models.LanguageActivity) {
+		points[row.Language] += row.Points
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sortedLanguageTotals(points), nil
+}
+
+func (s *DynamoDBStore) ListLanguagePointTotals(ctx context.Context, language, from, to string) ([]PointsTotal, error) {
+	points := make(map[string]int)
+	err := s.forEachLanguageDay(ctx, from, to, func(row models.LanguageActivity) {
+		if row.Language == language {
+			points[row.UserID] += row.Points
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.existingPointTotals(ctx, points)
+}
+
+func (s *DynamoDBStore) ListLanguageActivity(ctx context.Context, userID string) ([]models.LanguageActivity, error) {
+	return s.queryLanguageActivity(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.languageActivityTable),
+		KeyConditionExpression: aws.String("UserID = :uid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+}
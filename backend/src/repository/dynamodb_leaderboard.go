@@ -0,0 +1,356 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Every user row carries Board = "global" so that ScoreIndex (PK Board,
+// SK Score) holds the whole table in score order under a single partition.
+const (
+	scoreIndexName = "ScoreIndex"
+	boardAttribute = "Board"
+	boardPartition = "global"
+)
+
+// activityDateIndex is a GSI on DailyActivity (PK Date, SK UserID) that
+// holds every user's points for one day under a single partition.
+const activityDateIndex = "DateIndex"
+
+// periodIndex is a GSI on PeriodActivity (PK Period, SK UserID) that holds
+// every user's points for one rollup period under a single partition.
+const periodIndex = "PeriodIndex"
+
+func boardValue() types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: boardPartition}
+}
+
+func (s *DynamoDBStore) scoreIndexQuery() *dynamodb.QueryInput {
+	return &dynamodb.QueryInput{
+		TableName:              aws.String(s.usersTable),
+		IndexName:              aws.String(scoreIndexName),
+		KeyConditionExpression: aws.String("#board = :board"),
+		ExpressionAttributeNames: map[string]string{
+			"#board": boardAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":board": boardValue(),
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+}
+
+func scoreIndexKey(score int, id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"ID":           &types.AttributeValueMemberS{Value: id},
+		"Score":        &types.AttributeValueMemberN{Value: strconv.Itoa(score)},
+		boardAttribute: boardValue(),
+	}
+}
+
+// skipScoreIndex reads (keys only) past the first offset entries of the index
+// and returns the key to resume from, or nil if the index has fewer entries.
+func (s *DynamoDBStore) skipScoreIndex(ctx context.Context, offset int) (map[string]types.AttributeValue, error) {
+	input := s.scoreIndexQuery()
+	input.ProjectionExpression = aws.String("ID, Score, #board")
+	var startKey map[string]types.AttributeValue
+	skipped := 0
+	for skipped < offset {
+		input.Limit = aws.Int32(int32(offset - skipped))
+		input.ExclusiveStartKey = startKey
+		result, err := s.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query score index: %w", err)
+		}
+		skipped += len(result.Items)
+		if len(result.Items) > 0 {
+			startKey = result.Items[len(result.Items)-1]
+		}
+		if result.LastEvaluatedKey == nil && skipped < offset {
+			return nil, nil
+		}
+	}
+	return startKey, nil
+}
+
+func (s *DynamoDBStore) ListTopUsers(ctx context.Context, offset, limit int, cursor string) (*ScorePage, error) {
+	input := s.scoreIndexQuery()
+	if cursor != "" {
+		c, err := decodeScoreCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		offset = c.Position + 1
+		input.ExclusiveStartKey = scoreIndexKey(c.Score, c.ID)
+	} else if offset > 0 {
+		startKey, err := s.skipScoreIndex(ctx, offset)
+		if err != nil {
+			return nil, err
+		}
+		if startKey == nil {
+			return &ScorePage{Offset: offset, Users: []models.User{}}, nil
+		}
+		input.ExclusiveStartKey = startKey
+	}
+
+	// Read one extra entry to learn whether another page exists.
+	var users []models.User
+	for len(users) <= limit {
+		input.Limit = aws.Int32(int32(limit + 1 - len(users)))
+		result, err := s.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query score index: %w", err)
+		}
+		var pageUsers []models.User
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &pageUsers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal users: %w", err)
+		}
+		users = append(users, pageUsers...)
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	page := &ScorePage{Offset: offset, Users: users}
+	if page.Users == nil {
+		page.Users = []models.User{}
+	}
+	if len(users) > limit {
+		page.Users = users[:limit]
+		last := page.Users[limit-1]
+		page.Next = encodeScoreCursor(scoreCursor{Position: offset + limit - 1, Score: last.Score, ID: last.ID})
+	}
+	return page, nil
+}
+
+func (s *DynamoDBStore) CountUsersAbove(ctx context.Context, score int) (int, error) {
+	input := s.scoreIndexQuery()
+	input.KeyConditionExpression = aws.String("#board = :board AND #score > :score")
+	input.ExpressionAttributeNames["#score"] = "Score"
+	input.ExpressionAttributeValues[":score"] = &types.AttributeValueMemberN{Value: strconv.Itoa(score)}
+	input.Select = types.SelectCount
+
+	count := 0
+	paginator := dynamodb.NewQueryPaginator(s.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count users: %w", err)
+		}
+		count += int(page.Count)
+	}
+	return count, nil
+}
+
+// ListPointTotals reads the range as whole PeriodActivity rollups plus the
+// leftover days from DateIndex, so a yearly window costs a few dozen queries
+// rather than one per day.
+func (s *DynamoDBStore) ListPointTotals(ctx context.Context, from, to string) ([]PointsTotal, error) {
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date: %w", err)
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date: %w", err)
+	}
+
+	points := make(map[string]int)
+	periods, days := windowPeriods(start, end)
+	for _, period := range periods {
+		if err := s.addIndexedPoints(ctx, s.periodActivityTable, periodIndex, "Period", period, points); err != nil {
+			return nil, fmt.Errorf("failed to query period index: %w", err)
+		}
+	}
+	for _, day := range days {
+		if err := s.addIndexedPoints(ctx, s.dailyActivityTable, activityDateIndex, "Date", day, points); err != nil {
+			return nil, fmt.Errorf("failed to query date index: %w", err)
+		}
+	}
+
+	return s.existingPointTotals(ctx, points)
+}
+
+// addIndexedPoints adds to points every user's Points from the index
+// partition where attribute equals value.
+func (s *DynamoDBStore) addIndexedPoints(ctx context.Context, table, index, attribute, value string, points map[string]int) error {
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:              aws.String(table),
+		IndexName:              aws.String(index),
+		KeyConditionExpression: aws.String("#key = :value"),
+		ProjectionExpression:   aws.String("UserID, Points"),
+		ExpressionAttributeNames: map[string]string{
+			"#key": attribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":value": &types.AttributeValueMemberS{Value: value},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		var rows []models.PeriodActivity
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &rows); err != nil {
+			return fmt.Errorf("failed to unmarshal points: %w", err)
+		}
+		for _, row := range rows {
+			points[row.UserID] += row.Points
+		}
+	}
+	return nil
+}
+
+// periodActivityUpdates adds the points of rows to their PeriodActivity
+// rollups. A transaction may touch each item only once, so deltas to the same
+// period are merged, and periods whose deltas cancel out are left alone.
+func (s *DynamoDBStore) periodActivityUpdates(rows []models.DailyActivity) ([]types.TransactWriteItem, error) {
+	type periodKey struct{ userID, period string }
+	deltas := make(map[periodKey]int)
+	var keys []periodKey
+	for _, row := range rows {
+		if row.Points == 0 {
+			continue
+		}
+		periods, err := activityPeriods(row.Date)
+		if err != nil {
+			return nil, err
+		}
+		for _, period := range periods {
+			key := periodKey{row.UserID, period}
+			if _, ok := deltas[key]; !ok {
+				keys = append(keys, key)
+			}
+			deltas[key] += row.Points
+		}
+	}
+
+	var items []types.TransactWriteItem
+	for _, key := range keys {
+		if deltas[key] == 0 {
+			continue
+		}
+		items = append(items, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(s.periodActivityTable),
+				Key: map[string]types.AttributeValue{
+					"UserID": &types.AttributeValueMemberS{Value: key.userID},
+					"Period": &types.AttributeValueMemberS{Value: key.period},
+				},
+				UpdateExpression:         aws.String("ADD #points :points"),
+				ExpressionAttributeNames: map[string]string{"#points": "Points"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":points": &types.AttributeValueMemberN{Value: strconv.Itoa(deltas[key])},
+				},
+			},
+		})
+	}
+	return items, nil
+}
+
+// existingPointTotals is sortedPointTotals for the users that still exist;
+// activity outlives deleted users.
+func (s *DynamoDBStore) existingPointTotals(ctx context.Context, points map[string]int) ([]PointsTotal, error) {
+	keys := make([]map[string]types.AttributeValue, 0, len(points))
+	for userID := range points {
+		keys = append(keys, s.userKey(userID))
+	}
+	items, err := s.batchGetItems(ctx, s.usersTable, keys, "ID")
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]int, len(items))
+	for _, item := range items {
+		if id, ok := item["ID"].(*types.AttributeValueMemberS); ok {
+			existing[id.Value] = points[id.Value]
+		}
+	}
+	return sortedPointTotals(existing), nil
+}
+
+// backfillBoardAttribute tags users written before ScoreIndex existed so the
+// index covers them.
+func (s *DynamoDBStore) backfillBoardAttribute(ctx context.Context) error {
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:                aws.String(s.usersTable),
+		ProjectionExpression:     aws.String("ID"),
+		FilterExpression:         aws.String("attribute_not_exists(#board)"),
+		ExpressionAttributeNames: map[string]string{"#board": boardAttribute},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to scan users: %w", err)
+		}
+		for _, item := range page.Items {
+			_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName:                 aws.String(s.usersTable),
+				Key:                       map[string]types.AttributeValue{"ID": item["ID"]},
+				UpdateExpression:          aws.String("SET #board = :board, #score = if_not_exists(#score, :zero)"),
+				ExpressionAttributeNames:  map[string]string{"#board": boardAttribute, "#score": "Score"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{":board": boardValue(), ":zero": &types.AttributeValueMemberN{Value: "0"}},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to backfill user: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// backfillPeriodActivity rebuilds every PeriodActivity rollup from
+// DailyActivity. It overwrites rather than adds, so rerunning it is safe, but
+// daily writes made while it runs may be missed by their rollups.
+func (s *DynamoDBStore) backfillPeriodActivity(ctx context.Context) error {
+	type periodKey struct{ userID, period string }
+	totals := make(map[periodKey]int)
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:                aws.String(s.dailyActivityTable),
+		ProjectionExpression:     aws.String("UserID, #date, Points"),
+		ExpressionAttributeNames: map[string]string{"#date": "Date"},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to scan daily activity: %w", err)
+		}
+		var rows []models.DailyActivity
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &rows); err != nil {
+			return fmt.Errorf("failed to unmarshal daily activities: %w", err)
+		}
+		for _, row := range rows {
+			periods, err := activityPeriods(row.Date)
+			if err != nil {
+				return err
+			}
+			for _, period := range periods {
+				totals[periodKey{row.UserID, period}] += row.Points
+			}
+		}
+	}
+
+	for key, points := range totals {
+		item, err := attributevalue.MarshalMap(models.PeriodActivity{UserID: key.userID, Period: key.period, Points: points})
+		if err != nil {
+			return fmt.Errorf("failed to marshal period activity: %w", err)
+		}
+		_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(s.periodActivityTable),
+			Item:      item,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to backfill period activity: %w", err)
+		}
+	}
+	return nil
+}
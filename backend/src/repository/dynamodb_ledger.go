@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ledgerPut appends entry as part of a transaction. The condition keeps an
+// entry from ever being overwritten.
+func (s *DynamoDBStore) ledgerPut(entry models.LedgerEntry) (*types.Put, error) {
+	item, err := attributevalue.MarshalMap(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+	return &types.Put{
+		TableName:           aws.String(s.scoreLedgerTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(EntryID)"),
+	}, nil
+}
+
+func (s *DynamoDBStore) ListLedgerEntries(ctx context.Context, userID string, limit int, cursor string) ([]models.LedgerEntry, string, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.scoreLedgerTable),
+		KeyConditionExpression: aws.String("UserID = :uid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid": &types.AttributeValueMemberS{Value: userID},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(int32(limit)),
+	}
+	if cursor != "" {
+		entryID, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"UserID":  &types.AttributeValueMemberS{Value: userID},
+			"EntryID": &types.AttributeValueMemberS{Value: entryID},
+		}
+	}
+
+	result, err := s.client.Query(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query ledger entries: %w", err)
+	}
+	var entries []models.LedgerEntry
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &entries); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal ledger entries: %w", err)
+	}
+
+	next := ""
+	if last, ok := result.LastEvaluatedKey["EntryID"].(*types.AttributeValueMemberS); ok {
+		next = encodeCursor(last.Value)
+	}
+	return entries, next, nil
+}
@@ -0,0 +1,478 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// schemaVersionItemID is the key of the single item in the schema table that
+// records the applied version.
+const schemaVersionItemID = "devverse"
+
+type dynamoMigration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, s *DynamoDBStore) error
+}
+
+// dynamoMigrations must stay in version order and end at SchemaVersion.
+var dynamoMigrations = []dynamoMigration{
+	{
+		Version:     1,
+		Description: "create Users, Sessions and DailyActivity tables",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			if err := s.ensureTable(ctx, keyedTableInput(s.usersTable, "ID", "")); err != nil {
+				return err
+			}
+			if err := s.ensureTable(ctx, keyedTableInput(s.sessionsTable, "UserID", "SessionID")); err != nil {
+				return err
+			}
+			return s.ensureTable(ctx, keyedTableInput(s.dailyActivityTable, "UserID", "Date"))
+		},
+	},
+	{
+		Version:     2,
+		Description: "add ScoreIndex to Users for the leaderboard",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			err := s.ensureGlobalIndex(ctx, s.usersTable, types.GlobalSecondaryIndexUpdate{
+				Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName: aws.String(scoreIndexName),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String(boardAttribute), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("Score"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+			}, []types.AttributeDefinition{
+				{AttributeName: aws.String(boardAttribute), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("Score"), AttributeType: types.ScalarAttributeTypeN},
+			})
+			if err != nil {
+				return err
+			}
+			return s.backfillBoardAttribute(ctx)
+		},
+	},
+	{
+		Version:     3,
+		Description: "create IdempotencyKeys table with TTL on ExpiresAt",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			if err := s.ensureTable(ctx, keyedTableInput(s.idempotencyTable, "UserID", "Key")); err != nil {
+				return err
+			}
+			return s.ensureTTL(ctx, s.idempotencyTable, "ExpiresAt")
+		},
+	},
+	{
+		Version:     4,
+		Description: "create RefreshTokens and RevokedTokens tables with TTL on ExpiresAt",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			if err := s.ensureTable(ctx, keyedTableInput(s.refreshTokensTable, "TokenHash", "")); err != nil {
+				return err
+			}
+			if err := s.ensureTTL(ctx, s.refreshTokensTable, "ExpiresAt"); err != nil {
+				return err
+			}
+			if err := s.ensureTable(ctx, keyedTableInput(s.revokedTokensTable, "ID", "")); err != nil {
+				return err
+			}
+			return s.ensureTTL(ctx, s.revokedTokensTable, "ExpiresAt")
+		},
+	},
+	{
+		Version:     5,
+		Description: "add Roles and Banned to Users (attributes only, no table change)",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			return nil
+		},
+	},
+	{
+		Version:     6,
+		Description: "create PersonalAccessTokens table with UserIndex",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			if err := s.ensureTable(ctx, keyedTableInput(s.personalTokensTable, "TokenHash", "")); err != nil {
+				return err
+			}
+			return s.ensureGlobalIndex(ctx, s.personalTokensTable, types.GlobalSecondaryIndexUpdate{
+				Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName: aws.String(personalTokenUserIndex),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("UserID"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("ID"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+			}, []types.AttributeDefinition{
+				{AttributeName: aws.String("UserID"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("ID"), AttributeType: types.ScalarAttributeTypeS},
+			})
+		},
+	},
+	{
+		Version:     7,
+		Description: "add GithubLogin, AvatarURL, CreatedAt and LastSeenAt to Users (attributes only, no table change)",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			return nil
+		},
+	},
+	{
+		Version:     8,
+		Description: "create Identities table with UserIndex and UserMerges table",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			if err := s.ensureTable(ctx, keyedTableInput(s.identitiesTable, "ID", "")); err != nil {
+				return err
+			}
+			err := s.ensureGlobalIndex(ctx, s.identitiesTable, types.GlobalSecondaryIndexUpdate{
+				Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName: aws.String(identityUserIndex),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("UserID"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("ID"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+			}, []types.AttributeDefinition{
+				{AttributeName: aws.String("UserID"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("ID"), AttributeType: types.ScalarAttributeTypeS},
+			})
+			if err != nil {
+				return err
+			}
+			return s.ensureTable(ctx, keyedTableInput(s.userMergesTable, "SourceID", ""))
+		},
+	},
+	{
+		Version:     9,
+		Description: "create Streaks table",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			return s.ensureTable(ctx, keyedTableInput(s.streaksTable, "UserID", ""))
+		},
+	},
+	{
+		Version:     10,
+		Description: "add DateIndex to DailyActivity for windowed leaderboards",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			return s.ensureGlobalIndex(ctx, s.dailyActivityTable, types.GlobalSecondaryIndexUpdate{
+				Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName: aws.String(activityDateIndex),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("Date"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("UserID"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{
+						ProjectionType:   types.ProjectionTypeInclude,
+						NonKeyAttributes: []string{"Points"},
+					},
+				},
+			}, []types.AttributeDefinition{
+				{AttributeName: aws.String("Date"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("UserID"), AttributeType: types.ScalarAttributeTypeS},
+			})
+		},
+	},
+	{
+		Version:     11,
+		Description: "create LanguageActivity table with DateIndex",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			if err := s.ensureTable(ctx, keyedTableInput(s.languageActivityTable, "UserID", "DateLanguage")); err != nil {
+				return err
+			}
+			return s.ensureGlobalIndex(ctx, s.languageActivityTable, types.GlobalSecondaryIndexUpdate{
+				Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName: aws.String(activityDateIndex),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("Date"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("UserID"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{
+						ProjectionType:   types.ProjectionTypeInclude,
+						NonKeyAttributes: []string{"Language", "Points"},
+					},
+				},
+			}, []types.AttributeDefinition{
+				{AttributeName: aws.String("Date"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("UserID"), AttributeType: types.ScalarAttributeTypeS},
+			})
+		},
+	},
+	{
+		Version:     12,
+		Description: "add Timezone to Users and Date to Sessions (attributes only, no table change)",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			return nil
+		},
+	},
+	{
+		Version:     13,
+		Description: "add Days to Sessions (attributes only, no table change)",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			return nil
+		},
+	},
+	{
+		Version:     14,
+		Description: "add DeletedAt and Corrections to Sessions (attributes only, no table change)",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			return nil
+		},
+	},
+	{
+		Version:     15,
+		Description: "create ScoreLedger table",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			return s.ensureTable(ctx, keyedTableInput(s.scoreLedgerTable, "UserID", "EntryID"))
+		},
+	},
+	{
+		Version:     16,
+		Description: "add IssuedBefore to RevokedTokens (attributes only, no table change)",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			return nil
+		},
+	},
+	{
+		Version:     17,
+		Description: "add EndedAtIndex, StartedAtIndex and PointsIndex to Sessions and backfill FirstDate and LastDate",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			for _, sortBy := range []string{SessionSortEndedAt, SessionSortStartedAt, SessionSortPoints} {
+				index := sessionSortIndexes[sortBy]
+				err := s.ensureGlobalIndex(ctx, s.sessionsTable, types.GlobalSecondaryIndexUpdate{
+					Create: &types.CreateGlobalSecondaryIndexAction{
+						IndexName: aws.String(index.Name),
+						KeySchema: []types.KeySchemaElement{
+							{AttributeName: aws.String("UserID"), KeyType: types.KeyTypeHash},
+							{AttributeName: aws.String(index.Attribute), KeyType: types.KeyTypeRange},
+						},
+						Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+					},
+				}, []types.AttributeDefinition{
+					{AttributeName: aws.String("UserID"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String(index.Attribute), AttributeType: types.ScalarAttributeTypeN},
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return s.backfillSessionDates(ctx)
+		},
+	},
+	{
+		Version:     18,
+		Description: "create PeriodActivity table with PeriodIndex and backfill it from DailyActivity",
+		Up: func(ctx context.Context, s *DynamoDBStore) error {
+			if err := s.ensureTable(ctx, keyedTableInput(s.periodActivityTable, "UserID", "Period")); err != nil {
+				return err
+			}
+			err := s.ensureGlobalIndex(ctx, s.periodActivityTable, types.GlobalSecondaryIndexUpdate{
+				Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName: aws.String(periodIndex),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("Period"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("UserID"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{
+						ProjectionType:   types.ProjectionTypeInclude,
+						NonKeyAttributes: []string{"Points"},
+					},
+				},
+			}, []types.AttributeDefinition{
+				{AttributeName: aws.String("Period"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("UserID"), AttributeType: types.ScalarAttributeTypeS},
+			})
+			if err != nil {
+				return err
+			}
+			return s.backfillPeriodActivity(ctx)
+		},
+	},
+}
+
+// keyedTableInput builds an on-demand CreateTableInput with string keys.
+// rangeKey may be empty for hash-only tables.
+func keyedTableInput(name, hashKey, rangeKey string) *dynamodb.CreateTableInput {
+	input := &dynamodb.CreateTableInput{
+		TableName:   aws.String(name),
+		BillingMode: types.BillingModePayPerRequest,
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String(hashKey), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(hashKey), KeyType: types.KeyTypeHash},
+		},
+	}
+	if rangeKey != "" {
+		input.AttributeDefinitions = append(input.AttributeDefinitions,
+			types.AttributeDefinition{AttributeName: aws.String(rangeKey), AttributeType: types.ScalarAttributeTypeS})
+		input.KeySchema = append(input.KeySchema,
+			types.KeySchemaElement{AttributeName: aws.String(rangeKey), KeyType: types.KeyTypeRange})
+	}
+	return input
+}
+
+// ensureTable creates the table unless it already exists and waits for it to
+// become active.
+func (s *DynamoDBStore) ensureTable(ctx context.Context, input *dynamodb.CreateTableInput) error {
+	exists, err := s.tableExists(ctx, *input.TableName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err := s.client.CreateTable(ctx, input)
+		var inUse *types.ResourceInUseException
+		if err != nil && !errors.As(err, &inUse) {
+			return fmt.Errorf("failed to create table %s: %w", *input.TableName, err)
+		}
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(s.client)
+	err = waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: input.TableName}, 2*time.Minute)
+	if err != nil {
+		return fmt.Errorf("failed waiting for table %s: %w", *input.TableName, err)
+	}
+	return nil
+}
+
+// ensureGlobalIndex adds the index described by update.Create unless the table
+// already has an index with that name, then waits for it to become active.
+func (s *DynamoDBStore) ensureGlobalIndex(ctx context.Context, table string, update types.GlobalSecondaryIndexUpdate, attrs []types.AttributeDefinition) error {
+	name := *update.Create.IndexName
+	desc, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+	if err != nil {
+		return fmt.Errorf("failed to describe table %s: %w", table, err)
+	}
+
+	exists := false
+	for _, gsi := range desc.Table.GlobalSecondaryIndexes {
+		if aws.ToString(gsi.IndexName) == name {
+			exists = true
+		}
+	}
+	if !exists {
+		// Tables created with provisioned capacity (e.g. by the docker compose
+		// init script) need throughput on every new index.
+		if desc.Table.BillingModeSummary == nil || desc.Table.BillingModeSummary.BillingMode != types.BillingModePayPerRequest {
+			update.Create.ProvisionedThroughput = &types.ProvisionedThroughput{
+				ReadCapacityUnits:  aws.Int64(5),
+				WriteCapacityUnits: aws.Int64(5),
+			}
+		}
+		_, err := s.client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName:                   aws.String(table),
+			AttributeDefinitions:        attrs,
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{update},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create index %s on %s: %w", name, table, err)
+		}
+	}
+
+	deadline := time.Now().Add(10 * time.Minute)
+	for time.Now().Before(deadline) {
+		desc, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+		if err != nil {
+			return fmt.Errorf("failed to describe table %s: %w", table, err)
+		}
+		for _, gsi := range desc.Table.GlobalSecondaryIndexes {
+			if aws.ToString(gsi.IndexName) == name && gsi.IndexStatus == types.IndexStatusActive && !aws.ToBool(gsi.Backfilling) {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+	return fmt.Errorf("timed out waiting for index %s on %s", name, table)
+}
+
+// ensureTTL enables time-to-live on attribute unless it is already enabled.
+func (s *DynamoDBStore) ensureTTL(ctx context.Context, table, attribute string) error {
+	desc, err := s.client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(table)})
+	if err != nil {
+		return fmt.Errorf("failed to describe TTL on %s: %w", table, err)
+	}
+	if ttl := desc.TimeToLiveDescription; ttl != nil &&
+		(ttl.TimeToLiveStatus == types.TimeToLiveStatusEnabled || ttl.TimeToLiveStatus == types.TimeToLiveStatusEnabling) {
+		return nil
+	}
+	_, err = s.client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(table),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(attribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enable TTL on %s: %w", table, err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) AppliedSchemaVersion(ctx context.Context) (int, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.schemaTable),
+		Key:            map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: schemaVersionItemID}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		var resourceNotFound *types.ResourceNotFoundException
+		if errors.As(err, &resourceNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	v, ok := result.Item["Version"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(v.Value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q: %w", v.Value, err)
+	}
+	return version, nil
+}
+
+func (s *DynamoDBStore) recordSchemaVersion(ctx context.Context, version int) error {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.schemaTable),
+		Item: map[string]types.AttributeValue{
+			"ID":        &types.AttributeValueMemberS{Value: schemaVersionItemID},
+			"Version":   &types.AttributeValueMemberN{Value: strconv.Itoa(version)},
+			"AppliedAt": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) Migrate(ctx context.Context) error {
+	if err := s.ensureTable(ctx, keyedTableInput(s.schemaTable, "ID", "")); err != nil {
+		return err
+	}
+	current, err := s.AppliedSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range dynamoMigrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := m.Up(ctx, s); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		if err := s.recordSchemaVersion(ctx, m.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
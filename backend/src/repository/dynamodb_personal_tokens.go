@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// personalTokenUserIndex is the GSI on PersonalAccessTokens keyed by
+// (UserID, ID) used to list and revoke a user's tokens.
+const personalTokenUserIndex = "UserIndex"
+
+func personalTokenKey(tokenHash string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"TokenHash": &types.AttributeValueMemberS{Value: tokenHash}}
+}
+
+func (s *DynamoDBStore) CreatePersonalAccessToken(ctx context.Context, token models.PersonalAccessToken) error {
+	item, err := attributevalue.MarshalMap(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal personal access token: %w", err)
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.personalTokensTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(TokenHash)"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create personal access token: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) GetPersonalAccessToken(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.personalTokensTable),
+		Key:            personalTokenKey(tokenHash),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get personal access token: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var token models.PersonalAccessToken
+	if err := attributevalue.UnmarshalMap(result.Item, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal personal access token: %w", err)
+	}
+	return &token, nil
+}
+
+// ListPersonalAccessTokens reads UserIndex, which is eventually consistent,
+// so a token created a moment ago may be missing.
+func (s *DynamoDBStore) ListPersonalAccessTokens(ctx context.Context, userID string) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:              aws.String(s.personalTokensTable),
+		IndexName:              aws.String(personalTokenUserIndex),
+		KeyConditionExpression: aws.String("UserID = :userID"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userID": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query personal access tokens: %w", err)
+		}
+		var pageTokens []models.PersonalAccessToken
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageTokens); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal personal access tokens: %w", err)
+		}
+		tokens = append(tokens, pageTokens...)
+	}
+	return tokens, nil
+}
+
+func (s *DynamoDBStore) DeletePersonalAccessToken(ctx context.Context, userID, id string) (bool, error) {
+	tokens, err := s.ListPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, token := range tokens {
+		if token.ID != id {
+			continue
+		}
+		_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:           aws.String(s.personalTokensTable),
+			Key:                 personalTokenKey(token.TokenHash),
+			ConditionExpression: aws.String("UserID = :userID"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":userID": &types.AttributeValueMemberS{Value: userID},
+			},
+		})
+		if err != nil {
+			var ccf *types.ConditionalCheckFailedException
+			if errors.As(err, &ccf) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to delete personal access token: %w", err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *DynamoDBStore) TouchPersonalAccessToken(ctx context.Context, tokenHash string, usedAt int64) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.personalTokensTable),
+		Key:                 personalTokenKey(tokenHash),
+		UpdateExpression:    aws.String("SET LastUsedAt = :usedAt"),
+		ConditionExpression: aws.String("attribute_exists(TokenHash)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":usedAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(usedAt, 10)},
+		},
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return nil
+		}
+		return fmt.Errorf("failed to update personal access token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,543 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FirstDate and LastDate hold the first and last day a session has points
+// on, so that ListSessions can filter on them. They are not part of
+// models.Session.
+const (
+	sessionFirstDateAttribute = "FirstDate"
+	sessionLastDateAttribute  = "LastDate"
+)
+
+// sessionSortIndex is the Sessions index (PK: UserID, SK: Attribute) that
+// orders a user's sessions for one SessionQuery.SortBy.
+type sessionSortIndex struct {
+	Name      string
+	Attribute string
+}
+
+var sessionSortIndexes = map[string]sessionSortIndex{
+	SessionSortEndedAt:   {Name: "EndedAtIndex", Attribute: "EndedAt"},
+	SessionSortStartedAt: {Name: "StartedAtIndex", Attribute: "StartedAt"},
+	SessionSortPoints:    {Name: "PointsIndex", Attribute: "Points"},
+}
+
+// marshalSession marshals the session together with its FirstDate and
+// LastDate.
+func marshalSession(session models.Session) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if first, last := sessionDateRange(session); first != "" {
+		item[sessionFirstDateAttribute] = &types.AttributeValueMemberS{Value: first}
+		item[sessionLastDateAttribute] = &types.AttributeValueMemberS{Value: last}
+	}
+	return item, nil
+}
+
+// RecordSession writes the Sessions, Users, ScoreLedger, DailyActivity and
+// LanguageActivity items in one TransactWriteItems call. The conditional put on the session makes the whole
+// transaction a no-op for a replayed SessionID.
+func (s *DynamoDBStore) RecordSession(ctx context.Context, session models.Session, entry models.LedgerEntry) (bool, error) {
+	item, err := marshalSession(session)
+	if err != nil {
+		return false, err
+	}
+	put, err := s.ledgerPut(ledgerEntry(entry, session.UserID, session.Points))
+	if err != nil {
+		return false, err
+	}
+
+	items := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:           aws.String(s.sessionsTable),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(SessionID)"),
+			},
+		},
+		{
+			Update: &types.Update{
+				TableName:        aws.String(s.usersTable),
+				Key:              s.userKey(session.UserID),
+				UpdateExpression: aws.String("ADD #score :points SET #board = :board"),
+				ExpressionAttributeNames: map[string]string{
+					"#score": "Score",
+					"#board": boardAttribute,
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":points": &types.AttributeValueMemberN{Value: strconv.Itoa(session.Points)},
+					":board":  boardValue(),
+				},
+			},
+		},
+		{Put: put},
+	}
+	rows := make([]models.DailyActivity, len(session.Days))
+	for i, day := range session.Days {
+		rows[i] = models.DailyActivity{UserID: session.UserID, Date: day.Date, Points: day.Points}
+		if i == 0 {
+			rows[i].SessionCount = 1
+		}
+	}
+	activity, err := s.dailyActivityItems(rows)
+	if err != nil {
+		return false, err
+	}
+	items = append(items, activity...)
+	for _, row := range languageActivityRows(session.UserID, session.Days) {
+		items = append(items, types.TransactWriteItem{Update: s.languageActivityUpdate(row)})
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		if isConditionFailure(err, 0) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to record session: %w", err)
+	}
+	return true, nil
+}
+
+// dailyActivityUpdate adds points and sessions to the user's DailyActivity
+// row for date, creating it if needed.
+func (s *DynamoDBStore) dailyActivityUpdate(userID, date string, points, sessions int) *types.Update {
+	return &types.Update{
+		TableName: aws.String(s.dailyActivityTable),
+		Key: map[string]types.AttributeValue{
+			"UserID": &types.AttributeValueMemberS{Value: userID},
+			"Date":   &types.AttributeValueMemberS{Value: date},
+		},
+		UpdateExpression: aws.String("ADD #points :points, #sessionCount :sessions"),
+		ExpressionAttributeNames: map[string]string{
+			"#points":       "Points",
+			"#sessionCount": "SessionCount",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":points":   &types.AttributeValueMemberN{Value: strconv.Itoa(points)},
+			":sessions": &types.AttributeValueMemberN{Value: strconv.Itoa(sessions)},
+		},
+	}
+}
+
+// dailyActivityItems adds each row to DailyActivity and its points to the
+// PeriodActivity rollups.
+func (s *DynamoDBStore) dailyActivityItems(rows []models.DailyActivity) ([]types.TransactWriteItem, error) {
+	items := make([]types.TransactWriteItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, types.TransactWriteItem{
+			Update: s.dailyActivityUpdate(row.UserID, row.Date, row.Points, row.SessionCount),
+		})
+	}
+	periods, err := s.periodActivityUpdates(rows)
+	if err != nil {
+		return nil, err
+	}
+	return append(items, periods...), nil
+}
+
+// isConditionFailure reports whether err is a cancelled transaction whose
+// item at index failed its condition check.
+func isConditionFailure(err error, index int) bool {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) || index >= len(canceled.CancellationReasons) {
+		return false
+	}
+	return aws.ToString(canceled.CancellationReasons[index].Code) == "ConditionalCheckFailed"
+}
+
+func (s *DynamoDBStore) ListUserSessions(ctx context.Context, userID string, limit int) ([]models.Session, error) {
+	var sessions []models.Session
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:              aws.String(s.sessionsTable),
+		KeyConditionExpression: aws.String("UserID = :uid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid": &types.AttributeValueMemberS{Value: userID},
+		},
+		Limit: aws.Int32(int32(limit)),
+	})
+	for paginator.HasMorePages() && len(sessions) < limit {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query sessions: %w", err)
+		}
+		var pageSessions []models.Session
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageSessions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sessions: %w", err)
+		}
+		sessions = append(sessions, pageSessions...)
+	}
+	if len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions, nil
+}
+
+// ListSessions queries the index for the sort order and filters the rest in
+// DynamoDB, reading on until one session past the page is found.
+func (s *DynamoDBStore) ListSessions(ctx context.Context, userID string, query SessionQuery) ([]models.Session, string, error) {
+	index, ok := sessionSortIndexes[query.SortBy]
+	if !ok {
+		index = sessionSortIndexes[SessionSortEndedAt]
+	}
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.sessionsTable),
+		IndexName:              aws.String(index.Name),
+		KeyConditionExpression: aws.String("UserID = :uid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid": &types.AttributeValueMemberS{Value: userID},
+		},
+		ScanIndexForward: aws.Bool(!query.Descending),
+		Limit:            aws.Int32(int32(query.Limit + 1)),
+	}
+
+	var filters []string
+	names := map[string]string{}
+	if !query.Deleted {
+		filters = append(filters, "attribute_not_exists(#deletedAt)")
+		names["#deletedAt"] = "DeletedAt"
+	}
+	if query.From != "" || query.To != "" {
+		from, to := sessionDateBounds(query)
+		filters = append(filters, "#lastDate >= :from AND #firstDate <= :to")
+		names["#firstDate"], names["#lastDate"] = sessionFirstDateAttribute, sessionLastDateAttribute
+		input.ExpressionAttributeValues[":from"] = &types.AttributeValueMemberS{Value: from}
+		input.ExpressionAttributeValues[":to"] = &types.AttributeValueMemberS{Value: to}
+	}
+	if query.Language != "" {
+		filters = append(filters, "#breakdown.#language > :zero")
+		names["#breakdown"], names["#language"] = "LanguageBreakdown", query.Language
+		input.ExpressionAttributeValues[":zero"] = &types.AttributeValueMemberN{Value: "0"}
+	}
+	if len(filters) > 0 {
+		input.FilterExpression = aws.String(strings.Join(filters, " AND "))
+		input.ExpressionAttributeNames = names
+	}
+	if query.Cursor != "" {
+		c, err := decodeSessionCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"UserID":        &types.AttributeValueMemberS{Value: userID},
+			"SessionID":     &types.AttributeValueMemberS{Value: c.SessionID},
+			index.Attribute: &types.AttributeValueMemberN{Value: strconv.FormatInt(c.Value, 10)},
+		}
+	}
+
+	var sessions []models.Session
+	paginator := dynamodb.NewQueryPaginator(s.client, input)
+	for paginator.HasMorePages() && len(sessions) <= query.Limit {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to query sessions: %w", err)
+		}
+		var pageSessions []models.Session
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageSessions); err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal sessions: %w", err)
+		}
+		sessions = append(sessions, pageSessions...)
+	}
+	if len(sessions) > query.Limit {
+		sessions = sessions[:query.Limit]
+		last := sessions[query.Limit-1]
+		return sessions, encodeSessionCursor(sessionCursor{Value: sessionSortValue(last, query.SortBy), SessionID: last.SessionID}), nil
+	}
+	return sessions, "", nil
+}
+
+// backfillSessionDates sets FirstDate and LastDate on sessions written before
+// ListSessions filtered on them.
+func (s *DynamoDBStore) backfillSessionDates(ctx context.Context) error {
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:                aws.String(s.sessionsTable),
+		FilterExpression:         aws.String("attribute_not_exists(#lastDate)"),
+		ExpressionAttributeNames: map[string]string{"#lastDate": sessionLastDateAttribute},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to scan sessions: %w", err)
+		}
+		for _, item := range page.Items {
+			var session models.Session
+			if err := attributevalue.UnmarshalMap(item, &session); err != nil {
+				return fmt.Errorf("failed to unmarshal session: %w", err)
+			}
+			first, last := sessionDateRange(session)
+			if first == "" {
+				continue
+			}
+			_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName: aws.String(s.sessionsTable),
+				Key: map[string]types.AttributeValue{
+					"UserID":    item["UserID"],
+					"SessionID": item["SessionID"],
+				},
+				UpdateExpression: aws.String("SET #firstDate = :first, #lastDate = :last"),
+				ExpressionAttributeNames: map[string]string{
+					"#firstDate": sessionFirstDateAttribute,
+					"#lastDate":  sessionLastDateAttribute,
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":first": &types.AttributeValueMemberS{Value: first},
+					":last":  &types.AttributeValueMemberS{Value: last},
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to backfill session: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) GetSession(ctx context.Context, userID, sessionID string) (*models.Session, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.sessionsTable),
+		Key: map[string]types.AttributeValue{
+			"UserID":    &types.AttributeValueMemberS{Value: userID},
+			"SessionID": &types.AttributeValueMemberS{Value: sessionID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+	var session models.Session
+	if err := attributevalue.UnmarshalMap(result.Item, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	return &session, nil
+}
+
+// RedateSession checks the session's missing Date and the source row's points
+// as conditions of a single TransactWriteItems call.
+func (s *DynamoDBStore) RedateSession(ctx context.Context, session models.Session, from, to string) (bool, error) {
+	points := &types.AttributeValueMemberN{Value: strconv.Itoa(session.Points)}
+	dated := session
+	dated.Date = to
+	first, last := sessionDateRange(dated)
+	items := []types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName: aws.String(s.sessionsTable),
+				Key: map[string]types.AttributeValue{
+					"UserID":    &types.AttributeValueMemberS{Value: session.UserID},
+					"SessionID": &types.AttributeValueMemberS{Value: session.SessionID},
+				},
+				UpdateExpression:    aws.String("SET #date = :to, #firstDate = :first, #lastDate = :last"),
+				ConditionExpression: aws.String("attribute_exists(SessionID) AND attribute_not_exists(#date) AND attribute_not_exists(#deletedAt)"),
+				ExpressionAttributeNames: map[string]string{
+					"#date":      "Date",
+					"#deletedAt": "DeletedAt",
+					"#firstDate": sessionFirstDateAttribute,
+					"#lastDate":  sessionLastDateAttribute,
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":to":    &types.AttributeValueMemberS{Value: to},
+					":first": &types.AttributeValueMemberS{Value: first},
+					":last":  &types.AttributeValueMemberS{Value: last},
+				},
+			},
+		},
+	}
+	if from != to {
+		items = append(items,
+			types.TransactWriteItem{
+				Update: &types.Update{
+					TableName: aws.String(s.dailyActivityTable),
+					Key: map[string]types.AttributeValue{
+						"UserID": &types.AttributeValueMemberS{Value: session.UserID},
+						"Date":   &types.AttributeValueMemberS{Value: from},
+					},
+					UpdateExpression:    aws.String("ADD #points :negPoints, #sessionCount :negOne"),
+					ConditionExpression: aws.String("#points >= :points AND #sessionCount >= :one"),
+					ExpressionAttributeNames: map[string]string{
+						"#points":       "Points",
+						"#sessionCount": "SessionCount",
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":points":    points,
+						":negPoints": &types.AttributeValueMemberN{Value: strconv.Itoa(-session.Points)},
+						":one":       &types.AttributeValueMemberN{Value: "1"},
+						":negOne":    &types.AttributeValueMemberN{Value: "-1"},
+					},
+				},
+			},
+			types.TransactWriteItem{
+				Update: &types.Update{
+					TableName: aws.String(s.dailyActivityTable),
+					Key: map[string]types.AttributeValue{
+						"UserID": &types.AttributeValueMemberS{Value: session.UserID},
+						"Date":   &types.AttributeValueMemberS{Value: to},
+					},
+					UpdateExpression: aws.String("ADD #points :points, #sessionCount :one"),
+					ExpressionAttributeNames: map[string]string{
+						"#points":       "Points",
+						"#sessionCount": "SessionCount",
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":points": points,
+						":one":    &types.AttributeValueMemberN{Value: "1"},
+					},
+				},
+			},
+		)
+		periods, err := s.periodActivityUpdates([]models.DailyActivity{
+			{UserID: session.UserID, Date: from, Points: -session.Points},
+			{UserID: session.UserID, Date: to, Points: session.Points},
+		})
+		if err != nil {
+			return false, err
+		}
+		items = append(items, periods...)
+
+		languages, err := s.redateLanguageUpdates(ctx, session, from, to)
+		if err != nil {
+			return false, err
+		}
+		items = append(items, languages...)
+	}
+
+	_, err := s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		for i := range items {
+			if isConditionFailure(err, i) {
+				return false, nil
+			}
+		}
+		return false, fmt.Errorf("failed to redate session: %w", err)
+	}
+	return true, nil
+}
+
+// redateLanguageUpdates reads the session's LanguageActivity rows for from
+// and returns the updates that move them to to, each conditioned on the row
+// for from still holding the points read.
+func (s *DynamoDBStore) redateLanguageUpdates(ctx context.Context, session models.Session, from, to string) ([]types.TransactWriteItem, error) {
+	var keys []map[string]types.AttributeValue
+	for language := range session.LanguageBreakdown {
+		keys = append(keys, languageActivityKey(session.UserID, from+"#"+language))
+	}
+	found, err := s.batchGetItems(ctx, s.languageActivityTable, keys, "")
+	if err != nil {
+		return nil, err
+	}
+	var rows []models.LanguageActivity
+	if err := attributevalue.UnmarshalListOfMaps(found, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal language activity: %w", err)
+	}
+	held := make(map[string]int)
+	for _, row := range rows {
+		held[row.Language] = row.Points
+	}
+
+	var items []types.TransactWriteItem
+	for _, row := range redateLanguageRows(session, from, to, held) {
+		update := s.languageActivityUpdate(row)
+		if row.Date == from {
+			update.ConditionExpression = aws.String("Points >= :held")
+			update.ExpressionAttributeValues[":held"] = &types.AttributeValueMemberN{Value: strconv.Itoa(-row.Points)}
+		}
+		items = append(items, types.TransactWriteItem{Update: update})
+	}
+	return items, nil
+}
+
+// CorrectSession puts corrected on the condition that the stored session
+// still has old's Points and Date and is not deleted, together with the
+// score and rollup deltas, in one TransactWriteItems call.
+func (s *DynamoDBStore) CorrectSession(ctx context.Context, old, corrected models.Session, entry models.LedgerEntry) (bool, error) {
+	item, err := marshalSession(corrected)
+	if err != nil {
+		return false, err
+	}
+
+	condition := "#points = :oldPoints AND attribute_not_exists(#deletedAt) AND "
+	values := map[string]types.AttributeValue{
+		":oldPoints": &types.AttributeValueMemberN{Value: strconv.Itoa(old.Points)},
+	}
+	if old.Date == "" {
+		condition += "attribute_not_exists(#date)"
+	} else {
+		condition += "#date = :oldDate"
+		values[":oldDate"] = &types.AttributeValueMemberS{Value: old.Date}
+	}
+
+	correction := correctionDeltas(old, corrected)
+	put, err := s.ledgerPut(ledgerEntry(entry, old.UserID, correction.Points))
+	if err != nil {
+		return false, err
+	}
+	items := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:           aws.String(s.sessionsTable),
+				Item:                item,
+				ConditionExpression: aws.String(condition),
+				ExpressionAttributeNames: map[string]string{
+					"#points":    "Points",
+					"#deletedAt": "DeletedAt",
+					"#date":      "Date",
+				},
+				ExpressionAttributeValues: values,
+			},
+		},
+		{
+			Update: &types.Update{
+				TableName:        aws.String(s.usersTable),
+				Key:              s.userKey(old.UserID),
+				UpdateExpression: aws.String("ADD #score :points SET #board = :board"),
+				ExpressionAttributeNames: map[string]string{
+					"#score": "Score",
+					"#board": boardAttribute,
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":points": &types.AttributeValueMemberN{Value: strconv.Itoa(correction.Points)},
+					":board":  boardValue(),
+				},
+			},
+		},
+		{Put: put},
+	}
+	activity, err := s.dailyActivityItems(correction.Activity)
+	if err != nil {
+		return false, err
+	}
+	items = append(items, activity...)
+	for _, row := range correction.Languages {
+		items = append(items, types.TransactWriteItem{Update: s.languageActivityUpdate(row)})
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		if isConditionFailure(err, 0) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to correct session: %w", err)
+	}
+	return true, nil
+}
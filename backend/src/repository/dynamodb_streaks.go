@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func (s *DynamoDBStore) GetStreak(ctx context.Context, userID string) (*models.Streak, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.streaksTable),
+		Key: map[string]types.AttributeValue{
+			"UserID": &types.AttributeValueMemberS{Value: userID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get streak: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var streak models.Streak
+	if err := attributevalue.UnmarshalMap(result.Item, &streak); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal streak: %w", err)
+	}
+	return &streak, nil
+}
+
+func (s *DynamoDBStore) GetStreaks(ctx context.Context, userIDs []string) ([]models.Streak, error) {
+	keys := make([]map[string]types.AttributeValue, len(userIDs))
+	for i, id := range userIDs {
+		keys[i] = map[string]types.AttributeValue{
+			"UserID": &types.AttributeValueMemberS{Value: id},
+		}
+	}
+	items, err := s.batchGetItems(ctx, s.streaksTable, keys, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var streaks []models.Streak
+	if err := attributevalue.UnmarshalListOfMaps(items, &streaks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal streaks: %w", err)
+	}
+	return streaks, nil
+}
+
+func (s *DynamoDBStore) SaveStreak(ctx context.Context, streak models.Streak, version int) (bool, error) {
+	streak.Version = version + 1
+	item, err := attributevalue.MarshalMap(streak)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal streak: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(s.streaksTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(UserID)"),
+	}
+	if version > 0 {
+		input.ConditionExpression = aws.String("Version = :version")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: strconv.Itoa(version)},
+		}
+	}
+	if _, err := s.client.PutItem(ctx, input); err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to save streak: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func refreshTokenKey(tokenHash string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"TokenHash": &types.AttributeValueMemberS{Value: tokenHash}}
+}
+
+func (s *DynamoDBStore) CreateRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	item, err := attributevalue.MarshalMap(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal refresh token: %w", err)
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.refreshTokensTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.refreshTokensTable),
+		Key:            refreshTokenKey(tokenHash),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var token models.RefreshToken
+	if err := attributevalue.UnmarshalMap(result.Item, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken marks the old token used and stores the new one in one
+// TransactWriteItems call; the condition on UsedAt makes concurrent rotations
+// of the same token fail all but one.
+func (s *DynamoDBStore) RotateRefreshToken(ctx context.Context, oldHash string, next models.RefreshToken, now int64) (bool, error) {
+	item, err := attributevalue.MarshalMap(next)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal refresh token: %w", err)
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName:           aws.String(s.refreshTokensTable),
+					Key:                 refreshTokenKey(oldHash),
+					UpdateExpression:    aws.String("SET #usedAt = :now"),
+					ConditionExpression: aws.String("attribute_exists(TokenHash) AND #usedAt = :zero"),
+					ExpressionAttributeNames: map[string]string{
+						"#usedAt": "UsedAt",
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":now":  &types.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)},
+						":zero": &types.AttributeValueMemberN{Value: "0"},
+					},
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName:           aws.String(s.refreshTokensTable),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(TokenHash)"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailure(err, 0) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	return true, nil
+}
+
+func (s *DynamoDBStore) RevokeToken(ctx context.Context, revoked models.RevokedToken) error {
+	item, err := attributevalue.MarshalMap(revoked)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revoked token: %w", err)
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.revokedTokensTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) IsTokenRevoked(ctx context.Context, now, issuedAt int64, ids ...string) (bool, error) {
+	for _, id := range ids {
+		result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(s.revokedTokensTable),
+			Key:            map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}},
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if result.Item == nil {
+			continue
+		}
+
+		var revoked models.RevokedToken
+		if err := attributevalue.UnmarshalMap(result.Item, &revoked); err != nil {
+			return false, fmt.Errorf("failed to unmarshal revoked token: %w", err)
+		}
+		// TTL deletion is lazy, so an expired record may still be present.
+		if revokes(revoked, now, issuedAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
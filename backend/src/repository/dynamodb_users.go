@@ -0,0 +1,404 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func (s *DynamoDBStore) userKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"ID": &types.AttributeValueMemberS{Value: id},
+	}
+}
+
+func (s *DynamoDBStore) ListUsers(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName: aws.String(s.usersTable),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan users: %w", err)
+		}
+		var pageUsers []models.User
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageUsers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal users: %w", err)
+		}
+		users = append(users, pageUsers...)
+	}
+	return users, nil
+}
+
+func (s *DynamoDBStore) ListUsersPage(ctx context.Context, limit int, cursor string) ([]models.User, string, error) {
+	var startKey map[string]types.AttributeValue
+	if cursor != "" {
+		id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		startKey = s.userKey(id)
+	}
+
+	// Read one extra user to learn whether another page exists; a Scan can
+	// stop short of its Limit, and returns a LastEvaluatedKey even when
+	// nothing follows it, so keep going until the page is full or the table
+	// is exhausted.
+	var users []models.User
+	for len(users) <= limit {
+		result, err := s.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String(s.usersTable),
+			Limit:             aws.Int32(int32(limit + 1 - len(users))),
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan users: %w", err)
+		}
+		var pageUsers []models.User
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &pageUsers); err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal users: %w", err)
+		}
+		users = append(users, pageUsers...)
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		startKey = result.LastEvaluatedKey
+	}
+
+	if len(users) > limit {
+		users = users[:limit]
+		return users, encodeCursor(users[limit-1].ID), nil
+	}
+	return users, "", nil
+}
+
+func (s *DynamoDBStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.usersTable),
+		Key:       s.userKey(id),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var user models.User
+	if err := attributevalue.UnmarshalMap(result.Item, &user); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user: %w", err)
+	}
+	return &user, nil
+}
+
+func (s *DynamoDBStore) GetUsers(ctx context.Context, ids []string) ([]models.User, error) {
+	keys := make([]map[string]types.AttributeValue, len(ids))
+	for i, id := range ids {
+		keys[i] = s.userKey(id)
+	}
+	items, err := s.batchGetItems(ctx, s.usersTable, keys, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	if err := attributevalue.UnmarshalListOfMaps(items, &users); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal users: %w", err)
+	}
+	return users, nil
+}
+
+func (s *DynamoDBStore) PutUser(ctx context.Context, user models.User) error {
+	item, err := attributevalue.MarshalMap(user)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user: %w", err)
+	}
+	item[boardAttribute] = boardValue()
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.usersTable),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put user: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) CreateUser(ctx context.Context, user models.User) (bool, error) {
+	item, err := attributevalue.MarshalMap(user)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal user: %w", err)
+	}
+	item[boardAttribute] = boardValue()
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.usersTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(ID)"),
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create user: %w", err)
+	}
+	return true, nil
+}
+
+func (s *DynamoDBStore) UpdateUserProfile(ctx context.Context, id, name, email string) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(s.usersTable),
+		Key:              s.userKey(id),
+		UpdateExpression: aws.String("SET #name = :name, #email = :email, #board = :board, #score = if_not_exists(#score, :zero)"),
+		ExpressionAttributeNames: map[string]string{
+			"#name":  "Name",
+			"#email": "Email",
+			"#board": boardAttribute,
+			"#score": "Score",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":name":  &types.AttributeValueMemberS{Value: name},
+			":email": &types.AttributeValueMemberS{Value: email},
+			":board": boardValue(),
+			":zero":  &types.AttributeValueMemberN{Value: "0"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) UpdateUserIdentity(ctx context.Context, id, githubLogin, avatarURL string, seenAt int64) error {
+	update := "SET #avatarURL = :avatarURL, #lastSeenAt = :seenAt, #createdAt = if_not_exists(#createdAt, :seenAt)"
+	names := map[string]string{
+		"#avatarURL":  "AvatarURL",
+		"#lastSeenAt": "LastSeenAt",
+		"#createdAt":  "CreatedAt",
+	}
+	values := map[string]types.AttributeValue{
+		":avatarURL": &types.AttributeValueMemberS{Value: avatarURL},
+		":seenAt":    &types.AttributeValueMemberN{Value: strconv.FormatInt(seenAt, 10)},
+	}
+	if githubLogin != "" {
+		update += ", #githubLogin = :githubLogin"
+		names["#githubLogin"] = "GithubLogin"
+		values[":githubLogin"] = &types.AttributeValueMemberS{Value: githubLogin}
+	}
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.usersTable),
+		Key:                       s.userKey(id),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(ID)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &ccf) {
+		return fmt.Errorf("failed to update user identity: %w", err)
+	}
+	return nil
+}
+
+// maxSetScoreAttempts bounds the retries of SetUserScore when the score
+// changes between reading it and writing the new one.
+const maxSetScoreAttempts = 5
+
+// errScoreContention is returned when SetUserScore keeps racing other
+// changes to the same score.
+var errScoreContention = errors.New("score is being updated concurrently")
+
+// SetUserScore reads the current score to record the change in the ledger
+// and writes the new one only if it is still the score that was read.
+func (s *DynamoDBStore) SetUserScore(ctx context.Context, id string, score int, entry models.LedgerEntry) error {
+	for attempt := 0; attempt < maxSetScoreAttempts; attempt++ {
+		result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(s.usersTable),
+			Key:            s.userKey(id),
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		var user models.User
+		if err := attributevalue.UnmarshalMap(result.Item, &user); err != nil {
+			return fmt.Errorf("failed to unmarshal user: %w", err)
+		}
+		put, err := s.ledgerPut(ledgerEntry(entry, id, score-user.Score))
+		if err != nil {
+			return err
+		}
+
+		_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{
+					Update: &types.Update{
+						TableName:           aws.String(s.usersTable),
+						Key:                 s.userKey(id),
+						UpdateExpression:    aws.String("SET #score = :score, #board = :board"),
+						ConditionExpression: aws.String("attribute_not_exists(#score) OR #score = :old"),
+						ExpressionAttributeNames: map[string]string{
+							"#score": "Score",
+							"#board": boardAttribute,
+						},
+						ExpressionAttributeValues: map[string]types.AttributeValue{
+							":score": &types.AttributeValueMemberN{Value: strconv.Itoa(score)},
+							":old":   &types.AttributeValueMemberN{Value: strconv.Itoa(user.Score)},
+							":board": boardValue(),
+						},
+					},
+				},
+				{Put: put},
+			},
+		})
+		if err == nil {
+			return nil
+		}
+		if !isConditionFailure(err, 0) {
+			return fmt.Errorf("failed to update user score: %w", err)
+		}
+	}
+	return fmt.Errorf("failed to update user score: %w", errScoreContention)
+}
+
+func (s *DynamoDBStore) AddUserScore(ctx context.Context, id string, increment int, date string, entry models.LedgerEntry) error {
+	put, err := s.ledgerPut(ledgerEntry(entry, id, increment))
+	if err != nil {
+		return err
+	}
+	activity, err := s.dailyActivityItems([]models.DailyActivity{{UserID: id, Date: date, Points: increment}})
+	if err != nil {
+		return err
+	}
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: append([]types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName:        aws.String(s.usersTable),
+					Key:              s.userKey(id),
+					UpdateExpression: aws.String("ADD #score :increment SET #board = :board"),
+					ExpressionAttributeNames: map[string]string{
+						"#score": "Score",
+						"#board": boardAttribute,
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":increment": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", increment)},
+						":board":     boardValue(),
+					},
+				},
+			},
+			{Put: put},
+		}, activity...),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add user score: %w", err)
+	}
+	return nil
+}
+
+// updateExistingUser applies update to the user's item unless it does not
+// exist, so that changing a missing user never creates a partial row.
+func (s *DynamoDBStore) updateExistingUser(ctx context.Context, id, update string, names map[string]string, values map[string]types.AttributeValue) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.usersTable),
+		Key:                       s.userKey(id),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(ID)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil
+	}
+	return err
+}
+
+func (s *DynamoDBStore) SetUserRoles(ctx context.Context, id string, roles []string) error {
+	if roles == nil {
+		roles = []string{}
+	}
+	value, err := attributevalue.Marshal(roles)
+	if err != nil {
+		return fmt.Errorf("failed to marshal roles: %w", err)
+	}
+	err = s.updateExistingUser(ctx, id, "SET #roles = :roles",
+		map[string]string{"#roles": "Roles"},
+		map[string]types.AttributeValue{":roles": value})
+	if err != nil {
+		return fmt.Errorf("failed to set user roles: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) SetUserBanned(ctx context.Context, id string, banned bool) error {
+	err := s.updateExistingUser(ctx, id, "SET #banned = :banned",
+		map[string]string{"#banned": "Banned"},
+		map[string]types.AttributeValue{":banned": &types.AttributeValueMemberBOOL{Value: banned}})
+	if err != nil {
+		return fmt.Errorf("failed to set user banned: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) TouchUserLastSeen(ctx context.Context, id string, seenAt int64) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.usersTable),
+		Key:                 s.userKey(id),
+		UpdateExpression:    aws.String("SET LastSeenAt = :seenAt"),
+		ConditionExpression: aws.String("attribute_exists(ID) AND (attribute_not_exists(LastSeenAt) OR LastSeenAt < :seenAt)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":seenAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(seenAt, 10)},
+		},
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &ccf) {
+		return fmt.Errorf("failed to update user last seen: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) SetUserTimezone(ctx context.Context, id, timezone string) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.usersTable),
+		Key:                 s.userKey(id),
+		UpdateExpression:    aws.String("REMOVE #timezone"),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeNames: map[string]string{
+			"#timezone": "Timezone",
+		},
+	}
+	if timezone != "" {
+		input.UpdateExpression = aws.String("SET #timezone = :timezone")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":timezone": &types.AttributeValueMemberS{Value: timezone},
+		}
+	}
+	_, err := s.client.UpdateItem(ctx, input)
+	var ccf *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &ccf) {
+		return fmt.Errorf("failed to update user timezone: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) DeleteUser(ctx context.Context, id string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.usersTable),
+		Key:       s.userKey(id),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
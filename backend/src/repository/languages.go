@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// languageActivityRows returns the LanguageActivity increments for a
+// session's days: one row per day and language, and one all-time row per
+// language. Languages without positive points are skipped.
+func languageActivityRows(userID string, days []models.SessionDay) []models.LanguageActivity {
+	var rows []models.LanguageActivity
+	totals := make(map[string]int)
+	for _, day := range days {
+		for language, points := range day.Languages {
+			if points <= 0 {
+				continue
+			}
+			rows = append(rows, languageActivityRow(userID, day.Date, language, points))
+			totals[language] += points
+		}
+	}
+	for language, points := range totals {
+		rows = append(rows, languageActivityRow(userID, models.LanguageTotalDate, language, points))
+	}
+	return rows
+}
+
+func languageActivityRow(userID, date, language string, points int) models.LanguageActivity {
+	return models.LanguageActivity{
+		UserID:       userID,
+		DateLanguage: date + "#" + language,
+		Date:         date,
+		Language:     language,
+		Points:       points,
+	}
+}
+
+// redateLanguageRows returns the LanguageActivity deltas that move an
+// undated session's languages from the rows for from to the rows for to.
+// held is what each language's row for from holds; languages it holds less
+// of than the session, as for sessions recorded before the rollups existed,
+// are left where they are.
+func redateLanguageRows(session models.Session, from, to string, held map[string]int) []models.LanguageActivity {
+	var rows []models.LanguageActivity
+	for language, points := range session.LanguageBreakdown {
+		if points <= 0 || held[language] < points {
+			continue
+		}
+		rows = append(rows,
+			languageActivityRow(session.UserID, from, language, -points),
+			languageActivityRow(session.UserID, to, language, points))
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].DateLanguage < rows[j].DateLanguage })
+	return rows
+}
+
+// languageDateRange turns a LanguageRepository range into the dates to
+// read; an empty from selects the all-time rows.
+func languageDateRange(from, to string) (string, string) {
+	if from == "" {
+		return models.LanguageTotalDate, models.LanguageTotalDate
+	}
+	return from, to
+}
+
+// sortedLanguageTotals orders per-language sums as LanguageRepository
+// returns them, dropping languages without points.
+func sortedLanguageTotals(points map[string]int) []LanguageTotal {
+	totals := make([]LanguageTotal, 0, len(points))
+	for language, p := range points {
+		if p > 0 {
+			totals = append(totals, LanguageTotal{Language: language, Points: p})
+		}
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Points != totals[j].Points {
+			return totals[i].Points > totals[j].Points
+		}
+		return totals[i].Language < totals[j].Language
+	})
+	return totals
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// sortedPointTotals turns per-user point sums into the order ListPointTotals
+// returns, dropping users without points.
+func sortedPointTotals(points map[string]int) []PointsTotal {
+	totals := make([]PointsTotal, 0, len(points))
+	for userID, p := range points {
+		if p > 0 {
+			totals = append(totals, PointsTotal{UserID: userID, Points: p})
+		}
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Points != totals[j].Points {
+			return totals[i].Points > totals[j].Points
+		}
+		return totals[i].UserID < totals[j].UserID
+	})
+	return totals
+}
+
+// Rollup periods nest: a year holds its months, and a month holds its weeks
+// (days 1-7, 8-14, 15-21, 22-28 and 29 to the end of the month), so any
+// range of days splits into whole periods plus at most a few loose days.
+func yearPeriod(day time.Time) string  { return day.Format("Y2006") }
+func monthPeriod(day time.Time) string { return day.Format("M2006-01") }
+func weekPeriod(day time.Time) string {
+	return fmt.Sprintf("W%s-%d", day.Format("2006-01"), (day.Day()-1)/7+1)
+}
+
+// activityPeriods returns the rollup periods that date ("YYYY-MM-DD") counts
+// towards.
+func activityPeriods(date string) ([]string, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid activity date %q: %w", date, err)
+	}
+	return []string{yearPeriod(day), monthPeriod(day), weekPeriod(day)}, nil
+}
+
+// windowPeriods splits the days from..to into the fewest rollup periods that
+// fit wholly inside it and the leftover days ("YYYY-MM-DD").
+func windowPeriods(from, to time.Time) (periods, days []string) {
+	for day := from; !day.After(to); {
+		monthEnd := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+		weekEnd := day.AddDate(0, 0, 6)
+		if weekEnd.After(monthEnd) {
+			weekEnd = monthEnd
+		}
+		switch {
+		case day.YearDay() == 1 && !day.AddDate(1, 0, -1).After(to):
+			periods = append(periods, yearPeriod(day))
+			day = day.AddDate(1, 0, 0)
+		case day.Day() == 1 && !monthEnd.After(to):
+			periods = append(periods, monthPeriod(day))
+			day = monthEnd.AddDate(0, 0, 1)
+		case (day.Day()-1)%7 == 0 && !weekEnd.After(to):
+			periods = append(periods, weekPeriod(day))
+			day = weekEnd.AddDate(0, 0, 1)
+		default:
+			days = append(days, day.Format("2006-01-02"))
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+	return periods, days
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWindowPeriods(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		from, to    string
+		wantPeriods []string
+		wantDays    []string
+	}{
+		{"2024-03-05", "2024-03-05", nil, []string{"2024-03-05"}},
+		{"2024-01-01", "2024-12-31", []string{"Y2024"}, nil},
+		{"2024-02-01", "2024-02-29", []string{"M2024-02"}, nil},
+		{"2024-02-29", "2024-03-14", []string{"W2024-02-5", "W2024-03-1", "W2024-03-2"}, nil},
+		{"2024-01-30", "2024-03-09",
+			[]string{"M2024-02", "W2024-03-1"},
+			[]string{"2024-01-30", "2024-01-31", "2024-03-08", "2024-03-09"}},
+		{"2023-10-19", "2024-10-18",
+			[]string{"W2023-10-4", "W2023-10-5", "M2023-11", "M2023-12",
+				"M2024-01", "M2024-02", "M2024-03", "M2024-04", "M2024-05", "M2024-06",
+				"M2024-07", "M2024-08", "M2024-09", "W2024-10-1", "W2024-10-2"},
+			[]string{"2023-10-19", "2023-10-20", "2023-10-21", "2024-10-15", "2024-10-16",
+				"2024-10-17", "2024-10-18"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"/"+tt.to, func(t *testing.T) {
+			periods, days := windowPeriods(day(tt.from), day(tt.to))
+			if !reflect.DeepEqual(periods, tt.wantPeriods) || !reflect.DeepEqual(days, tt.wantDays) {
+				t.Errorf("windowPeriods = %v, %v, want %v, %v", periods, days, tt.wantPeriods, tt.wantDays)
+			}
+		})
+	}
+}
+
+// Every day of a window must be counted exactly once, by one period it falls
+// in or as a loose day.
+func TestWindowPeriodsCoverEachDayOnce(t *testing.T) {
+	first := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	for offset := 0; offset < 70; offset++ {
+		from := first.AddDate(0, 0, offset)
+		for _, length := range []int{1, 6, 7, 13, 31, 45, 365, 366} {
+			to := from.AddDate(0, 0, length-1)
+			periods, days := windowPeriods(from, to)
+			counted := make(map[string]int)
+			for _, d := range days {
+				counted[d]++
+			}
+			for d := from.AddDate(0, 0, -7); !d.After(to.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+				date := d.Format("2006-01-02")
+				in, err := activityPeriods(date)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, p := range in {
+					for _, q := range periods {
+						if p == q {
+							counted[date]++
+						}
+					}
+				}
+				want := 0
+				if !d.Before(from) && !d.After(to) {
+					want = 1
+				}
+				if counted[date] != want {
+					t.Fatalf("%s..%s counts %s %d times, want %d", from.Format("2006-01-02"), to.Format("2006-01-02"), date, counted[date], want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package repository
+
+import "github.com/Brian-w-m/DevVerse/backend/src/models"
+
+// ledgerEntry returns entry as recorded for a change of delta to userID's
+// score.
+func ledgerEntry(entry models.LedgerEntry, userID string, delta int) models.LedgerEntry {
+	entry.UserID, entry.Delta = userID, delta
+	return entry
+}
+
+// moveEntries returns the ledger entries of moving score from sourceID to
+// targetID: one for each user, referring to the other.
+func moveEntries(entry models.LedgerEntry, sourceID, targetID string, score int) (models.LedgerEntry, models.LedgerEntry) {
+	from := ledgerEntry(entry, sourceID, -score)
+	from.ReferenceID = targetID
+	to := ledgerEntry(entry, targetID, score)
+	to.ReferenceID = sourceID
+	return from, to
+}
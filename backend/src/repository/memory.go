@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// MemoryStore implements Store with in-process maps. It is safe for concurrent
+// use and is intended for tests and zero-dependency local development; all
+// data is lost when the process exits.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	users    map[string]models.User
+	byScore  []scoreKey                                 // every user, in leaderboard order; see putUserLocked
+	sessions map[string]map[string]models.Session       // UserID -> SessionID -> Session
+	activity map[string]map[string]models.DailyActivity // UserID -> Date -> DailyActivity
+
+	idempotency map[string]models.IdempotencyRecord // UserID + "\x00" + Key
+
+	refreshTokens map[string]models.RefreshToken // TokenHash -> RefreshToken
+	revokedTokens map[string]models.RevokedToken // ID -> revocation
+
+	personalTokens map[string]models.PersonalAccessToken // TokenHash -> token
+
+	identities map[string]models.IdentityLink // ID -> link
+	merges     map[string]models.UserMerge    // SourceID -> merge
+
+	streaks map[string]models.Streak // UserID -> streak
+
+	languages map[string]map[string]models.LanguageActivity // UserID -> DateLanguage -> row
+
+	ledger map[string][]models.LedgerEntry // UserID -> entries, oldest first
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:    make(map[string]models.User),
+		sessions: make(map[string]map[string]models.Session),
+		activity: make(map[string]map[string]models.DailyActivity),
+
+		idempotency: make(map[string]models.IdempotencyRecord),
+
+		refreshTokens: make(map[string]models.RefreshToken),
+		revokedTokens: make(map[string]models.RevokedToken),
+
+		personalTokens: make(map[string]models.PersonalAccessToken),
+
+		identities: make(map[string]models.IdentityLink),
+		merges:     make(map[string]models.UserMerge),
+
+		streaks: make(map[string]models.Streak),
+
+		languages: make(map[string]map[string]models.LanguageActivity),
+
+		ledger: make(map[string][]models.LedgerEntry),
+	}
+}
+
+func (s *MemoryStore) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// Migrate is a no-op: the in-memory maps need no schema.
+func (s *MemoryStore) Migrate(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStore) AppliedSchemaVersion(ctx context.Context) (int, error) {
+	return SchemaVersion, nil
+}
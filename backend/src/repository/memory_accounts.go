@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *MemoryStore) GetIdentityLink(ctx context.Context, id string) (*models.IdentityLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	link, ok := s.identities[id]
+	if !ok {
+		return nil, nil
+	}
+	return &link, nil
+}
+
+func (s *MemoryStore) CreateIdentityLink(ctx context.Context, link models.IdentityLink) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.identities[link.ID]; exists {
+		return false, nil
+	}
+	s.identities[link.ID] = link
+	return true, nil
+}
+
+func (s *MemoryStore) ListIdentityLinks(ctx context.Context, userID string) ([]models.IdentityLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var links []models.IdentityLink
+	for _, link := range s.identities {
+		if link.UserID == userID {
+			links = append(links, link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].ID < links[j].ID })
+	return links, nil
+}
+
+func (s *MemoryStore) DeleteIdentityLink(ctx context.Context, userID, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.identities[id]
+	if !ok || link.UserID != userID {
+		return false, nil
+	}
+	delete(s.identities, id)
+	return true, nil
+}
+
+func (s *MemoryStore) SetIdentityLinkUser(ctx context.Context, id, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if link, ok := s.identities[id]; ok {
+		link.UserID = userID
+		s.identities[id] = link
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateUserMerge(ctx context.Context, merge models.UserMerge) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.merges[merge.SourceID]; exists {
+		return false, nil
+	}
+	s.merges[merge.SourceID] = merge
+	return true, nil
+}
+
+func (s *MemoryStore) GetUserMerge(ctx context.Context, sourceID string) (*models.UserMerge, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	merge, ok := s.merges[sourceID]
+	if !ok {
+		return nil, nil
+	}
+	return &merge, nil
+}
+
+func (s *MemoryStore) PutUserMerge(ctx context.Context, merge models.UserMerge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.merges[merge.SourceID] = merge
+	return nil
+}
+
+func (s *MemoryStore) MoveSession(ctx context.Context, session models.Session, targetID, targetSessionID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target, ok := s.sessions[targetID]
+	if !ok {
+		target = make(map[string]models.Session)
+		s.sessions[targetID] = target
+	}
+	if _, exists := target[targetSessionID]; exists {
+		return false, nil
+	}
+	delete(s.sessions[session.UserID], session.SessionID)
+	session.UserID = targetID
+	session.SessionID = targetSessionID
+	target[targetSessionID] = session
+	return true, nil
+}
+
+func (s *MemoryStore) MoveDailyActivity(ctx context.Context, userID, date, targetID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.activity[userID][date]
+	if !ok {
+		return nil
+	}
+	s.addDailyActivityLocked(targetID, date, row.Points, row.SessionCount)
+	delete(s.activity[userID], date)
+	return nil
+}
+
+func (s *MemoryStore) MoveLanguageActivity(ctx context.Context, row models.LanguageActivity, targetID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.languages[row.UserID][row.DateLanguage]
+	if !ok {
+		return nil
+	}
+	delete(s.languages[row.UserID], row.DateLanguage)
+	stored.UserID = targetID
+	s.addLanguageActivityLocked([]models.LanguageActivity{stored})
+	return nil
+}
+
+func (s *MemoryStore) MoveUserScore(ctx context.Context, sourceID, targetID string, entry models.LedgerEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, ok := s.users[sourceID]
+	if !ok || source.Score == 0 {
+		return nil
+	}
+	target := s.users[targetID]
+	target.ID = targetID
+	target.Score += source.Score
+	s.putUserLocked(target)
+	from, to := moveEntries(entry, sourceID, targetID, source.Score)
+	s.appendLedgerLocked(from)
+	s.appendLedgerLocked(to)
+	source.Score = 0
+	s.putUserLocked(source)
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *MemoryStore) AddDailyActivity(ctx context.Context, userID, date string, points, sessions int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addDailyActivityLocked(userID, date, points, sessions)
+	return nil
+}
+
+// addDailyActivityLocked is AddDailyActivity for callers already holding s.mu.
+func (s *MemoryStore) addDailyActivityLocked(userID, date string, points, sessions int) {
+	days, ok := s.activity[userID]
+	if !ok {
+		days = make(map[string]models.DailyActivity)
+		s.activity[userID] = days
+	}
+	day := days[date]
+	day.UserID = userID
+	day.Date = date
+	day.Points += points
+	day.SessionCount += sessions
+	days[date] = day
+}
+
+// sortedActivity returns the user's rows ordered by Date ascending.
+// Callers must hold s.mu.
+func (s *MemoryStore) sortedActivity(userID string) []models.DailyActivity {
+	days := s.activity[userID]
+	activities := make([]models.DailyActivity, 0, len(days))
+	for _, day := range days {
+		activities = append(activities, day)
+	}
+	sort.Slice(activities, func(i, j int) bool { return activities[i].Date < activities[j].Date })
+	return activities
+}
+
+func (s *MemoryStore) ListActivitySince(ctx context.Context, userID, since string) ([]models.DailyActivity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var activities []models.DailyActivity
+	for _, day := range s.sortedActivity(userID) {
+		if day.Date >= since {
+			activities = append(activities, day)
+		}
+	}
+	return activities, nil
+}
+
+func (s *MemoryStore) ListRecentActivity(ctx context.Context, userID string, limit int) ([]models.DailyActivity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sorted := s.sortedActivity(userID)
+	activities := make([]models.DailyActivity, 0, limit)
+	for i := len(sorted) - 1; i >= 0 && len(activities) < limit; i-- {
+		activities = append(activities, sorted[i])
+	}
+	return activities, nil
+}
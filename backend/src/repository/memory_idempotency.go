@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *MemoryStore) ClaimIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord, now int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := rec.UserID + "\x00" + rec.Key
+	if existing, ok := s.idempotency[id]; ok && existing.ExpiresAt > now {
+		return false, nil
+	}
+	s.idempotency[id] = rec
+	return true, nil
+}
+
+func (s *MemoryStore) GetIdempotencyRecord(ctx context.Context, userID, key string, now int64) (*models.IdempotencyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, ok := s.idempotency[userID+"\x00"+key]
+	if !ok || rec.ExpiresAt <= now {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+func (s *MemoryStore) PutIdempotencyRecord(ctx context.Context, rec models.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.idempotency[rec.UserID+"\x00"+rec.Key] = rec
+	return nil
+}
+
+func (s *MemoryStore) DeleteIdempotencyRecord(ctx context.Context, userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.idempotency, userID+"\x00"+key)
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// addLanguageActivityLocked adds rows to the stored rollups. Callers must
+// hold s.mu.
+func (s *MemoryStore) addLanguageActivityLocked(rows []models.LanguageActivity) {
+	for _, row := range rows {
+		userRows, ok := s.languages[row.UserID]
+		if !ok {
+			userRows = make(map[string]models.LanguageActivity)
+			s.languages[row.UserID] = userRows
+		}
+		stored := userRows[row.DateLanguage]
+		row.Points += stored.Points
+		userRows[row.DateLanguage] = row
+	}
+}
+
+// languageRowsBetween calls fn for each row of userRows in the range.
+func languageRowsBetween(userRows map[string]models.LanguageActivity, from, to string, fn func(models.LanguageActivity)) {
+	from, to = languageDateRange(from, to)
+	for _, row := range userRows {
+		if row.Date >= from && row.Date <= to {
+			fn(row)
+		}
+	}
+}
+
+func (s *MemoryStore) ListUserLanguageTotals(ctx context.Context, userID, from, to string) ([]LanguageTotal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	points := make(map[string]int)
+	languageRowsBetween(s.languages[userID], from, to, func(row models.LanguageActivity) {
+		points[row.Language] += row.Points
+	})
+	return sortedLanguageTotals(points), nil
+}
+
+func (s *MemoryStore) ListLanguageTotals(ctx context.Context, from, to string) ([]LanguageTotal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	points := make(map[string]int)
+	for _, userRows := range s.languages {
+		languageRowsBetween(userRows, from, to, func(row models.LanguageActivity) {
+			points[row.Language] += row.Points
+		})
+	}
+	return sortedLanguageTotals(points), nil
+}
+
+func (s *MemoryStore) ListLanguagePointTotals(ctx context.Context, language, from, to string) ([]PointsTotal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	points := make(map[string]int)
+	for userID, userRows := range s.languages {
+		if _, ok := s.users[userID]; !ok {
+			continue
+		}
+		languageRowsBetween(userRows, from, to, func(row models.LanguageActivity) {
+			if row.Language == language {
+				points[userID] += row.Points
+			}
+		})
+	}
+	return sortedPointTotals(points), nil
+}
+
+func (s *MemoryStore) ListLanguageActivity(ctx context.Context, userID string) ([]models.LanguageActivity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := make([]models.LanguageActivity, 0, len(s.languages[userID]))
+	for _, row := range s.languages[userID] {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].DateLanguage < rows[j].DateLanguage })
+	return rows, nil
+}
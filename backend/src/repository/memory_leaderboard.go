@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// scoreKey is an entry of MemoryStore.byScore.
+type scoreKey struct {
+	Score int
+	ID    string
+}
+
+// before reports whether k ranks above other: by Score descending, then ID.
+func (k scoreKey) before(other scoreKey) bool {
+	if k.Score != other.Score {
+		return k.Score > other.Score
+	}
+	return k.ID < other.ID
+}
+
+// scorePosition returns the index of k in s.byScore, or where it belongs if
+// absent. Callers must hold s.mu.
+func (s *MemoryStore) scorePosition(k scoreKey) int {
+	return sort.Search(len(s.byScore), func(i int) bool { return !s.byScore[i].before(k) })
+}
+
+// putUserLocked stores user and moves it within s.byScore if its score
+// changed, so that reads never have to sort. Callers must hold s.mu for
+// writing.
+func (s *MemoryStore) putUserLocked(user models.User) {
+	old, ok := s.users[user.ID]
+	s.users[user.ID] = user
+	if ok {
+		if old.Score == user.Score {
+			return
+		}
+		i := s.scorePosition(scoreKey{old.Score, old.ID})
+		s.byScore = slices.Delete(s.byScore, i, i+1)
+	}
+	k := scoreKey{user.Score, user.ID}
+	s.byScore = slices.Insert(s.byScore, s.scorePosition(k), k)
+}
+
+// deleteUserLocked removes the user and its s.byScore entry. Callers must
+// hold s.mu for writing.
+func (s *MemoryStore) deleteUserLocked(id string) {
+	user, ok := s.users[id]
+	if !ok {
+		return
+	}
+	delete(s.users, id)
+	i := s.scorePosition(scoreKey{user.Score, user.ID})
+	s.byScore = slices.Delete(s.byScore, i, i+1)
+}
+
+func (s *MemoryStore) ListTopUsers(ctx context.Context, offset, limit int, cursor string) (*ScorePage, error) {
+	if cursor != "" {
+		c, err := decodeScoreCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		offset = c.Position + 1
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	page := &ScorePage{Offset: offset, Users: []models.User{}}
+	if offset >= len(s.byScore) {
+		return page, nil
+	}
+	end := offset + limit
+	if end > len(s.byScore) {
+		end = len(s.byScore)
+	}
+	for _, k := range s.byScore[offset:end] {
+		page.Users = append(page.Users, s.users[k.ID])
+	}
+	if end < len(s.byScore) {
+		last := s.byScore[end-1]
+		page.Next = encodeScoreCursor(scoreCursor{Position: end - 1, Score: last.Score, ID: last.ID})
+	}
+	return page, nil
+}
+
+func (s *MemoryStore) CountUsersAbove(ctx context.Context, score int) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sort.Search(len(s.byScore), func(i int) bool { return s.byScore[i].Score <= score }), nil
+}
+
+func (s *MemoryStore) ListPointTotals(ctx context.Context, from, to string) ([]PointsTotal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	points := make(map[string]int)
+	for userID, days := range s.activity {
+		if _, ok := s.users[userID]; !ok {
+			continue
+		}
+		for date, day := range days {
+			if date >= from && date <= to {
+				points[userID] += day.Points
+			}
+		}
+	}
+	return sortedPointTotals(points), nil
+}
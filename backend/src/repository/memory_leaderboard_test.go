@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// The score index must always match sorting every user from scratch.
+func TestMemoryScoreIndexFollowsScoreWrites(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		id := fmt.Sprintf("u%02d", rng.Intn(40))
+		var err error
+		switch rng.Intn(6) {
+		case 0:
+			err = s.PutUser(ctx, models.User{ID: id, Score: rng.Intn(50)})
+		case 1:
+			err = s.SetUserScore(ctx, id, rng.Intn(50), models.LedgerEntry{})
+		case 2:
+			err = s.AddUserScore(ctx, id, rng.Intn(21)-10, "2024-01-01", models.LedgerEntry{})
+		case 3:
+			err = s.MoveUserScore(ctx, id, fmt.Sprintf("u%02d", rng.Intn(40)), models.LedgerEntry{})
+		case 4:
+			err = s.SetUserBanned(ctx, id, rng.Intn(2) == 0)
+		case 5:
+			err = s.DeleteUser(ctx, id)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var want []models.User
+		for _, u := range s.users {
+			want = append(want, u)
+		}
+		sort.Slice(want, func(i, j int) bool {
+			return scoreKey{want[i].Score, want[i].ID}.before(scoreKey{want[j].Score, want[j].ID})
+		})
+		page, err := s.ListTopUsers(ctx, 0, len(want)+1, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Users) != len(want) {
+			t.Fatalf("step %d: ListTopUsers returned %d users, want %d", i, len(page.Users), len(want))
+		}
+		for j := range want {
+			if page.Users[j].ID != want[j].ID || page.Users[j].Score != want[j].Score || page.Users[j].Banned != want[j].Banned {
+				t.Fatalf("step %d: position %d is %+v, want %+v", i, j, page.Users[j], want[j])
+			}
+		}
+
+		score := rng.Intn(60) - 10
+		above := 0
+		for _, u := range want {
+			if u.Score > score {
+				above++
+			}
+		}
+		if got, _ := s.CountUsersAbove(ctx, score); got != above {
+			t.Fatalf("step %d: CountUsersAbove(%d) = %d, want %d", i, score, got, above)
+		}
+	}
+}
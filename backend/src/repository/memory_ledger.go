@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// appendLedgerLocked appends entry to its user's ledger. Callers must hold
+// s.mu.
+func (s *MemoryStore) appendLedgerLocked(entry models.LedgerEntry) {
+	s.ledger[entry.UserID] = append(s.ledger[entry.UserID], entry)
+}
+
+func (s *MemoryStore) ListLedgerEntries(ctx context.Context, userID string, limit int, cursor string) ([]models.LedgerEntry, string, error) {
+	before := ""
+	if cursor != "" {
+		id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		before = id
+	}
+
+	s.mu.RLock()
+	all := append([]models.LedgerEntry(nil), s.ledger[userID]...)
+	s.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool { return all[i].EntryID > all[j].EntryID })
+
+	entries := make([]models.LedgerEntry, 0, limit)
+	for _, entry := range all {
+		if before != "" && entry.EntryID >= before {
+			continue
+		}
+		if len(entries) == limit {
+			return entries, encodeCursor(entries[len(entries)-1].EntryID), nil
+		}
+		entries = append(entries, entry)
+	}
+	return entries, "", nil
+}
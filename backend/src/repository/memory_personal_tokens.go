@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *MemoryStore) CreatePersonalAccessToken(ctx context.Context, token models.PersonalAccessToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token.Scopes = append([]string(nil), token.Scopes...)
+	s.personalTokens[token.TokenHash] = token
+	return nil
+}
+
+func (s *MemoryStore) GetPersonalAccessToken(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.personalTokens[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+func (s *MemoryStore) ListPersonalAccessTokens(ctx context.Context, userID string) ([]models.PersonalAccessToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tokens []models.PersonalAccessToken
+	for _, token := range s.personalTokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].CreatedAt != tokens[j].CreatedAt {
+			return tokens[i].CreatedAt < tokens[j].CreatedAt
+		}
+		return tokens[i].ID < tokens[j].ID
+	})
+	return tokens, nil
+}
+
+func (s *MemoryStore) DeletePersonalAccessToken(ctx context.Context, userID, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, token := range s.personalTokens {
+		if token.UserID == userID && token.ID == id {
+			delete(s.personalTokens, hash)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryStore) TouchPersonalAccessToken(ctx context.Context, tokenHash string, usedAt int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token, ok := s.personalTokens[tokenHash]; ok {
+		token.LastUsedAt = usedAt
+		s.personalTokens[tokenHash] = token
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *MemoryStore) RecordSession(ctx context.Context, session models.Session, entry models.LedgerEntry) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userSessions, ok := s.sessions[session.UserID]
+	if !ok {
+		userSessions = make(map[string]models.Session)
+		s.sessions[session.UserID] = userSessions
+	}
+	if _, exists := userSessions[session.SessionID]; exists {
+		return false, nil
+	}
+	userSessions[session.SessionID] = session
+
+	user := s.users[session.UserID]
+	user.ID = session.UserID
+	user.Score += session.Points
+	s.putUserLocked(user)
+	s.appendLedgerLocked(ledgerEntry(entry, session.UserID, session.Points))
+
+	for i, day := range session.Days {
+		sessions := 0
+		if i == 0 {
+			sessions = 1
+		}
+		s.addDailyActivityLocked(session.UserID, day.Date, day.Points, sessions)
+	}
+	s.addLanguageActivityLocked(languageActivityRows(session.UserID, session.Days))
+	return true, nil
+}
+
+func (s *MemoryStore) ListUserSessions(ctx context.Context, userID string, limit int) ([]models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := make([]models.Session, 0, len(s.sessions[userID]))
+	for _, session := range s.sessions[userID] {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].SessionID < sessions[j].SessionID })
+	if len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions, nil
+}
+
+func (s *MemoryStore) ListSessions(ctx context.Context, userID string, query SessionQuery) ([]models.Session, string, error) {
+	var after *sessionCursor
+	if query.Cursor != "" {
+		c, err := decodeSessionCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &c
+	}
+	from, to := sessionDateBounds(query)
+	filterDates := query.From != "" || query.To != ""
+
+	// before reports whether a sorts ahead of b: by value, then by SessionID.
+	before := func(aValue int64, aID string, bValue int64, bID string) bool {
+		if aValue != bValue {
+			return (aValue < bValue) != query.Descending
+		}
+		return aID < bID
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []models.Session
+	for _, session := range s.sessions[userID] {
+		if session.DeletedAt != 0 && !query.Deleted {
+			continue
+		}
+		if query.Language != "" && session.LanguageBreakdown[query.Language] <= 0 {
+			continue
+		}
+		if first, last := sessionDateRange(session); filterDates && (last < from || first > to) {
+			continue
+		}
+		if after != nil && !before(after.Value, after.SessionID, sessionSortValue(session, query.SortBy), session.SessionID) {
+			continue
+		}
+		matched = append(matched, session)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return before(sessionSortValue(matched[i], query.SortBy), matched[i].SessionID,
+			sessionSortValue(matched[j], query.SortBy), matched[j].SessionID)
+	})
+	if len(matched) <= query.Limit {
+		return matched, "", nil
+	}
+	last := matched[query.Limit-1]
+	return matched[:query.Limit], encodeSessionCursor(sessionCursor{Value: sessionSortValue(last, query.SortBy), SessionID: last.SessionID}), nil
+}
+
+func (s *MemoryStore) GetSession(ctx context.Context, userID, sessionID string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[userID][sessionID]
+	if !ok {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+func (s *MemoryStore) RedateSession(ctx context.Context, session models.Session, from, to string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.sessions[session.UserID][session.SessionID]
+	if !ok || stored.Date != "" || stored.DeletedAt != 0 {
+		return false, nil
+	}
+	if from != to {
+		row, ok := s.activity[session.UserID][from]
+		if !ok || row.Points < session.Points || row.SessionCount < 1 {
+			return false, nil
+		}
+		s.addDailyActivityLocked(session.UserID, from, -session.Points, -1)
+		s.addDailyActivityLocked(session.UserID, to, session.Points, 1)
+
+		held := make(map[string]int)
+		for language := range session.LanguageBreakdown {
+			held[language] = s.languages[session.UserID][from+"#"+language].Points
+		}
+		s.addLanguageActivityLocked(redateLanguageRows(session, from, to, held))
+	}
+	stored.Date = to
+	s.sessions[session.UserID][session.SessionID] = stored
+	return true, nil
+}
+
+func (s *MemoryStore) CorrectSession(ctx context.Context, old, corrected models.Session, entry models.LedgerEntry) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.sessions[old.UserID][old.SessionID]
+	if !ok || stored.DeletedAt != 0 || stored.Points != old.Points || stored.Date != old.Date {
+		return false, nil
+	}
+	s.sessions[old.UserID][old.SessionID] = corrected
+
+	correction := correctionDeltas(old, corrected)
+	user := s.users[old.UserID]
+	user.ID = old.UserID
+	user.Score += correction.Points
+	s.putUserLocked(user)
+	s.appendLedgerLocked(ledgerEntry(entry, old.UserID, correction.Points))
+	for _, row := range correction.Activity {
+		s.addDailyActivityLocked(row.UserID, row.Date, row.Points, row.SessionCount)
+	}
+	s.addLanguageActivityLocked(correction.Languages)
+	return true, nil
+}
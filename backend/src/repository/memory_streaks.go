@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *MemoryStore) GetStreak(ctx context.Context, userID string) (*models.Streak, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	streak, ok := s.streaks[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &streak, nil
+}
+
+func (s *MemoryStore) GetStreaks(ctx context.Context, userIDs []string) ([]models.Streak, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var streaks []models.Streak
+	for _, id := range userIDs {
+		if streak, ok := s.streaks[id]; ok {
+			streaks = append(streaks, streak)
+		}
+	}
+	return streaks, nil
+}
+
+func (s *MemoryStore) SaveStreak(ctx context.Context, streak models.Streak, version int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.streaks[streak.UserID].Version != version {
+		return false, nil
+	}
+	streak.Version = version + 1
+	s.streaks[streak.UserID] = streak
+	return true, nil
+}
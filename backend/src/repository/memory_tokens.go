@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *MemoryStore) CreateRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshTokens[token.TokenHash] = token
+	return nil
+}
+
+func (s *MemoryStore) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+func (s *MemoryStore) RotateRefreshToken(ctx context.Context, oldHash string, next models.RefreshToken, now int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.refreshTokens[oldHash]
+	if !ok || old.UsedAt != 0 {
+		return false, nil
+	}
+	old.UsedAt = now
+	s.refreshTokens[oldHash] = old
+	s.refreshTokens[next.TokenHash] = next
+	return true, nil
+}
+
+func (s *MemoryStore) RevokeToken(ctx context.Context, revoked models.RevokedToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revokedTokens[revoked.ID] = revoked
+	return nil
+}
+
+func (s *MemoryStore) IsTokenRevoked(ctx context.Context, now, issuedAt int64, ids ...string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, id := range ids {
+		if revoked, ok := s.revokedTokens[id]; ok && revokes(revoked, now, issuedAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *MemoryStore) ListUsers(ctx context.Context) ([]models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (s *MemoryStore) ListUsersPage(ctx context.Context, limit int, cursor string) ([]models.User, string, error) {
+	after := ""
+	if cursor != "" {
+		id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = id
+	}
+
+	all, _ := s.ListUsers(ctx)
+	users := make([]models.User, 0, limit)
+	for _, u := range all {
+		if u.ID <= after {
+			continue
+		}
+		if len(users) == limit {
+			return users, encodeCursor(users[len(users)-1].ID), nil
+		}
+		users = append(users, u)
+	}
+	return users, "", nil
+}
+
+func (s *MemoryStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (s *MemoryStore) GetUsers(ctx context.Context, ids []string) ([]models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var users []models.User
+	for _, id := range ids {
+		if user, ok := s.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (s *MemoryStore) PutUser(ctx context.Context, user models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.putUserLocked(user)
+	return nil
+}
+
+func (s *MemoryStore) CreateUser(ctx context.Context, user models.User) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[user.ID]; exists {
+		return false, nil
+	}
+	s.putUserLocked(user)
+	return true, nil
+}
+
+// UpdateUserProfile, SetUserScore and AddUserScore upsert like their DynamoDB
+// UpdateItem counterparts do.
+func (s *MemoryStore) UpdateUserProfile(ctx context.Context, id, name, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.users[id]
+	user.ID = id
+	user.Name = name
+	user.Email = email
+	s.putUserLocked(user)
+	return nil
+}
+
+func (s *MemoryStore) UpdateUserIdentity(ctx context.Context, id, githubLogin, avatarURL string, seenAt int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil
+	}
+	if githubLogin != "" {
+		user.GithubLogin = githubLogin
+	}
+	user.AvatarURL = avatarURL
+	user.LastSeenAt = seenAt
+	if user.CreatedAt == 0 {
+		user.CreatedAt = seenAt
+	}
+	s.putUserLocked(user)
+	return nil
+}
+
+func (s *MemoryStore) SetUserScore(ctx context.Context, id string, score int, entry models.LedgerEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.users[id]
+	s.appendLedgerLocked(ledgerEntry(entry, id, score-user.Score))
+	user.ID = id
+	user.Score = score
+	s.putUserLocked(user)
+	return nil
+}
+
+func (s *MemoryStore) AddUserScore(ctx context.Context, id string, increment int, date string, entry models.LedgerEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.users[id]
+	user.ID = id
+	user.Score += increment
+	s.putUserLocked(user)
+	s.addDailyActivityLocked(id, date, increment, 0)
+	s.appendLedgerLocked(ledgerEntry(entry, id, increment))
+	return nil
+}
+
+func (s *MemoryStore) SetUserRoles(ctx context.Context, id string, roles []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[id]; ok {
+		user.Roles = append([]string(nil), roles...)
+		s.putUserLocked(user)
+	}
+	return nil
+}
+
+func (s *MemoryStore) SetUserBanned(ctx context.Context, id string, banned bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[id]; ok {
+		user.Banned = banned
+		s.putUserLocked(user)
+	}
+	return nil
+}
+
+func (s *MemoryStore) TouchUserLastSeen(ctx context.Context, id string, seenAt int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[id]; ok && user.LastSeenAt < seenAt {
+		user.LastSeenAt = seenAt
+		s.putUserLocked(user)
+	}
+	return nil
+}
+
+func (s *MemoryStore) SetUserTimezone(ctx context.Context, id, timezone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[id]; ok {
+		user.Timezone = timezone
+		s.putUserLocked(user)
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteUser(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteUserLocked(id)
+	return nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import "context"
+
+// SchemaVersion is the schema version this build of the server expects.
+// Bump it together with a new entry in every backend's migration list.
+const SchemaVersion = 18
+
+// Migrator creates and upgrades a backend's schema.
+type Migrator interface {
+	// Migrate applies, in order, every migration newer than the recorded
+	// schema version. Each migration is idempotent and the version is recorded
+	// after it succeeds, so an interrupted run can simply be repeated.
+	Migrate(ctx context.Context) error
+	// AppliedSchemaVersion returns the recorded schema version, or 0 if the
+	// schema has never been migrated.
+	AppliedSchemaVersion(ctx context.Context) (int, error)
+}
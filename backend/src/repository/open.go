@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/database"
+)
+
+var (
+	_ Store = (*DynamoDBStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
+
+// Open returns the Store selected by cfg.StorageBackend.
+func Open(cfg appconfig.Config) (Store, error) {
+	switch cfg.StorageBackend {
+	case appconfig.StorageBackendDynamoDB:
+		client, err := database.NewDynamoDBClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialise DynamoDB client: %w", err)
+		}
+		return NewDynamoDBStore(client, cfg), nil
+	case appconfig.StorageBackendSQLite:
+		store, err := NewSQLiteStore(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case appconfig.StorageBackendMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
@@ -0,0 +1,289 @@
+// Package repository defines the storage interfaces used by the services layer
+// and the backends that implement them.
+package repository
+
+import (
+	"context"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// UserRepository stores rows of the Users table.
+// Lookups return (nil, nil) when the user does not exist.
+type UserRepository interface {
+	// ListUsers returns every user, reading the whole table.
+	ListUsers(ctx context.Context) ([]models.User, error)
+	// ListUsersPage returns up to limit users starting after cursor ("" for the
+	// first page) and the cursor for the next page, which is "" on the last page.
+	ListUsersPage(ctx context.Context, limit int, cursor string) ([]models.User, string, error)
+	GetUser(ctx context.Context, id string) (*models.User, error)
+	// GetUsers returns those of ids that exist, in no particular order.
+	GetUsers(ctx context.Context, ids []string) ([]models.User, error)
+	PutUser(ctx context.Context, user models.User) error
+	// CreateUser stores user unless a user with the same ID exists, and
+	// reports whether it did.
+	CreateUser(ctx context.Context, user models.User) (bool, error)
+	UpdateUserProfile(ctx context.Context, id, name, email string) error
+	// UpdateUserIdentity sets, on an existing user, the fields refreshed from
+	// the identity provider at login: GithubLogin unless githubLogin is
+	// empty, AvatarURL and LastSeenAt, and CreatedAt if it was never set. It
+	// leaves every other field alone and does nothing if the user does not
+	// exist.
+	UpdateUserIdentity(ctx context.Context, id, githubLogin, avatarURL string, seenAt int64) error
+	// SetUserScore sets Score, creating the row if needed, and appends entry
+	// with the resulting change to the ledger in the same atomic write.
+	SetUserScore(ctx context.Context, id string, score int, entry models.LedgerEntry) error
+	// AddUserScore atomically increments Score, creating the row if needed,
+	// adds increment to the user's DailyActivity row for date and appends
+	// entry with the increment to the ledger.
+	AddUserScore(ctx context.Context, id string, increment int, date string, entry models.LedgerEntry) error
+	// SetUserRoles and SetUserBanned change an existing user and do nothing
+	// if the user does not exist.
+	SetUserRoles(ctx context.Context, id string, roles []string) error
+	SetUserBanned(ctx context.Context, id string, banned bool) error
+	// TouchUserLastSeen raises LastSeenAt to seenAt on an existing user. It
+	// never moves LastSeenAt backwards and does nothing if the user does not
+	// exist.
+	TouchUserLastSeen(ctx context.Context, id string, seenAt int64) error
+	// SetUserTimezone sets Timezone on an existing user, clearing it if
+	// timezone is empty, and does nothing if the user does not exist.
+	SetUserTimezone(ctx context.Context, id, timezone string) error
+	DeleteUser(ctx context.Context, id string) error
+}
+
+// ScorePage is one page of users ordered by Score descending.
+type ScorePage struct {
+	Users []models.User
+	// Offset is the zero-based leaderboard position of Users[0].
+	Offset int
+	// Next is the cursor for the following page, or "" on the last page.
+	Next string
+}
+
+// PointsTotal is the points one user earned over a date range.
+type PointsTotal struct {
+	UserID string
+	Points int
+}
+
+// LeaderboardRepository answers ranking queries from a score-sorted index
+// and from DailyActivity indexed by date.
+type LeaderboardRepository interface {
+	// ListTopUsers returns up to limit users by descending Score, starting at
+	// cursor if set and otherwise skipping offset users.
+	ListTopUsers(ctx context.Context, offset, limit int, cursor string) (*ScorePage, error)
+	// CountUsersAbove returns how many users have a Score strictly greater
+	// than score.
+	CountUsersAbove(ctx context.Context, score int) (int, error)
+	// ListPointTotals sums each user's DailyActivity points for dates from
+	// to to inclusive ("YYYY-MM-DD"). Users without points in the range and
+	// users that no longer exist are omitted; the rest are ordered by Points
+	// descending, then UserID.
+	ListPointTotals(ctx context.Context, from, to string) ([]PointsTotal, error)
+}
+
+// Orders SessionQuery.SortBy can take.
+const (
+	SessionSortEndedAt   = "endedAt"
+	SessionSortStartedAt = "startedAt"
+	SessionSortPoints    = "points"
+)
+
+// SessionQuery selects and orders a page of a user's sessions.
+type SessionQuery struct {
+	From       string // "YYYY-MM-DD"; only sessions with points on a day from From to To; an empty bound is open
+	To         string
+	Language   string // only sessions with points in this language
+	SortBy     string // one of the SessionSort constants
+	Descending bool
+	Deleted    bool // include deleted sessions
+	Limit      int
+	Cursor     string // returned with the previous page
+}
+
+// SessionRepository stores rows of the Sessions table.
+type SessionRepository interface {
+	// RecordSession atomically stores the session, adds its points to the
+	// user's Score, adds each of session.Days to the DailyActivity row for its
+	// date, with one session on the row for Days[0], and adds each day's
+	// languages to the LanguageActivity rows for that date and for all time,
+	// and appends entry with the session's points to the ledger. If a session
+	// with the same SessionID already exists for the user nothing is written.
+	// It reports whether the session was newly stored.
+	RecordSession(ctx context.Context, session models.Session, entry models.LedgerEntry) (bool, error)
+	// ListUserSessions returns up to limit of the user's sessions ordered by
+	// SessionID.
+	ListUserSessions(ctx context.Context, userID string, limit int) ([]models.Session, error)
+	// ListSessions returns one page of the user's sessions matching query
+	// and the cursor for the next page, or "" on the last page. Pages stay
+	// stable while new sessions arrive.
+	ListSessions(ctx context.Context, userID string, query SessionQuery) ([]models.Session, string, error)
+	GetSession(ctx context.Context, userID, sessionID string) (*models.Session, error)
+	// RedateSession sets the Date of a session stored without one to to and,
+	// if from differs, atomically moves its points and one session from the
+	// DailyActivity row for from to the row for to, and each language's
+	// points from the LanguageActivity row for from to the row for to where
+	// the row for from holds them. It writes nothing and returns false if the
+	// session is gone, deleted or already dated, or if the row for from holds
+	// fewer points than the session or no session.
+	RedateSession(ctx context.Context, session models.Session, from, to string) (bool, error)
+	// CorrectSession atomically replaces old, a session read from the store,
+	// with corrected and moves the user's Score and the DailyActivity and
+	// LanguageActivity rows from what old credited to what corrected credits,
+	// appending entry with the change in Score to the ledger. A deleted
+	// session credits nothing. It writes nothing and returns false if the
+	// stored session is gone, deleted or no longer has old's Points and Date.
+	CorrectSession(ctx context.Context, old, corrected models.Session, entry models.LedgerEntry) (bool, error)
+}
+
+// ActivityRepository stores rows of the DailyActivity table.
+type ActivityRepository interface {
+	// AddDailyActivity atomically adds points and sessions to the user's row for
+	// date ("YYYY-MM-DD"), creating the row if needed.
+	AddDailyActivity(ctx context.Context, userID, date string, points, sessions int) error
+	// ListActivitySince returns rows with Date >= since, oldest first. An empty
+	// since returns every row.
+	ListActivitySince(ctx context.Context, userID, since string) ([]models.DailyActivity, error)
+	// ListRecentActivity returns at most limit rows, newest first.
+	ListRecentActivity(ctx context.Context, userID string, limit int) ([]models.DailyActivity, error)
+}
+
+// LanguageTotal is the points earned in one language.
+type LanguageTotal struct {
+	Language string
+	Points   int
+}
+
+// LanguageRepository reads the LanguageActivity rollups that RecordSession
+// writes. In every range query an empty from means all time, answered from
+// the LanguageTotalDate rows.
+type LanguageRepository interface {
+	// ListUserLanguageTotals sums the user's points per language for dates
+	// from to to inclusive, ordered by Points descending, then Language.
+	ListUserLanguageTotals(ctx context.Context, userID, from, to string) ([]LanguageTotal, error)
+	// ListLanguageTotals is ListUserLanguageTotals summed over every user.
+	ListLanguageTotals(ctx context.Context, from, to string) ([]LanguageTotal, error)
+	// ListLanguagePointTotals is ListPointTotals for points earned in one
+	// language.
+	ListLanguagePointTotals(ctx context.Context, language, from, to string) ([]PointsTotal, error)
+	// ListLanguageActivity returns every row the user has, including the
+	// all-time rows.
+	ListLanguageActivity(ctx context.Context, userID string) ([]models.LanguageActivity, error)
+}
+
+// IdempotencyRepository stores rows of the IdempotencyKeys table. Records
+// whose ExpiresAt is not after now are treated as absent.
+type IdempotencyRepository interface {
+	// ClaimIdempotencyKey stores rec unless an unexpired record already exists
+	// for the same user and key. It reports whether rec was stored.
+	ClaimIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord, now int64) (bool, error)
+	GetIdempotencyRecord(ctx context.Context, userID, key string, now int64) (*models.IdempotencyRecord, error)
+	// PutIdempotencyRecord overwrites the record, e.g. to store the response.
+	PutIdempotencyRecord(ctx context.Context, rec models.IdempotencyRecord) error
+	DeleteIdempotencyRecord(ctx context.Context, userID, key string) error
+}
+
+// TokenRepository stores rows of the RefreshTokens and RevokedTokens tables.
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token models.RefreshToken) error
+	// GetRefreshToken returns the token with the given hash, including used and
+	// expired ones so callers can detect reuse.
+	GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// RotateRefreshToken atomically marks oldHash as used at now and stores
+	// next. It reports false without writing if oldHash was already used.
+	RotateRefreshToken(ctx context.Context, oldHash string, next models.RefreshToken, now int64) (bool, error)
+	RevokeToken(ctx context.Context, revoked models.RevokedToken) error
+	// IsTokenRevoked reports whether any of ids has an unexpired revocation
+	// covering a token issued at issuedAt.
+	IsTokenRevoked(ctx context.Context, now, issuedAt int64, ids ...string) (bool, error)
+}
+
+// PersonalTokenRepository stores rows of the PersonalAccessTokens table.
+type PersonalTokenRepository interface {
+	CreatePersonalAccessToken(ctx context.Context, token models.PersonalAccessToken) error
+	GetPersonalAccessToken(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+	// ListPersonalAccessTokens returns the user's tokens, oldest first.
+	ListPersonalAccessTokens(ctx context.Context, userID string) ([]models.PersonalAccessToken, error)
+	// DeletePersonalAccessToken reports whether the user had a token with id.
+	DeletePersonalAccessToken(ctx context.Context, userID, id string) (bool, error)
+	TouchPersonalAccessToken(ctx context.Context, tokenHash string, usedAt int64) error
+}
+
+// AccountRepository stores identity links and user merges, and moves a
+// user's data into another account during a merge. Each Move call is atomic,
+// so an interrupted merge can be resumed by moving whatever is left.
+type AccountRepository interface {
+	GetIdentityLink(ctx context.Context, id string) (*models.IdentityLink, error)
+	// CreateIdentityLink stores link unless the identity is already linked.
+	// It reports whether link was stored.
+	CreateIdentityLink(ctx context.Context, link models.IdentityLink) (bool, error)
+	// ListIdentityLinks returns the user's links ordered by ID.
+	ListIdentityLinks(ctx context.Context, userID string) ([]models.IdentityLink, error)
+	// DeleteIdentityLink removes one of the user's links. It reports false if
+	// the user has no link with that ID.
+	DeleteIdentityLink(ctx context.Context, userID, id string) (bool, error)
+	// SetIdentityLinkUser attaches an existing link to userID.
+	SetIdentityLinkUser(ctx context.Context, id, userID string) error
+
+	// CreateUserMerge stores merge unless a merge of the same source exists.
+	// It reports whether merge was stored.
+	CreateUserMerge(ctx context.Context, merge models.UserMerge) (bool, error)
+	GetUserMerge(ctx context.Context, sourceID string) (*models.UserMerge, error)
+	PutUserMerge(ctx context.Context, merge models.UserMerge) error
+
+	// MoveSession stores session under targetID as targetSessionID and
+	// deletes the original, leaving scores and activity untouched. It reports
+	// false, changing nothing, if targetID already has targetSessionID.
+	MoveSession(ctx context.Context, session models.Session, targetID, targetSessionID string) (bool, error)
+	// MoveDailyActivity adds the user's row for date to targetID's row for
+	// the same date and deletes it. A missing row is not an error.
+	MoveDailyActivity(ctx context.Context, userID, date, targetID string) error
+	// MoveLanguageActivity adds row to targetID's row for the same date and
+	// language and deletes it.
+	MoveLanguageActivity(ctx context.Context, row models.LanguageActivity, targetID string) error
+	// MoveUserScore adds sourceID's score to targetID's and sets sourceID's
+	// to zero, appending entry to both users' ledgers with the amount each
+	// gained or lost. Nothing is written if sourceID's score is zero.
+	MoveUserScore(ctx context.Context, sourceID, targetID string, entry models.LedgerEntry) error
+}
+
+// LedgerRepository reads the ScoreLedger table. Entries are appended by the
+// methods that change a user's Score.
+type LedgerRepository interface {
+	// ListLedgerEntries returns up to limit of the user's entries, newest
+	// first, starting after cursor ("" for the first page), and the cursor
+	// for the next page, which is "" on the last page.
+	ListLedgerEntries(ctx context.Context, userID string, limit int, cursor string) ([]models.LedgerEntry, string, error)
+}
+
+// StreakRepository stores rows of the Streaks table.
+type StreakRepository interface {
+	// GetStreak returns (nil, nil) if the user has no stored streak.
+	GetStreak(ctx context.Context, userID string) (*models.Streak, error)
+	// GetStreaks returns the stored streaks of those of userIDs that have
+	// one, in no particular order.
+	GetStreaks(ctx context.Context, userIDs []string) ([]models.Streak, error)
+	// SaveStreak stores streak with Version set to version+1, provided the
+	// stored row still has Version == version (0 meaning no row). It reports
+	// false, writing nothing, if another write got there first.
+	SaveStreak(ctx context.Context, streak models.Streak, version int) (bool, error)
+}
+
+// Store bundles every repository a storage backend provides.
+type Store interface {
+	UserRepository
+	LeaderboardRepository
+	SessionRepository
+	ActivityRepository
+	LanguageRepository
+	IdempotencyRepository
+	TokenRepository
+	PersonalTokenRepository
+	AccountRepository
+	StreakRepository
+	LedgerRepository
+	Migrator
+
+	// HealthCheck reports whether the backend is reachable and usable.
+	HealthCheck(ctx context.Context) error
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// creditedDays returns the days whose DailyActivity and LanguageActivity
+// rows hold the session's points. A session dated but recorded before
+// sessions were split counts as one day; a deleted or undated session
+// credits no day.
+func creditedDays(session models.Session) []models.SessionDay {
+	switch {
+	case session.DeletedAt != 0:
+		return nil
+	case len(session.Days) > 0:
+		return session.Days
+	case session.Date != "":
+		return []models.SessionDay{{Date: session.Date, Points: session.Points, Languages: session.LanguageBreakdown}}
+	}
+	return nil
+}
+
+// creditedPoints returns the points the session adds to the user's score.
+func creditedPoints(session models.Session) int {
+	if session.DeletedAt != 0 {
+		return 0
+	}
+	return session.Points
+}
+
+// sessionCorrection is what CorrectSession adds besides rewriting the
+// session: deltas to the user's score and to the DailyActivity and
+// LanguageActivity rows. Rows whose deltas are all zero are left out.
+type sessionCorrection struct {
+	Points    int
+	Activity  []models.DailyActivity
+	Languages []models.LanguageActivity
+}
+
+// correctionDeltas returns the changes that turn what old credits into what
+// corrected credits.
+func correctionDeltas(old, corrected models.Session) sessionCorrection {
+	correction := sessionCorrection{Points: creditedPoints(corrected) - creditedPoints(old)}
+
+	activity := make(map[string]models.DailyActivity)
+	languages := make(map[string]models.LanguageActivity)
+	credit := func(session models.Session, sign int) {
+		days := creditedDays(session)
+		for i, day := range days {
+			row := activity[day.Date]
+			row.UserID, row.Date = session.UserID, day.Date
+			row.Points += sign * day.Points
+			if i == 0 {
+				row.SessionCount += sign
+			}
+			activity[day.Date] = row
+		}
+		for _, row := range languageActivityRows(session.UserID, days) {
+			delta := languages[row.DateLanguage]
+			row.Points = delta.Points + sign*row.Points
+			languages[row.DateLanguage] = row
+		}
+	}
+	credit(old, -1)
+	credit(corrected, 1)
+
+	for _, row := range activity {
+		if row.Points != 0 || row.SessionCount != 0 {
+			correction.Activity = append(correction.Activity, row)
+		}
+	}
+	sort.Slice(correction.Activity, func(i, j int) bool { return correction.Activity[i].Date < correction.Activity[j].Date })
+	for _, row := range languages {
+		if row.Points != 0 {
+			correction.Languages = append(correction.Languages, row)
+		}
+	}
+	sort.Slice(correction.Languages, func(i, j int) bool {
+		return correction.Languages[i].DateLanguage < correction.Languages[j].DateLanguage
+	})
+	return correction
+}
+
+// sessionDateRange returns the first and last day the session has points on.
+// Sessions recorded before they were dated are placed on the UTC day they
+// ended. Both are empty if the session has no day at all.
+func sessionDateRange(session models.Session) (string, string) {
+	var first, last string
+	for _, day := range session.Days {
+		if first == "" || day.Date < first {
+			first = day.Date
+		}
+		if day.Date > last {
+			last = day.Date
+		}
+	}
+	switch {
+	case first != "":
+	case session.Date != "":
+		first, last = session.Date, session.Date
+	case session.EndedAt > 0:
+		first = time.Unix(session.EndedAt, 0).UTC().Format("2006-01-02")
+		last = first
+	}
+	return first, last
+}
+
+// sessionDateBounds returns the inclusive bounds ListSessions compares a
+// session's first and last day against, with open bounds filled in so that
+// sessions without days never match.
+func sessionDateBounds(query SessionQuery) (string, string) {
+	from, to := query.From, query.To
+	if from == "" {
+		from = "0000-00-00"
+	}
+	if to == "" {
+		to = "9999-99-99"
+	}
+	return from, to
+}
+
+// sessionSortValue returns the value of the session that SessionQuery.SortBy
+// orders by.
+func sessionSortValue(session models.Session, sortBy string) int64 {
+	switch sortBy {
+	case SessionSortStartedAt:
+		return session.StartedAt
+	case SessionSortPoints:
+		return int64(session.Points)
+	}
+	return session.EndedAt
+}
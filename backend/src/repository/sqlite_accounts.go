@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+const identityColumns = `id, provider, subject, user_id, login, linked_at`
+
+func scanIdentityLink(row rowScanner) (models.IdentityLink, error) {
+	var l models.IdentityLink
+	err := row.Scan(&l.ID, &l.Provider, &l.Subject, &l.UserID, &l.Login, &l.LinkedAt)
+	return l, err
+}
+
+func (s *SQLiteStore) GetIdentityLink(ctx context.Context, id string) (*models.IdentityLink, error) {
+	link, err := scanIdentityLink(s.db.QueryRowContext(ctx,
+		`SELECT `+identityColumns+` FROM identities WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity link: %w", err)
+	}
+	return &link, nil
+}
+
+func (s *SQLiteStore) CreateIdentityLink(ctx context.Context, link models.IdentityLink) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO identities (`+identityColumns+`) VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (id) DO NOTHING`,
+		link.ID, link.Provider, link.Subject, link.UserID, link.Login, link.LinkedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create identity link: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to create identity link: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *SQLiteStore) ListIdentityLinks(ctx context.Context, userID string) ([]models.IdentityLink, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+identityColumns+` FROM identities WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identity links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []models.IdentityLink
+	for rows.Next() {
+		link, err := scanIdentityLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan identity link: %w", err)
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+func (s *SQLiteStore) DeleteIdentityLink(ctx context.Context, userID, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM identities WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete identity link: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete identity link: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *SQLiteStore) SetIdentityLinkUser(ctx context.Context, id, userID string) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE identities SET user_id = ? WHERE id = ?`, userID, id); err != nil {
+		return fmt.Errorf("failed to update identity link: %w", err)
+	}
+	return nil
+}
+
+const mergeColumns = `source_id, target_id, requested_by, step, started_at, updated_at, completed_at`
+
+func (s *SQLiteStore) CreateUserMerge(ctx context.Context, merge models.UserMerge) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO user_merges (`+mergeColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (source_id) DO NOTHING`,
+		merge.SourceID, merge.TargetID, merge.RequestedBy, merge.Step, merge.StartedAt, merge.UpdatedAt, merge.CompletedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create user merge: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to create user merge: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *SQLiteStore) GetUserMerge(ctx context.Context, sourceID string) (*models.UserMerge, error) {
+	var m models.UserMerge
+	err := s.db.QueryRowContext(ctx,
+		`SELECT `+mergeColumns+` FROM user_merges WHERE source_id = ?`, sourceID).
+		Scan(&m.SourceID, &m.TargetID, &m.RequestedBy, &m.Step, &m.StartedAt, &m.UpdatedAt, &m.CompletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user merge: %w", err)
+	}
+	return &m, nil
+}
+
+func (s *SQLiteStore) PutUserMerge(ctx context.Context, merge models.UserMerge) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO user_merges (`+mergeColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		merge.SourceID, merge.TargetID, merge.RequestedBy, merge.Step, merge.StartedAt, merge.UpdatedAt, merge.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to put user merge: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) MoveSession(ctx context.Context, session models.Session, targetID, targetSessionID string) (bool, error) {
+	breakdown, days, corrections, err := marshalSessionJSON(session)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	first, last := sessionDateRange(session)
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO sessions (`+sessionColumns+`, first_date, last_date)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (user_id, session_id) DO NOTHING`,
+		targetID, targetSessionID, session.StartedAt, session.EndedAt, session.Points, breakdown, session.Date, days,
+		session.DeletedAt, corrections, first, last)
+	if err != nil {
+		return false, fmt.Errorf("failed to move session: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to move session: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM sessions WHERE user_id = ? AND session_id = ?`, session.UserID, session.SessionID); err != nil {
+		return false, fmt.Errorf("failed to move session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit session move: %w", err)
+	}
+	return true, nil
+}
+
+func (s *SQLiteStore) MoveDailyActivity(ctx context.Context, userID, date, targetID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO daily_activity (user_id, date, points, session_count)
+		 SELECT ?, date, points, session_count FROM daily_activity WHERE user_id = ? AND date = ?
+		 ON CONFLICT (user_id, date) DO UPDATE SET
+			points = points + excluded.points,
+			session_count = session_count + excluded.session_count`,
+		targetID, userID, date)
+	if err != nil {
+		return fmt.Errorf("failed to move daily activity: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM daily_activity WHERE user_id = ? AND date = ?`, userID, date); err != nil {
+		return fmt.Errorf("failed to move daily activity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit daily activity move: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) MoveLanguageActivity(ctx context.Context, row models.LanguageActivity, targetID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO language_activity (user_id, date, language, points)
+		 SELECT ?, date, language, points FROM language_activity
+		 WHERE user_id = ? AND date = ? AND language = ?
+		 ON CONFLICT (user_id, date, language) DO UPDATE SET points = points + excluded.points`,
+		targetID, row.UserID, row.Date, row.Language)
+	if err != nil {
+		return fmt.Errorf("failed to move language activity: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM language_activity WHERE user_id = ? AND date = ? AND language = ?`,
+		row.UserID, row.Date, row.Language); err != nil {
+		return fmt.Errorf("failed to move language activity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit language activity move: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) MoveUserScore(ctx context.Context, sourceID, targetID string, entry models.LedgerEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var score int
+	err = tx.QueryRowContext(ctx, `SELECT score FROM users WHERE id = ?`, sourceID).Scan(&score)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && score == 0) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user score: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO users (id, score) VALUES (?, ?)
+		 ON CONFLICT (id) DO UPDATE SET score = score + excluded.score`,
+		targetID, score)
+	if err != nil {
+		return fmt.Errorf("failed to move user score: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET score = 0 WHERE id = ?`, sourceID); err != nil {
+		return fmt.Errorf("failed to move user score: %w", err)
+	}
+	from, to := moveEntries(entry, sourceID, targetID, score)
+	for _, e := range []models.LedgerEntry{from, to} {
+		if err := appendLedgerEntry(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit score move: %w", err)
+	}
+	return nil
+}
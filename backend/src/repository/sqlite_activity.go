@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *SQLiteStore) AddDailyActivity(ctx context.Context, userID, date string, points, sessions int) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO daily_activity (user_id, date, points, session_count) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (user_id, date) DO UPDATE SET
+			points = points + excluded.points,
+			session_count = session_count + excluded.session_count`,
+		userID, date, points, sessions)
+	if err != nil {
+		return fmt.Errorf("failed to update daily activity: %w", err)
+	}
+	return nil
+}
+
+func scanDailyActivity(rows *sql.Rows) ([]models.DailyActivity, error) {
+	defer rows.Close()
+
+	var activities []models.DailyActivity
+	for rows.Next() {
+		var a models.DailyActivity
+		if err := rows.Scan(&a.UserID, &a.Date, &a.Points, &a.SessionCount); err != nil {
+			return nil, fmt.Errorf("failed to scan daily activity: %w", err)
+		}
+		activities = append(activities, a)
+	}
+	return activities, rows.Err()
+}
+
+func (s *SQLiteStore) ListActivitySince(ctx context.Context, userID, since string) ([]models.DailyActivity, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT user_id, date, points, session_count FROM daily_activity
+		 WHERE user_id = ? AND date >= ? ORDER BY date`,
+		userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily activity: %w", err)
+	}
+	return scanDailyActivity(rows)
+}
+
+func (s *SQLiteStore) ListRecentActivity(ctx context.Context, userID string, limit int) ([]models.DailyActivity, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT user_id, date, points, session_count FROM daily_activity
+		 WHERE user_id = ? ORDER BY date DESC LIMIT ?`,
+		userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily activity: %w", err)
+	}
+	return scanDailyActivity(rows)
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *SQLiteStore) ClaimIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord, now int64) (bool, error) {
+	// Expired rows are purged here since SQLite has no TTL.
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, now); err != nil {
+		return false, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (user_id, key, request_hash, status_code, content_type, body, expires_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (user_id, key) DO NOTHING`,
+		rec.UserID, rec.Key, rec.RequestHash, rec.StatusCode, rec.ContentType, rec.Body, rec.ExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	return n == 1, nil
+}
+
+func (s *SQLiteStore) GetIdempotencyRecord(ctx context.Context, userID, key string, now int64) (*models.IdempotencyRecord, error) {
+	var rec models.IdempotencyRecord
+	err := s.db.QueryRowContext(ctx,
+		`SELECT user_id, key, request_hash, status_code, content_type, body, expires_at
+		 FROM idempotency_keys WHERE user_id = ? AND key = ? AND expires_at > ?`,
+		userID, key, now).
+		Scan(&rec.UserID, &rec.Key, &rec.RequestHash, &rec.StatusCode, &rec.ContentType, &rec.Body, &rec.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+	return &rec, nil
+}
+
+func (s *SQLiteStore) PutIdempotencyRecord(ctx context.Context, rec models.IdempotencyRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO idempotency_keys (user_id, key, request_hash, status_code, content_type, body, expires_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		rec.UserID, rec.Key, rec.RequestHash, rec.StatusCode, rec.ContentType, rec.Body, rec.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to put idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DeleteIdempotencyRecord(ctx context.Context, userID, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE user_id = ? AND key = ?`, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency record: %w", err)
+	}
+	return nil
+}
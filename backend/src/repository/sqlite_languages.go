@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// addLanguageActivity adds rows to the stored rollups inside tx.
+func addLanguageActivity(ctx context.Context, tx *sql.Tx, rows []models.LanguageActivity) error {
+	for _, row := range rows {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO language_activity (user_id, date, language, points) VALUES (?, ?, ?, ?)
+			 ON CONFLICT (user_id, date, language) DO UPDATE SET points = points + excluded.points`,
+			row.UserID, row.Date, row.Language, row.Points)
+		if err != nil {
+			return fmt.Errorf("failed to update language activity: %w", err)
+		}
+	}
+	return nil
+}
+
+// heldLanguagePoints returns the user's points per language on date.
+func heldLanguagePoints(ctx context.Context, tx *sql.Tx, userID, date string) (map[string]int, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT language, points FROM language_activity WHERE user_id = ? AND date = ?`, userID, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get language activity: %w", err)
+	}
+	defer rows.Close()
+
+	held := make(map[string]int)
+	for rows.Next() {
+		var language string
+		var points int
+		if err := rows.Scan(&language, &points); err != nil {
+			return nil, fmt.Errorf("failed to scan language activity: %w", err)
+		}
+		held[language] = points
+	}
+	return held, rows.Err()
+}
+
+func scanLanguageTotals(rows *sql.Rows) ([]LanguageTotal, error) {
+	defer rows.Close()
+
+	var totals []LanguageTotal
+	for rows.Next() {
+		var total LanguageTotal
+		if err := rows.Scan(&total.Language, &total.Points); err != nil {
+			return nil, fmt.Errorf("failed to scan language total: %w", err)
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+func (s *SQLiteStore) ListUserLanguageTotals(ctx context.Context, userID, from, to string) ([]LanguageTotal, error) {
+	from, to = languageDateRange(from, to)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT language, SUM(points) AS total FROM language_activity
+		 WHERE user_id = ? AND date >= ? AND date <= ?
+		 GROUP BY language HAVING total > 0
+		 ORDER BY total DESC, language`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum language activity: %w", err)
+	}
+	return scanLanguageTotals(rows)
+}
+
+func (s *SQLiteStore) ListLanguageTotals(ctx context.Context, from, to string) ([]LanguageTotal, error) {
+	from, to = languageDateRange(from, to)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT language, SUM(points) AS total FROM language_activity
+		 WHERE date >= ? AND date <= ?
+		 GROUP BY language HAVING total > 0
+		 ORDER BY total DESC, language`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum language activity: %w", err)
+	}
+	return scanLanguageTotals(rows)
+}
+
+func (s *SQLiteStore) ListLanguagePointTotals(ctx context.Context, language, from, to string) ([]PointsTotal, error) {
+	from, to = languageDateRange(from, to)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT a.user_id, SUM(a.points) AS total FROM language_activity a
+		 JOIN users u ON u.id = a.user_id
+		 WHERE a.date >= ? AND a.date <= ? AND a.language = ?
+		 GROUP BY a.user_id HAVING total > 0
+		 ORDER BY total DESC, a.user_id`, from, to, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum language activity: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []PointsTotal
+	for rows.Next() {
+		var total PointsTotal
+		if err := rows.Scan(&total.UserID, &total.Points); err != nil {
+			return nil, fmt.Errorf("failed to scan points total: %w", err)
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+func (s *SQLiteStore) ListLanguageActivity(ctx context.Context, userID string) ([]models.LanguageActivity, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT user_id, date, language, points FROM language_activity
+		 WHERE user_id = ? ORDER BY date, language`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query language activity: %w", err)
+	}
+	defer rows.Close()
+
+	var activity []models.LanguageActivity
+	for rows.Next() {
+		var row models.LanguageActivity
+		if err := rows.Scan(&row.UserID, &row.Date, &row.Language, &row.Points); err != nil {
+			return nil, fmt.Errorf("failed to scan language activity: %w", err)
+		}
+		row.DateLanguage = row.Date + "#" + row.Language
+		activity = append(activity, row)
+	}
+	return activity, rows.Err()
+}
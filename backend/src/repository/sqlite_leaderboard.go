@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListTopUsers reads from users_score_idx. Cursors use keyset pagination on
+// (score DESC, id) so deep pages do not rescan earlier rows.
+func (s *SQLiteStore) ListTopUsers(ctx context.Context, offset, limit int, cursor string) (*ScorePage, error) {
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY score DESC, id LIMIT ? OFFSET ?`
+	args := []any{limit + 1, offset}
+	if cursor != "" {
+		c, err := decodeScoreCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		offset = c.Position + 1
+		query = `SELECT ` + userColumns + ` FROM users
+			WHERE score < ? OR (score = ? AND id > ?)
+			ORDER BY score DESC, id LIMIT ?`
+		args = []any{c.Score, c.Score, c.ID, limit + 1}
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
+	}
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ScorePage{Offset: offset, Users: users}
+	if len(users) > limit {
+		page.Users = users[:limit]
+		last := page.Users[limit-1]
+		page.Next = encodeScoreCursor(scoreCursor{Position: offset + limit - 1, Score: last.Score, ID: last.ID})
+	}
+	return page, nil
+}
+
+func (s *SQLiteStore) CountUsersAbove(ctx context.Context, score int) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE score > ?`, score).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
+
+// ListPointTotals reads daily_activity through daily_activity_date_idx.
+func (s *SQLiteStore) ListPointTotals(ctx context.Context, from, to string) ([]PointsTotal, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT a.user_id, SUM(a.points) AS total FROM daily_activity a
+		 JOIN users u ON u.id = a.user_id
+		 WHERE a.date >= ? AND a.date <= ?
+		 GROUP BY a.user_id HAVING total > 0
+		 ORDER BY total DESC, a.user_id`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum daily activity: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []PointsTotal
+	for rows.Next() {
+		var total PointsTotal
+		if err := rows.Scan(&total.UserID, &total.Points); err != nil {
+			return nil, fmt.Errorf("failed to scan points total: %w", err)
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// appendLedgerEntry inserts entry inside tx.
+func appendLedgerEntry(ctx context.Context, tx *sql.Tx, entry models.LedgerEntry) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO score_ledger (user_id, entry_id, delta, source, reference_id, actor_id, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.UserID, entry.EntryID, entry.Delta, entry.Source, entry.ReferenceID, entry.ActorID, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to append ledger entry: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ListLedgerEntries(ctx context.Context, userID string, limit int, cursor string) ([]models.LedgerEntry, string, error) {
+	query := `SELECT user_id, entry_id, delta, source, reference_id, actor_id, created_at
+		 FROM score_ledger WHERE user_id = ?`
+	args := []any{userID}
+	if cursor != "" {
+		before, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query += ` AND entry_id < ?`
+		args = append(args, before)
+	}
+	// Fetch one extra row to learn whether another page exists.
+	query += ` ORDER BY entry_id DESC LIMIT ?`
+	args = append(args, limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.LedgerEntry
+	for rows.Next() {
+		var e models.LedgerEntry
+		if err := rows.Scan(&e.UserID, &e.EntryID, &e.Delta, &e.Source, &e.ReferenceID, &e.ActorID, &e.CreatedAt); err != nil {
+			return nil, "", fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		return entries, encodeCursor(entries[limit-1].EntryID), nil
+	}
+	return entries, "", nil
+}
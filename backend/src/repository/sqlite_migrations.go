@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+type sqliteMigration struct {
+	Version     int
+	Description string
+	SQL         string
+}
+
+// sqliteMigrations must stay in version order and end at SchemaVersion.
+// The applied version is tracked in PRAGMA user_version.
+var sqliteMigrations = []sqliteMigration{
+	{
+		Version:     1,
+		Description: "create users, sessions and daily_activity tables",
+		SQL: `
+CREATE TABLE IF NOT EXISTS users (
+	id    TEXT PRIMARY KEY,
+	name  TEXT NOT NULL DEFAULT '',
+	email TEXT NOT NULL DEFAULT '',
+	score INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	user_id            TEXT NOT NULL,
+	session_id         TEXT NOT NULL,
+	started_at         INTEGER NOT NULL DEFAULT 0,
+	ended_at           INTEGER NOT NULL DEFAULT 0,
+	points             INTEGER NOT NULL DEFAULT 0,
+	language_breakdown TEXT,
+	PRIMARY KEY (user_id, session_id)
+);
+
+CREATE TABLE IF NOT EXISTS daily_activity (
+	user_id       TEXT NOT NULL,
+	date          TEXT NOT NULL,
+	points        INTEGER NOT NULL DEFAULT 0,
+	session_count INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (user_id, date)
+);`,
+	},
+	{
+		Version:     2,
+		Description: "index users by score for the leaderboard",
+		SQL:         `CREATE INDEX IF NOT EXISTS users_score_idx ON users (score DESC, id);`,
+	},
+	{
+		Version:     3,
+		Description: "create idempotency_keys table",
+		SQL: `
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	user_id      TEXT NOT NULL,
+	key          TEXT NOT NULL,
+	request_hash TEXT NOT NULL,
+	status_code  INTEGER NOT NULL DEFAULT 0,
+	content_type TEXT NOT NULL DEFAULT '',
+	body         TEXT NOT NULL DEFAULT '',
+	expires_at   INTEGER NOT NULL,
+	PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_idx ON idempotency_keys (expires_at);`,
+	},
+	{
+		Version:     4,
+		Description: "create refresh_tokens and revoked_tokens tables",
+		SQL: `
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	token_hash TEXT PRIMARY KEY,
+	user_id    TEXT NOT NULL,
+	family_id  TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL,
+	used_at    INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_expires_idx ON refresh_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	id         TEXT PRIMARY KEY,
+	expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_idx ON revoked_tokens (expires_at);`,
+	},
+	{
+		Version:     5,
+		Description: "add roles and banned to users",
+		SQL: `
+ALTER TABLE users ADD COLUMN roles TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN banned INTEGER NOT NULL DEFAULT 0;`,
+	},
+	{
+		Version:     6,
+		Description: "create personal_access_tokens table",
+		SQL: `
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+	token_hash   TEXT PRIMARY KEY,
+	id           TEXT NOT NULL,
+	user_id      TEXT NOT NULL,
+	name         TEXT NOT NULL DEFAULT '',
+	scopes       TEXT NOT NULL DEFAULT '',
+	created_at   INTEGER NOT NULL,
+	expires_at   INTEGER NOT NULL DEFAULT 0,
+	last_used_at INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS personal_access_tokens_user_idx ON personal_access_tokens (user_id, id);`,
+	},
+	{
+		Version:     7,
+		Description: "add GitHub profile and activity timestamps to users",
+		SQL: `
+ALTER TABLE users ADD COLUMN github_login TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN last_seen_at INTEGER NOT NULL DEFAULT 0;`,
+	},
+	{
+		Version:     8,
+		Description: "create identities and user_merges tables",
+		SQL: `
+CREATE TABLE IF NOT EXISTS identities (
+	id        TEXT PRIMARY KEY,
+	provider  TEXT NOT NULL,
+	subject   TEXT NOT NULL,
+	user_id   TEXT NOT NULL,
+	login     TEXT NOT NULL DEFAULT '',
+	linked_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS identities_user_idx ON identities (user_id, id);
+
+CREATE TABLE IF NOT EXISTS user_merges (
+	source_id    TEXT PRIMARY KEY,
+	target_id    TEXT NOT NULL,
+	requested_by TEXT NOT NULL DEFAULT '',
+	step         TEXT NOT NULL,
+	started_at   INTEGER NOT NULL,
+	updated_at   INTEGER NOT NULL,
+	completed_at INTEGER NOT NULL DEFAULT 0
+);`,
+	},
+	{
+		Version:     9,
+		Description: "create streaks table",
+		SQL: `
+CREATE TABLE IF NOT EXISTS streaks (
+	user_id        TEXT PRIMARY KEY,
+	current_start  TEXT NOT NULL DEFAULT '',
+	current_end    TEXT NOT NULL DEFAULT '',
+	current_length INTEGER NOT NULL DEFAULT 0,
+	longest_start  TEXT NOT NULL DEFAULT '',
+	longest_end    TEXT NOT NULL DEFAULT '',
+	longest_length INTEGER NOT NULL DEFAULT 0,
+	updated_at     INTEGER NOT NULL DEFAULT 0,
+	version        INTEGER NOT NULL
+);`,
+	},
+	{
+		Version:     10,
+		Description: "index daily_activity by date for windowed leaderboards",
+		SQL: `
+CREATE INDEX IF NOT EXISTS daily_activity_date_idx ON daily_activity (date, user_id, points);`,
+	},
+	{
+		Version:     11,
+		Description: "create language_activity table",
+		SQL: `
+CREATE TABLE IF NOT EXISTS language_activity (
+	user_id  TEXT NOT NULL,
+	date     TEXT NOT NULL,
+	language TEXT NOT NULL,
+	points   INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (user_id, date, language)
+);
+
+CREATE INDEX IF NOT EXISTS language_activity_date_idx ON language_activity (date, language, user_id, points);`,
+	},
+	{
+		Version:     12,
+		Description: "add timezone to users and date to sessions",
+		SQL: `
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN date TEXT NOT NULL DEFAULT '';`,
+	},
+	{
+		Version:     13,
+		Description: "add days to sessions",
+		SQL:         `ALTER TABLE sessions ADD COLUMN days TEXT;`,
+	},
+	{
+		Version:     14,
+		Description: "add deleted_at and corrections to sessions",
+		SQL: `
+ALTER TABLE sessions ADD COLUMN deleted_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN corrections TEXT;`,
+	},
+	{
+		Version:     15,
+		Description: "create score_ledger table",
+		SQL: `
+CREATE TABLE IF NOT EXISTS score_ledger (
+	user_id      TEXT NOT NULL,
+	entry_id     TEXT NOT NULL,
+	delta        INTEGER NOT NULL,
+	source       TEXT NOT NULL,
+	reference_id TEXT NOT NULL DEFAULT '',
+	actor_id     TEXT NOT NULL DEFAULT '',
+	created_at   INTEGER NOT NULL,
+	PRIMARY KEY (user_id, entry_id)
+);`,
+	},
+	{
+		Version:     16,
+		Description: "add issued_before to revoked_tokens",
+		SQL: `
+ALTER TABLE revoked_tokens ADD COLUMN issued_before INTEGER NOT NULL DEFAULT 0;`,
+	},
+	{
+		Version:     17,
+		Description: "add first_date and last_date to sessions and index the session sort orders",
+		SQL: `
+ALTER TABLE sessions ADD COLUMN first_date TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN last_date TEXT NOT NULL DEFAULT '';
+UPDATE sessions SET
+	first_date = COALESCE((SELECT MIN(json_extract(value, '$.date')) FROM json_each(sessions.days)), NULLIF(date, ''),
+		CASE WHEN ended_at > 0 THEN date(ended_at, 'unixepoch') END, ''),
+	last_date = COALESCE((SELECT MAX(json_extract(value, '$.date')) FROM json_each(sessions.days)), NULLIF(date, ''),
+		CASE WHEN ended_at > 0 THEN date(ended_at, 'unixepoch') END, '');
+CREATE INDEX IF NOT EXISTS sessions_ended_at_idx ON sessions (user_id, ended_at);
+CREATE INDEX IF NOT EXISTS sessions_started_at_idx ON sessions (user_id, started_at);
+CREATE INDEX IF NOT EXISTS sessions_points_idx ON sessions (user_id, points);`,
+	},
+	{
+		Version:     18,
+		Description: "no change; windowed totals already sum daily_activity through daily_activity_date_idx",
+		SQL:         `SELECT 1;`,
+	},
+}
+
+func (s *SQLiteStore) AppliedSchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	if err := s.db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Migrate runs each pending migration and its version bump in one transaction.
+func (s *SQLiteStore) Migrate(ctx context.Context) error {
+	current, err := s.AppliedSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range sqliteMigrations {
+		if m.Version <= current {
+			continue
+		}
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+		}
+		if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`PRAGMA user_version = %d`, m.Version)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record schema version: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+const personalTokenColumns = `token_hash, id, user_id, name, scopes, created_at, expires_at, last_used_at`
+
+func scanPersonalToken(row rowScanner) (models.PersonalAccessToken, error) {
+	var t models.PersonalAccessToken
+	var scopes string
+	err := row.Scan(&t.TokenHash, &t.ID, &t.UserID, &t.Name, &scopes, &t.CreatedAt, &t.ExpiresAt, &t.LastUsedAt)
+	if err != nil {
+		return t, err
+	}
+	if scopes != "" {
+		t.Scopes = strings.Split(scopes, ",")
+	}
+	return t, nil
+}
+
+func (s *SQLiteStore) CreatePersonalAccessToken(ctx context.Context, token models.PersonalAccessToken) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO personal_access_tokens (`+personalTokenColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		token.TokenHash, token.ID, token.UserID, token.Name, strings.Join(token.Scopes, ","),
+		token.CreatedAt, token.ExpiresAt, token.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create personal access token: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetPersonalAccessToken(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	token, err := scanPersonalToken(s.db.QueryRowContext(ctx,
+		`SELECT `+personalTokenColumns+` FROM personal_access_tokens WHERE token_hash = ?`, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get personal access token: %w", err)
+	}
+	return &token, nil
+}
+
+func (s *SQLiteStore) ListPersonalAccessTokens(ctx context.Context, userID string) ([]models.PersonalAccessToken, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+personalTokenColumns+` FROM personal_access_tokens
+		 WHERE user_id = ? ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personal access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []models.PersonalAccessToken
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal access token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (s *SQLiteStore) DeletePersonalAccessToken(ctx context.Context, userID, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM personal_access_tokens WHERE user_id = ? AND id = ?`, userID, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete personal access token: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete personal access token: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *SQLiteStore) TouchPersonalAccessToken(ctx context.Context, tokenHash string, usedAt int64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE personal_access_tokens SET last_used_at = ? WHERE token_hash = ?`, usedAt, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to update personal access token: %w", err)
+	}
+	return nil
+}
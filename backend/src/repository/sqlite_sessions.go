@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *SQLiteStore) RecordSession(ctx context.Context, session models.Session, entry models.LedgerEntry) (bool, error) {
+	breakdown, days, corrections, err := marshalSessionJSON(session)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	first, last := sessionDateRange(session)
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO sessions (`+sessionColumns+`, first_date, last_date)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (user_id, session_id) DO NOTHING`,
+		session.UserID, session.SessionID, session.StartedAt, session.EndedAt, session.Points, breakdown, session.Date, days,
+		session.DeletedAt, corrections, first, last)
+	if err != nil {
+		return false, fmt.Errorf("failed to put session: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to put session: %w", err)
+	}
+	if n == 0 {
+		// Replayed SessionID: leave score and activity untouched.
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO users (id, score) VALUES (?, ?)
+		 ON CONFLICT (id) DO UPDATE SET score = score + excluded.score`,
+		session.UserID, session.Points)
+	if err != nil {
+		return false, fmt.Errorf("failed to add user score: %w", err)
+	}
+	if err := appendLedgerEntry(ctx, tx, ledgerEntry(entry, session.UserID, session.Points)); err != nil {
+		return false, err
+	}
+
+	for i, day := range session.Days {
+		sessions := 0
+		if i == 0 {
+			sessions = 1
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO daily_activity (user_id, date, points, session_count) VALUES (?, ?, ?, ?)
+			 ON CONFLICT (user_id, date) DO UPDATE SET
+				points = points + excluded.points,
+				session_count = session_count + excluded.session_count`,
+			session.UserID, day.Date, day.Points, sessions)
+		if err != nil {
+			return false, fmt.Errorf("failed to update daily activity: %w", err)
+		}
+	}
+
+	if err := addLanguageActivity(ctx, tx, languageActivityRows(session.UserID, session.Days)); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit session: %w", err)
+	}
+	return true, nil
+}
+
+// sessionColumns is the column list scanSession expects.
+const sessionColumns = `user_id, session_id, started_at, ended_at, points, language_breakdown, date, days, deleted_at, corrections`
+
+// marshalSessionJSON encodes the session's JSON columns: the language
+// breakdown, the days and the corrections.
+func marshalSessionJSON(session models.Session) (string, string, string, error) {
+	breakdown, err := json.Marshal(session.LanguageBreakdown)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to marshal session: %w", err)
+	}
+	days, err := json.Marshal(session.Days)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to marshal session: %w", err)
+	}
+	corrections, err := json.Marshal(session.Corrections)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to marshal session: %w", err)
+	}
+	return string(breakdown), string(days), string(corrections), nil
+}
+
+func scanSession(row rowScanner) (models.Session, error) {
+	var session models.Session
+	var breakdown, days, corrections sql.NullString
+	if err := row.Scan(&session.UserID, &session.SessionID, &session.StartedAt, &session.EndedAt,
+		&session.Points, &breakdown, &session.Date, &days, &session.DeletedAt, &corrections); err != nil {
+		return session, err
+	}
+	if breakdown.Valid && breakdown.String != "" {
+		if err := json.Unmarshal([]byte(breakdown.String), &session.LanguageBreakdown); err != nil {
+			return session, fmt.Errorf("failed to unmarshal session: %w", err)
+		}
+	}
+	if days.Valid && days.String != "" {
+		if err := json.Unmarshal([]byte(days.String), &session.Days); err != nil {
+			return session, fmt.Errorf("failed to unmarshal session: %w", err)
+		}
+	}
+	if corrections.Valid && corrections.String != "" {
+		if err := json.Unmarshal([]byte(corrections.String), &session.Corrections); err != nil {
+			return session, fmt.Errorf("failed to unmarshal session: %w", err)
+		}
+	}
+	return session, nil
+}
+
+func (s *SQLiteStore) ListUserSessions(ctx context.Context, userID string, limit int) ([]models.Session, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+sessionColumns+` FROM sessions WHERE user_id = ? ORDER BY session_id LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// sessionSortColumns maps SessionQuery.SortBy to the column it orders by.
+var sessionSortColumns = map[string]string{
+	SessionSortEndedAt:   "ended_at",
+	SessionSortStartedAt: "started_at",
+	SessionSortPoints:    "points",
+}
+
+func (s *SQLiteStore) ListSessions(ctx context.Context, userID string, query SessionQuery) ([]models.Session, string, error) {
+	column, ok := sessionSortColumns[query.SortBy]
+	if !ok {
+		column = "ended_at"
+	}
+	order, after := "ASC", ">"
+	if query.Descending {
+		order, after = "DESC", "<"
+	}
+
+	stmt := `SELECT ` + sessionColumns + ` FROM sessions WHERE user_id = ?`
+	args := []any{userID}
+	if !query.Deleted {
+		stmt += ` AND deleted_at = 0`
+	}
+	if query.From != "" || query.To != "" {
+		from, to := sessionDateBounds(query)
+		stmt += ` AND last_date >= ? AND first_date <= ?`
+		args = append(args, from, to)
+	}
+	if query.Language != "" {
+		stmt += ` AND EXISTS (SELECT 1 FROM json_each(sessions.language_breakdown) WHERE key = ? AND value > 0)`
+		args = append(args, query.Language)
+	}
+	if query.Cursor != "" {
+		c, err := decodeSessionCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		stmt += ` AND (` + column + ` ` + after + ` ? OR (` + column + ` = ? AND session_id > ?))`
+		args = append(args, c.Value, c.Value, c.SessionID)
+	}
+	// Fetch one extra row to learn whether another page exists.
+	stmt += ` ORDER BY ` + column + ` ` + order + `, session_id LIMIT ?`
+	args = append(args, query.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(sessions) > query.Limit {
+		sessions = sessions[:query.Limit]
+		last := sessions[query.Limit-1]
+		return sessions, encodeSessionCursor(sessionCursor{Value: sessionSortValue(last, query.SortBy), SessionID: last.SessionID}), nil
+	}
+	return sessions, "", nil
+}
+
+func (s *SQLiteStore) GetSession(ctx context.Context, userID, sessionID string) (*models.Session, error) {
+	session, err := scanSession(s.db.QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM sessions WHERE user_id = ? AND session_id = ?`, userID, sessionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &session, nil
+}
+
+func (s *SQLiteStore) RedateSession(ctx context.Context, session models.Session, from, to string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	dated := session
+	dated.Date = to
+	first, last := sessionDateRange(dated)
+	res, err := tx.ExecContext(ctx,
+		`UPDATE sessions SET date = ?, first_date = ?, last_date = ?
+		 WHERE user_id = ? AND session_id = ? AND date = '' AND deleted_at = 0`,
+		to, first, last, session.UserID, session.SessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update session date: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if from != to {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE daily_activity SET points = points - ?, session_count = session_count - 1
+			 WHERE user_id = ? AND date = ? AND points >= ? AND session_count >= 1`,
+			session.Points, session.UserID, from, session.Points)
+		if err != nil {
+			return false, fmt.Errorf("failed to update daily activity: %w", err)
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return false, err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO daily_activity (user_id, date, points, session_count) VALUES (?, ?, ?, 1)
+			 ON CONFLICT (user_id, date) DO UPDATE SET
+				points = points + excluded.points,
+				session_count = session_count + 1`,
+			session.UserID, to, session.Points)
+		if err != nil {
+			return false, fmt.Errorf("failed to update daily activity: %w", err)
+		}
+
+		held, err := heldLanguagePoints(ctx, tx, session.UserID, from)
+		if err != nil {
+			return false, err
+		}
+		if err := addLanguageActivity(ctx, tx, redateLanguageRows(session, from, to, held)); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit session date: %w", err)
+	}
+	return true, nil
+}
+
+func (s *SQLiteStore) CorrectSession(ctx context.Context, old, corrected models.Session, entry models.LedgerEntry) (bool, error) {
+	breakdown, days, corrections, err := marshalSessionJSON(corrected)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	first, last := sessionDateRange(corrected)
+	res, err := tx.ExecContext(ctx,
+		`UPDATE sessions SET points = ?, language_breakdown = ?, date = ?, days = ?, deleted_at = ?, corrections = ?,
+		 	first_date = ?, last_date = ?
+		 WHERE user_id = ? AND session_id = ? AND points = ? AND date = ? AND deleted_at = 0`,
+		corrected.Points, breakdown, corrected.Date, days, corrected.DeletedAt, corrections, first, last,
+		old.UserID, old.SessionID, old.Points, old.Date)
+	if err != nil {
+		return false, fmt.Errorf("failed to update session: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	correction := correctionDeltas(old, corrected)
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO users (id, score) VALUES (?, ?)
+		 ON CONFLICT (id) DO UPDATE SET score = score + excluded.score`,
+		old.UserID, correction.Points)
+	if err != nil {
+		return false, fmt.Errorf("failed to add user score: %w", err)
+	}
+	if err := appendLedgerEntry(ctx, tx, ledgerEntry(entry, old.UserID, correction.Points)); err != nil {
+		return false, err
+	}
+	for _, row := range correction.Activity {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO daily_activity (user_id, date, points, session_count) VALUES (?, ?, ?, ?)
+			 ON CONFLICT (user_id, date) DO UPDATE SET
+				points = points + excluded.points,
+				session_count = session_count + excluded.session_count`,
+			row.UserID, row.Date, row.Points, row.SessionCount)
+		if err != nil {
+			return false, fmt.Errorf("failed to update daily activity: %w", err)
+		}
+	}
+	if err := addLanguageActivity(ctx, tx, correction.Languages); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit session correction: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+const streakColumns = `user_id, current_start, current_end, current_length,
+	longest_start, longest_end, longest_length, updated_at, version`
+
+func (s *SQLiteStore) GetStreak(ctx context.Context, userID string) (*models.Streak, error) {
+	var streak models.Streak
+	err := s.db.QueryRowContext(ctx,
+		`SELECT `+streakColumns+` FROM streaks WHERE user_id = ?`, userID).
+		Scan(&streak.UserID, &streak.CurrentStart, &streak.CurrentEnd, &streak.CurrentLength,
+			&streak.LongestStart, &streak.LongestEnd, &streak.LongestLength, &streak.UpdatedAt, &streak.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get streak: %w", err)
+	}
+	return &streak, nil
+}
+
+func (s *SQLiteStore) GetStreaks(ctx context.Context, userIDs []string) ([]models.Streak, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(userIDs)), ", ")
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+streakColumns+` FROM streaks WHERE user_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get streaks: %w", err)
+	}
+	defer rows.Close()
+
+	var streaks []models.Streak
+	for rows.Next() {
+		var streak models.Streak
+		if err := rows.Scan(&streak.UserID, &streak.CurrentStart, &streak.CurrentEnd, &streak.CurrentLength,
+			&streak.LongestStart, &streak.LongestEnd, &streak.LongestLength, &streak.UpdatedAt, &streak.Version); err != nil {
+			return nil, fmt.Errorf("failed to scan streak: %w", err)
+		}
+		streaks = append(streaks, streak)
+	}
+	return streaks, rows.Err()
+}
+
+func (s *SQLiteStore) SaveStreak(ctx context.Context, streak models.Streak, version int) (bool, error) {
+	// A first write (version 0) must not replace an existing row, whose
+	// version is at least 1, so the update is guarded by the old version.
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO streaks (`+streakColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (user_id) DO UPDATE SET
+			current_start = excluded.current_start,
+			current_end = excluded.current_end,
+			current_length = excluded.current_length,
+			longest_start = excluded.longest_start,
+			longest_end = excluded.longest_end,
+			longest_length = excluded.longest_length,
+			updated_at = excluded.updated_at,
+			version = excluded.version
+		 WHERE streaks.version = ?`,
+		streak.UserID, streak.CurrentStart, streak.CurrentEnd, streak.CurrentLength,
+		streak.LongestStart, streak.LongestEnd, streak.LongestLength, streak.UpdatedAt, version+1, version)
+	if err != nil {
+		return false, fmt.Errorf("failed to save streak: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to save streak: %w", err)
+	}
+	return n > 0, nil
+}
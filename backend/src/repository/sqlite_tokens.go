@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *SQLiteStore) CreateRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	// Expired rows are purged here since SQLite has no TTL.
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= ?`, token.CreatedAt); err != nil {
+		return fmt.Errorf("failed to purge refresh tokens: %w", err)
+	}
+	if err := insertRefreshToken(ctx, s.db, token); err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertRefreshToken(ctx context.Context, db sqlExecer, token models.RefreshToken) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO refresh_tokens (token_hash, user_id, family_id, created_at, expires_at, used_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		token.TokenHash, token.UserID, token.FamilyID, token.CreatedAt, token.ExpiresAt, token.UsedAt)
+	return err
+}
+
+func (s *SQLiteStore) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := s.db.QueryRowContext(ctx,
+		`SELECT token_hash, user_id, family_id, created_at, expires_at, used_at
+		 FROM refresh_tokens WHERE token_hash = ?`, tokenHash).
+		Scan(&token.TokenHash, &token.UserID, &token.FamilyID, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+func (s *SQLiteStore) RotateRefreshToken(ctx context.Context, oldHash string, next models.RefreshToken, now int64) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET used_at = ? WHERE token_hash = ? AND used_at = 0`, now, oldHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return false, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit refresh token rotation: %w", err)
+	}
+	return true, nil
+}
+
+func (s *SQLiteStore) RevokeToken(ctx context.Context, revoked models.RevokedToken) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= unixepoch()`); err != nil {
+		return fmt.Errorf("failed to purge revoked tokens: %w", err)
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO revoked_tokens (id, expires_at, issued_before) VALUES (?, ?, ?)
+		 ON CONFLICT (id) DO UPDATE SET expires_at = MAX(expires_at, excluded.expires_at),
+		 	issued_before = excluded.issued_before`,
+		revoked.ID, revoked.ExpiresAt, revoked.IssuedBefore)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) IsTokenRevoked(ctx context.Context, now, issuedAt int64, ids ...string) (bool, error) {
+	if len(ids) == 0 {
+		return false, nil
+	}
+	args := make([]any, 0, len(ids)+2)
+	args = append(args, now, issuedAt)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+	var revoked bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE expires_at > ? AND (issued_before = 0 OR issued_before > ?) AND id IN (`+placeholders+`))`,
+		args...).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return revoked, nil
+}
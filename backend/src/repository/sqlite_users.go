@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func (s *SQLiteStore) ListUsers(ctx context.Context) ([]models.User, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return scanUsers(rows)
+}
+
+func (s *SQLiteStore) ListUsersPage(ctx context.Context, limit int, cursor string) ([]models.User, string, error) {
+	after := ""
+	if cursor != "" {
+		id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = id
+	}
+
+	// Fetch one extra row to learn whether another page exists.
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+userColumns+` FROM users WHERE id > ? ORDER BY id LIMIT ?`, after, limit+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list users: %w", err)
+	}
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(users) > limit {
+		users = users[:limit]
+		return users, encodeCursor(users[limit-1].ID), nil
+	}
+	return users, "", nil
+}
+
+// userColumns is the column list scanUser expects.
+const userColumns = `id, name, email, score, roles, banned, github_login, avatar_url, created_at, last_seen_at, timezone`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (models.User, error) {
+	var u models.User
+	var roles string
+	if err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Score, &roles, &u.Banned,
+		&u.GithubLogin, &u.AvatarURL, &u.CreatedAt, &u.LastSeenAt, &u.Timezone); err != nil {
+		return u, err
+	}
+	u.Roles = splitRoles(roles)
+	return u, nil
+}
+
+// Roles are stored comma-separated; role names never contain commas.
+func splitRoles(roles string) []string {
+	if roles == "" {
+		return nil
+	}
+	return strings.Split(roles, ",")
+}
+
+func scanUsers(rows *sql.Rows) ([]models.User, error) {
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (s *SQLiteStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	u, err := scanUser(s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &u, nil
+}
+
+func (s *SQLiteStore) GetUsers(ctx context.Context, ids []string) ([]models.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	return scanUsers(rows)
+}
+
+func (s *SQLiteStore) PutUser(ctx context.Context, user models.User) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		user.ID, user.Name, user.Email, user.Score, strings.Join(user.Roles, ","), user.Banned,
+		user.GithubLogin, user.AvatarURL, user.CreatedAt, user.LastSeenAt, user.Timezone)
+	if err != nil {
+		return fmt.Errorf("failed to put user: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) CreateUser(ctx context.Context, user models.User) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (id) DO NOTHING`,
+		user.ID, user.Name, user.Email, user.Score, strings.Join(user.Roles, ","), user.Banned,
+		user.GithubLogin, user.AvatarURL, user.CreatedAt, user.LastSeenAt, user.Timezone)
+	if err != nil {
+		return false, fmt.Errorf("failed to create user: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to create user: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *SQLiteStore) UpdateUserProfile(ctx context.Context, id, name, email string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO users (id, name, email) VALUES (?, ?, ?)
+		 ON CONFLICT (id) DO UPDATE SET name = excluded.name, email = excluded.email`,
+		id, name, email)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) UpdateUserIdentity(ctx context.Context, id, githubLogin, avatarURL string, seenAt int64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE users SET github_login = COALESCE(NULLIF(?, ''), github_login), avatar_url = ?, last_seen_at = ?,
+		 	created_at = CASE WHEN created_at = 0 THEN ? ELSE created_at END
+		 WHERE id = ?`,
+		githubLogin, avatarURL, seenAt, seenAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to update user identity: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SetUserScore(ctx context.Context, id string, score int, entry models.LedgerEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var old int
+	err = tx.QueryRowContext(ctx, `SELECT score FROM users WHERE id = ?`, id).Scan(&old)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get user score: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO users (id, score) VALUES (?, ?)
+		 ON CONFLICT (id) DO UPDATE SET score = excluded.score`,
+		id, score)
+	if err != nil {
+		return fmt.Errorf("failed to update user score: %w", err)
+	}
+	if err := appendLedgerEntry(ctx, tx, ledgerEntry(entry, id, score-old)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user score: %w", err)
+	}
+	return nil
+}
+
+// AddUserScore performs the increment in a single statement so concurrent
+// callers cannot lose updates, matching DynamoDB's ADD semantics.
+func (s *SQLiteStore) AddUserScore(ctx context.Context, id string, increment int, date string, entry models.LedgerEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO users (id, score) VALUES (?, ?)
+		 ON CONFLICT (id) DO UPDATE SET score = score + excluded.score`,
+		id, increment)
+	if err != nil {
+		return fmt.Errorf("failed to add user score: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO daily_activity (user_id, date, points, session_count) VALUES (?, ?, ?, 0)
+		 ON CONFLICT (user_id, date) DO UPDATE SET points = points + excluded.points`,
+		id, date, increment)
+	if err != nil {
+		return fmt.Errorf("failed to update daily activity: %w", err)
+	}
+	if err := appendLedgerEntry(ctx, tx, ledgerEntry(entry, id, increment)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user score: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SetUserRoles(ctx context.Context, id string, roles []string) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE users SET roles = ? WHERE id = ?`, strings.Join(roles, ","), id); err != nil {
+		return fmt.Errorf("failed to set user roles: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SetUserBanned(ctx context.Context, id string, banned bool) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE users SET banned = ? WHERE id = ?`, banned, id); err != nil {
+		return fmt.Errorf("failed to set user banned: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) TouchUserLastSeen(ctx context.Context, id string, seenAt int64) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET last_seen_at = ? WHERE id = ? AND last_seen_at < ?`, seenAt, id, seenAt)
+	if err != nil {
+		return fmt.Errorf("failed to update user last seen: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SetUserTimezone(ctx context.Context, id, timezone string) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE users SET timezone = ? WHERE id = ?`, timezone, id); err != nil {
+		return fmt.Errorf("failed to update user timezone: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DeleteUser(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,512 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// The conformance tests below run against every backend that works without
+// external services; the DynamoDB store has to pass the same behaviour.
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store { return NewMemoryStore() })
+}
+
+func TestSQLiteStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "devverse.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		if err := s.Migrate(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s Store)
+	}{
+		{"Users", testStoreUsers},
+		{"ListUsersPage", testStoreListUsersPage},
+		{"UpdateUserIdentity", testStoreUpdateUserIdentity},
+		{"AddUserScore", testStoreAddUserScore},
+		{"Leaderboard", testStoreLeaderboard},
+		{"RecordSession", testStoreRecordSession},
+		{"RedateSession", testStoreRedateSession},
+		{"ListSessions", testStoreListSessions},
+		{"RevokedTokens", testStoreRevokedTokens},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { tt.run(t, newStore(t)) })
+	}
+}
+
+func testStoreUsers(t *testing.T, s Store) {
+	ctx := context.Background()
+	if user, err := s.GetUser(ctx, "nobody"); err != nil || user != nil {
+		t.Fatalf("GetUser(missing) = %v, %v, want nil, nil", user, err)
+	}
+	want := models.User{ID: "u1", Name: "Ada", Email: "ada@example.com", Score: 5}
+	if err := s.PutUser(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	user, err := s.GetUser(ctx, "u1")
+	if err != nil || user == nil || user.Name != want.Name || user.Email != want.Email || user.Score != want.Score {
+		t.Fatalf("GetUser = %+v, %v, want %+v", user, err, want)
+	}
+
+	if created, err := s.CreateUser(ctx, models.User{ID: "u1", Name: "Other"}); err != nil || created {
+		t.Errorf("CreateUser(existing) = %v, %v, want false", created, err)
+	}
+	if user, _ := s.GetUser(ctx, "u1"); user == nil || user.Name != want.Name {
+		t.Errorf("CreateUser overwrote the existing user: %+v", user)
+	}
+	if created, err := s.CreateUser(ctx, models.User{ID: "u2", Name: "Grace"}); err != nil || !created {
+		t.Errorf("CreateUser(new) = %v, %v, want true", created, err)
+	}
+
+	if err := s.SetUserBanned(ctx, "nobody", true); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := s.GetUser(ctx, "nobody"); user != nil {
+		t.Errorf("SetUserBanned created missing user %+v", user)
+	}
+
+	if err := s.DeleteUser(ctx, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if user, err := s.GetUser(ctx, "u1"); err != nil || user != nil {
+		t.Errorf("GetUser after DeleteUser = %+v, %v", user, err)
+	}
+}
+
+// The last page carries no cursor, even when it is exactly full.
+func testStoreListUsersPage(t *testing.T, s Store) {
+	ctx := context.Background()
+	for _, id := range []string{"u1", "u2", "u3"} {
+		if err := s.PutUser(ctx, models.User{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var ids []string
+	next := ""
+	for pages := 0; ; pages++ {
+		users, cursor, err := s.ListUsersPage(ctx, 2, next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) == 0 {
+			t.Fatalf("page %d is empty", pages)
+		}
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		if cursor == "" {
+			break
+		}
+		next = cursor
+	}
+	if len(ids) != 3 {
+		t.Errorf("paged through %v, want 3 users", ids)
+	}
+
+	if users, cursor, err := s.ListUsersPage(ctx, 3, ""); err != nil || len(users) != 3 || cursor != "" {
+		t.Errorf("ListUsersPage(3) = %d users, cursor %q, %v, want 3 users and no cursor", len(users), cursor, err)
+	}
+	if _, _, err := s.ListUsersPage(ctx, 2, "not a cursor"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("ListUsersPage(bad cursor) error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func testStoreUpdateUserIdentity(t *testing.T, s Store) {
+	ctx := context.Background()
+	if err := s.UpdateUserIdentity(ctx, "nobody", "login", "avatar", 100); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := s.GetUser(ctx, "nobody"); user != nil {
+		t.Fatalf("UpdateUserIdentity created missing user %+v", user)
+	}
+
+	err := s.PutUser(ctx, models.User{ID: "u1", Name: "Edited", Email: "edited@example.com", GithubLogin: "old", Score: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateUserIdentity(ctx, "u1", "", "avatar-1", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateUserIdentity(ctx, "u1", "new", "avatar-2", 200); err != nil {
+		t.Fatal(err)
+	}
+	user, err := s.GetUser(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.User{ID: "u1", Name: "Edited", Email: "edited@example.com", GithubLogin: "new", AvatarURL: "avatar-2", Score: 7, LastSeenAt: 200, CreatedAt: 100}
+	if user.Name != want.Name || user.Email != want.Email || user.GithubLogin != want.GithubLogin || user.AvatarURL != want.AvatarURL ||
+		user.Score != want.Score || user.LastSeenAt != want.LastSeenAt || user.CreatedAt != want.CreatedAt {
+		t.Errorf("user = %+v, want %+v", *user, want)
+	}
+}
+
+func testStoreAddUserScore(t *testing.T, s Store) {
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry := models.LedgerEntry{EntryID: fmt.Sprintf("e%02d", i), Source: models.LedgerSourceManualAdd, CreatedAt: int64(i)}
+			errs <- s.AddUserScore(ctx, "u1", 3, "2024-05-01", entry)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	user, err := s.GetUser(ctx, "u1")
+	if err != nil || user == nil || user.Score != 60 {
+		t.Fatalf("GetUser = %+v, %v, want score 60", user, err)
+	}
+	activity, err := s.ListActivitySince(ctx, "u1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activity) != 1 || activity[0].Date != "2024-05-01" || activity[0].Points != 60 || activity[0].SessionCount != 0 {
+		t.Errorf("activity = %+v, want 60 points on 2024-05-01", activity)
+	}
+
+	var entries []models.LedgerEntry
+	cursor := ""
+	for {
+		page, next, err := s.ListLedgerEntries(ctx, "u1", 7, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, page...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(entries) != 20 {
+		t.Fatalf("ledger has %d entries, want 20", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.EntryID != fmt.Sprintf("e%02d", 19-i) || entry.Delta != 3 || entry.UserID != "u1" {
+			t.Errorf("entries[%d] = %+v", i, entry)
+		}
+	}
+
+	if err := s.SetUserScore(ctx, "u1", 10, models.LedgerEntry{EntryID: "e99", Source: models.LedgerSourceAdminSet}); err != nil {
+		t.Fatal(err)
+	}
+	latest, _, err := s.ListLedgerEntries(ctx, "u1", 1, "")
+	if err != nil || len(latest) != 1 || latest[0].Delta != -50 {
+		t.Errorf("SetUserScore ledger entry = %+v, %v, want delta -50", latest, err)
+	}
+}
+
+func testStoreLeaderboard(t *testing.T, s Store) {
+	ctx := context.Background()
+	for _, u := range []models.User{{ID: "a", Score: 10}, {ID: "b", Score: 30}, {ID: "c", Score: 10}, {ID: "d", Score: 0}} {
+		if err := s.PutUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ids []string
+	page, err := s.ListTopUsers(ctx, 0, 3, "")
+	for err == nil {
+		for _, u := range page.Users {
+			ids = append(ids, u.ID)
+		}
+		if page.Next == "" {
+			break
+		}
+		page, err = s.ListTopUsers(ctx, 0, 3, page.Next)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b", "a", "c", "d"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ListTopUsers order = %v, want %v", ids, want)
+	}
+	if page, err := s.ListTopUsers(ctx, 1, 2, ""); err != nil || page.Offset != 1 || len(page.Users) != 2 || page.Users[0].ID != "a" {
+		t.Errorf("ListTopUsers(offset 1) = %+v, %v", page, err)
+	}
+	if n, err := s.CountUsersAbove(ctx, 10); err != nil || n != 1 {
+		t.Errorf("CountUsersAbove(10) = %d, %v, want 1", n, err)
+	}
+
+	for _, row := range []models.DailyActivity{
+		{UserID: "a", Date: "2024-01-31", Points: 100},
+		{UserID: "a", Date: "2024-02-01", Points: 4},
+		{UserID: "b", Date: "2024-02-10", Points: 4},
+		{UserID: "c", Date: "2024-02-29", Points: 9},
+		{UserID: "gone", Date: "2024-02-15", Points: 50},
+	} {
+		if err := s.AddDailyActivity(ctx, row.UserID, row.Date, row.Points, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	totals, err := s.ListPointTotals(ctx, "2024-02-01", "2024-02-29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []PointsTotal{{"c", 9}, {"a", 4}, {"b", 4}}; !reflect.DeepEqual(totals, want) {
+		t.Errorf("ListPointTotals = %v, want %v", totals, want)
+	}
+}
+
+func testStoreRecordSession(t *testing.T, s Store) {
+	ctx := context.Background()
+	session := models.Session{
+		UserID: "u1", SessionID: "s1", StartedAt: 1000, EndedAt: 2000, Points: 12,
+		LanguageBreakdown: map[string]int{"go": 12},
+		Date:              "2024-03-01",
+		Days: []models.SessionDay{
+			{Date: "2024-03-01", Points: 5, Languages: map[string]int{"go": 5}},
+			{Date: "2024-03-02", Points: 7, Languages: map[string]int{"go": 7}},
+		},
+	}
+	entry := models.LedgerEntry{EntryID: "e1", Source: models.LedgerSourceSession, ReferenceID: "s1"}
+	for i, want := range []bool{true, false} {
+		stored, err := s.RecordSession(ctx, session, entry)
+		if err != nil || stored != want {
+			t.Fatalf("RecordSession #%d = %v, %v, want %v", i+1, stored, err, want)
+		}
+	}
+
+	user, err := s.GetUser(ctx, "u1")
+	if err != nil || user == nil || user.Score != 12 {
+		t.Fatalf("GetUser = %+v, %v, want score 12", user, err)
+	}
+	activity, err := s.ListActivitySince(ctx, "u1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.DailyActivity{
+		{UserID: "u1", Date: "2024-03-01", Points: 5, SessionCount: 1},
+		{UserID: "u1", Date: "2024-03-02", Points: 7, SessionCount: 0},
+	}
+	if !reflect.DeepEqual(activity, want) {
+		t.Errorf("activity = %+v, want %+v", activity, want)
+	}
+	languages, err := s.ListUserLanguageTotals(ctx, "u1", "", "")
+	if err != nil || !reflect.DeepEqual(languages, []LanguageTotal{{"go", 12}}) {
+		t.Errorf("ListUserLanguageTotals = %v, %v", languages, err)
+	}
+	entries, _, err := s.ListLedgerEntries(ctx, "u1", 10, "")
+	if err != nil || len(entries) != 1 || entries[0].Delta != 12 {
+		t.Errorf("ledger = %+v, %v, want one entry of 12", entries, err)
+	}
+}
+
+func testStoreRedateSession(t *testing.T, s Store) {
+	ctx := context.Background()
+	// An undated session from before days were counted in the user's
+	// timezone, whose points were added to its UTC day separately.
+	session := models.Session{UserID: "u1", SessionID: "s1", StartedAt: 1000, EndedAt: 2000, Points: 5,
+		LanguageBreakdown: map[string]int{"go": 3, "rust": 2}}
+	if _, err := s.RecordSession(ctx, session, models.LedgerEntry{EntryID: "e1", Source: models.LedgerSourceSession}); err != nil {
+		t.Fatal(err)
+	}
+	activityOn := func(date string) models.DailyActivity {
+		t.Helper()
+		rows, err := s.ListActivitySince(ctx, "u1", date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			if row.Date == date {
+				return row
+			}
+		}
+		return models.DailyActivity{UserID: "u1", Date: date}
+	}
+
+	// The row has the points but no session to move, so nothing changes.
+	if err := s.AddDailyActivity(ctx, "u1", "2024-06-01", 5, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.RedateSession(ctx, session, "2024-06-01", "2024-06-02"); err != nil || ok {
+		t.Fatalf("RedateSession(no session count) = %v, %v, want false", ok, err)
+	}
+	if stored, _ := s.GetSession(ctx, "u1", "s1"); stored == nil || stored.Date != "" {
+		t.Errorf("session after failed RedateSession = %+v, want undated", stored)
+	}
+	if row := activityOn("2024-06-01"); row.Points != 5 || row.SessionCount != 0 {
+		t.Errorf("source row after failed RedateSession = %+v", row)
+	}
+
+	// Another session on the day adds the session count and a go row. There
+	// is no rust row, as for sessions recorded before language rollups.
+	other := models.Session{UserID: "u1", SessionID: "s0", StartedAt: 500, EndedAt: 600, Points: 3, Date: "2024-06-01",
+		LanguageBreakdown: map[string]int{"go": 3},
+		Days:              []models.SessionDay{{Date: "2024-06-01", Points: 3, Languages: map[string]int{"go": 3}}}}
+	if _, err := s.RecordSession(ctx, other, models.LedgerEntry{EntryID: "e0", Source: models.LedgerSourceSession}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.RedateSession(ctx, session, "2024-06-01", "2024-06-02"); err != nil || !ok {
+		t.Fatalf("RedateSession = %v, %v, want true", ok, err)
+	}
+	if row := activityOn("2024-06-01"); row.Points != 3 || row.SessionCount != 0 {
+		t.Errorf("source row = %+v, want 3 points and no session", row)
+	}
+	if row := activityOn("2024-06-02"); row.Points != 5 || row.SessionCount != 1 {
+		t.Errorf("target row = %+v, want 5 points and 1 session", row)
+	}
+	if totals, err := s.ListUserLanguageTotals(ctx, "u1", "2024-06-01", "2024-06-01"); err != nil || len(totals) != 0 {
+		t.Errorf("source language totals = %v, %v, want none", totals, err)
+	}
+	if totals, err := s.ListUserLanguageTotals(ctx, "u1", "2024-06-02", "2024-06-02"); err != nil || !reflect.DeepEqual(totals, []LanguageTotal{{"go", 3}}) {
+		t.Errorf("target language totals = %v, %v, want go 3", totals, err)
+	}
+	if ok, err := s.RedateSession(ctx, session, "2024-06-02", "2024-06-03"); err != nil || ok {
+		t.Errorf("RedateSession(dated) = %v, %v, want false", ok, err)
+	}
+}
+
+func testStoreListSessions(t *testing.T, s Store) {
+	ctx := context.Background()
+	sessions := []models.Session{
+		{SessionID: "s1", StartedAt: 100, EndedAt: 200, Points: 30, Date: "2024-04-01",
+			Days: []models.SessionDay{{Date: "2024-04-01", Points: 30, Languages: map[string]int{"go": 30}}}},
+		{SessionID: "s2", StartedAt: 300, EndedAt: 400, Points: 10, Date: "2024-04-02",
+			Days: []models.SessionDay{{Date: "2024-04-02", Points: 10, Languages: map[string]int{"rust": 10}}}},
+		{SessionID: "s3", StartedAt: 500, EndedAt: 600, Points: 30, Date: "2024-04-03",
+			Days: []models.SessionDay{
+				{Date: "2024-04-03", Points: 20, Languages: map[string]int{"go": 20}},
+				{Date: "2024-04-04", Points: 10, Languages: map[string]int{"rust": 10}},
+			}},
+		{SessionID: "s4", StartedAt: 700, EndedAt: 800, Points: 20, Date: "2024-04-05",
+			Days: []models.SessionDay{{Date: "2024-04-05", Points: 20, Languages: map[string]int{"go": 20}}}},
+	}
+	for i, session := range sessions {
+		session.UserID = "u1"
+		session.LanguageBreakdown = map[string]int{}
+		for _, day := range session.Days {
+			for language, points := range day.Languages {
+				session.LanguageBreakdown[language] += points
+			}
+		}
+		entry := models.LedgerEntry{EntryID: fmt.Sprintf("e%d", i), Source: models.LedgerSourceSession}
+		if _, err := s.RecordSession(ctx, session, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stored, err := s.GetSession(ctx, "u1", "s4")
+	if err != nil || stored == nil {
+		t.Fatalf("GetSession = %v, %v", stored, err)
+	}
+	removed := *stored
+	removed.DeletedAt = 900
+	if ok, err := s.CorrectSession(ctx, *stored, removed, models.LedgerEntry{EntryID: "e9", Source: models.LedgerSourceCorrection}); err != nil || !ok {
+		t.Fatalf("CorrectSession = %v, %v", ok, err)
+	}
+
+	tests := []struct {
+		name  string
+		query SessionQuery
+		want  []string
+	}{
+		{"newest first", SessionQuery{SortBy: SessionSortEndedAt, Descending: true}, []string{"s3", "s2", "s1"}},
+		{"with deleted", SessionQuery{SortBy: SessionSortStartedAt, Deleted: true}, []string{"s1", "s2", "s3", "s4"}},
+		{"points ties by id", SessionQuery{SortBy: SessionSortPoints, Descending: true}, []string{"s1", "s3", "s2"}},
+		{"date range", SessionQuery{SortBy: SessionSortEndedAt, From: "2024-04-02", To: "2024-04-03"}, []string{"s2", "s3"}},
+		{"spanning day", SessionQuery{SortBy: SessionSortEndedAt, From: "2024-04-04"}, []string{"s3"}},
+		{"language", SessionQuery{SortBy: SessionSortEndedAt, Language: "rust"}, []string{"s2", "s3"}},
+	}
+	for _, tt := range tests {
+		for _, limit := range []int{1, 2, 10} {
+			t.Run(fmt.Sprintf("%s/limit %d", tt.name, limit), func(t *testing.T) {
+				query := tt.query
+				query.Limit = limit
+				var ids []string
+				for pages := 0; pages < 10; pages++ {
+					page, next, err := s.ListSessions(ctx, "u1", query)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(page) > limit {
+						t.Fatalf("page of %d sessions, limit %d", len(page), limit)
+					}
+					for _, session := range page {
+						ids = append(ids, session.SessionID)
+					}
+					if next == "" {
+						break
+					}
+					query.Cursor = next
+				}
+				if !reflect.DeepEqual(ids, tt.want) {
+					t.Errorf("sessions = %v, want %v", ids, tt.want)
+				}
+			})
+		}
+	}
+
+	_, _, err = s.ListSessions(ctx, "u1", SessionQuery{SortBy: SessionSortEndedAt, Limit: 1, Cursor: "not a cursor"})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("ListSessions(bad cursor) error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func testStoreRevokedTokens(t *testing.T, s Store) {
+	ctx := context.Background()
+	// Stores may purge revocations that have expired by the wall clock.
+	now := time.Now().Unix()
+	for _, revoked := range []models.RevokedToken{
+		{ID: "jti", ExpiresAt: now + 1000},
+		{ID: "user:u1", ExpiresAt: now + 1000, IssuedBefore: now - 100},
+		{ID: "user:u2", ExpiresAt: now - 1, IssuedBefore: now - 100},
+	} {
+		if err := s.RevokeToken(ctx, revoked); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name     string
+		issuedAt int64
+		ids      []string
+		want     bool
+	}{
+		{"revoked token", now, []string{"jti"}, true},
+		{"issued before cutoff", now - 101, []string{"other", "user:u1"}, true},
+		{"issued at cutoff", now - 100, []string{"user:u1"}, false},
+		{"expired revocation", now - 200, []string{"user:u2"}, false},
+		{"not revoked", 0, []string{"other"}, false},
+	}
+	for _, tt := range tests {
+		got, err := s.IsTokenRevoked(ctx, now, tt.issuedAt, tt.ids...)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: IsTokenRevoked = %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+
+	// Revoking again replaces the cutoff, e.g. when a user is banned twice.
+	if err := s.RevokeToken(ctx, models.RevokedToken{ID: "user:u1", ExpiresAt: now + 1000, IssuedBefore: now}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.IsTokenRevoked(ctx, now, now-50, "user:u1"); err != nil || !got {
+		t.Errorf("IsTokenRevoked after new cutoff = %v, %v, want true", got, err)
+	}
+}
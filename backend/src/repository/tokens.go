@@ -0,0 +1,12 @@
+package repository
+
+import "github.com/Brian-w-m/DevVerse/backend/src/models"
+
+// revokes reports whether revoked is still in force at now and covers a token
+// issued at issuedAt.
+func revokes(revoked models.RevokedToken, now, issuedAt int64) bool {
+	if revoked.ExpiresAt <= now {
+		return false
+	}
+	return revoked.IssuedBefore == 0 || issuedAt < revoked.IssuedBefore
+}
@@ -0,0 +1,139 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/services"
+	"github.com/Brian-w-m/DevVerse/backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// registerAccounts registers self-service identity linking and merging for
+// the authenticated user. Each call proves ownership of the identity with a
+// fresh provider access token.
+func registerAccounts(r gin.IRoutes, store repository.Store, keys *utils.Keyring, cfg appconfig.Config, logger *utils.Logger) {
+	authService := newAuthService(cfg)
+	userService := services.NewUserService(store, store, store)
+	accountService := services.NewAccountService(store, store, store, store, store, store, newTokenService(store, keys, cfg))
+	sessionOnly := utils.RequireSessionToken()
+
+	r.GET("/users/me/identities", sessionOnly, func(c *gin.Context) {
+		links, err := accountService.ListIdentities(c.Request.Context(), c.GetString("user_id"))
+		if err != nil {
+			logger.Errorf("failed to list identities: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list identities"})
+			return
+		}
+		if links == nil {
+			links = []models.IdentityLink{}
+		}
+		c.JSON(http.StatusOK, gin.H{"identities": links})
+	})
+
+	// Linking an identity that already belongs to another account fails with
+	// 409 unless merge is set, in which case that account is merged into
+	// this one. Repeating a merge request resumes an interrupted merge.
+	r.POST("/users/me/identities", sessionOnly, func(c *gin.Context) {
+		var req struct {
+			Provider    string `json:"provider" binding:"required"`
+			AccessToken string `json:"accessToken" binding:"required"`
+			Merge       bool   `json:"merge"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx := c.Request.Context()
+		userID := c.GetString("user_id")
+
+		identity, err := authService.VerifyIdentity(ctx, req.Provider, req.AccessToken)
+		if errors.Is(err, services.ErrUnknownProvider) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown identity provider"})
+			return
+		}
+		if err != nil {
+			logger.Errorf("failed to verify %s token: %v", req.Provider, err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid " + req.Provider + " token"})
+			return
+		}
+
+		owner, err := accountService.LinkIdentity(ctx, userID, identity)
+		if errors.Is(err, services.ErrIdentityLinked) {
+			if !req.Merge {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":  "identity belongs to another account; set merge to merge it into this one",
+					"userId": owner,
+				})
+				return
+			}
+			source, err := userService.GetUserByID(ctx, owner)
+			if err != nil {
+				logger.Errorf("failed to get user: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to link identity"})
+				return
+			}
+			if source != nil && source.Banned {
+				c.JSON(http.StatusForbidden, gin.H{"error": "account is banned"})
+				return
+			}
+			merge, err := accountService.MergeUsers(ctx, owner, userID, userID)
+			if err != nil {
+				writeMergeError(c, merge, err, logger)
+				return
+			}
+			// Identities of accounts from before linking have no link to move.
+			if _, err = accountService.LinkIdentity(ctx, userID, identity); err != nil {
+				logger.Errorf("failed to link identity after merge: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to link identity"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"identity": identity.LinkID(), "merge": merge})
+			return
+		}
+		if err != nil {
+			logger.Errorf("failed to link identity: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to link identity"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"identity": identity.LinkID()})
+	})
+
+	r.DELETE("/users/me/identities/:identityId", sessionOnly, func(c *gin.Context) {
+		deleted, err := accountService.UnlinkIdentity(c.Request.Context(), c.GetString("user_id"), c.Param("identityId"))
+		if errors.Is(err, services.ErrLastIdentity) {
+			c.JSON(http.StatusConflict, gin.H{"error": "cannot unlink your only identity"})
+			return
+		}
+		if err != nil {
+			logger.Errorf("failed to unlink identity: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlink identity"})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "identity not found"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
+
+// writeMergeError reports a failed MergeUsers call. A merge that stopped
+// part-way is returned with its progress so the caller can retry to resume.
+func writeMergeError(c *gin.Context, merge *models.UserMerge, err error, logger *utils.Logger) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMerge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "both users must exist and differ"})
+	case errors.Is(err, services.ErrMergeConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "user is already being merged into another account"})
+	default:
+		logger.Errorf("failed to merge users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "merge was interrupted; repeat the request to resume it",
+			"merge": merge,
+		})
+	}
+}
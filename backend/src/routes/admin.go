@@ -0,0 +1,242 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/services"
+	"github.com/Brian-w-m/DevVerse/backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// registerAdmin registers admin-only operations. r must already require an
+// admin (see Register).
+func registerAdmin(r gin.IRoutes, store repository.Store, keys *utils.Keyring, cfg appconfig.Config, logger *utils.Logger) {
+	userService := services.NewUserService(store, store, store)
+	tokenService := newTokenService(store, keys, cfg)
+	accountService := services.NewAccountService(store, store, store, store, store, store, tokenService)
+	sessionService := services.NewSessionService(store, store, store, store, sessionPolicy(cfg))
+	idempotencyService := services.NewIdempotencyService(store, time.Duration(cfg.IdempotencyTTLHours)*time.Hour)
+	idempotency := idempotent(idempotencyService, logger)
+
+	// Roles and bans are left to /admin/admins and /admin/users/:id/ban, so
+	// only the profile can be set here.
+	r.POST("/users", func(c *gin.Context) {
+		var req struct {
+			ID    string `json:"id" binding:"required"`
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		user := models.User{ID: req.ID, Name: req.Name, Email: req.Email}
+
+		if err := userService.CreateUser(c.Request.Context(), user); err != nil {
+			if errors.Is(err, services.ErrUserExists) {
+				c.JSON(http.StatusConflict, gin.H{"error": "user already exists"})
+				return
+			}
+			logger.Errorf("failed to create user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, user)
+	})
+
+	r.PATCH("/users/:id/score", idempotency, func(c *gin.Context) {
+		id := c.Param("id")
+
+		user, err := userService.GetUserByID(c.Request.Context(), id)
+		if err != nil {
+			logger.Errorf("failed to get user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+			return
+		}
+		if user == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		// Score is a pointer so that resetting a score to 0 is not mistaken
+		// for a missing field.
+		var updateReq struct {
+			Score *int `json:"score"`
+		}
+		if err := c.ShouldBindJSON(&updateReq); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if updateReq.Score == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "score is required"})
+			return
+		}
+
+		p, _ := utils.CurrentPrincipal(c)
+		if err := userService.UpdateUserScore(c.Request.Context(), id, *updateReq.Score, p.UserID); err != nil {
+			logger.Errorf("failed to update user score: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user score"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"id":    id,
+			"score": *updateReq.Score,
+		})
+	})
+
+	r.DELETE("/users/:id", func(c *gin.Context) {
+		id := c.Param("id")
+		if err := userService.DeleteUser(c.Request.Context(), id); err != nil {
+			logger.Errorf("failed to delete user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
+			return
+		}
+
+		c.JSON(http.StatusNoContent, nil)
+	})
+
+	r.GET("/admin/admins", func(c *gin.Context) {
+		admins, err := userService.ListUsersWithRole(c.Request.Context(), utils.RoleAdmin)
+		if err != nil {
+			logger.Errorf("failed to list admins: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list admins"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"admins": admins})
+	})
+
+	r.PUT("/admin/admins/:id", func(c *gin.Context) {
+		user, err := userService.GrantRole(c.Request.Context(), c.Param("id"), utils.RoleAdmin)
+		if err != nil {
+			logger.Errorf("failed to grant admin role: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to grant admin role"})
+			return
+		}
+		if user == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusOK, user)
+	})
+
+	r.DELETE("/admin/admins/:id", func(c *gin.Context) {
+		id := c.Param("id")
+		// Keeps an admin from locking themselves out of the admin API.
+		if p, _ := utils.CurrentPrincipal(c); p.UserID == id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cannot remove your own admin role"})
+			return
+		}
+		user, err := userService.RevokeRole(c.Request.Context(), id, utils.RoleAdmin)
+		if err != nil {
+			logger.Errorf("failed to revoke admin role: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke admin role"})
+			return
+		}
+		if user == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusOK, user)
+	})
+
+	// Banning also revokes every token the user holds; banned users cannot
+	// log in or refresh until unbanned.
+	r.PUT("/admin/users/:id/ban", func(c *gin.Context) {
+		id := c.Param("id")
+		if p, _ := utils.CurrentPrincipal(c); p.UserID == id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cannot ban yourself"})
+			return
+		}
+		user, err := userService.SetBanned(c.Request.Context(), id, true)
+		if err != nil {
+			logger.Errorf("failed to ban user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to ban user"})
+			return
+		}
+		if user == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if err := tokenService.RevokeUser(c.Request.Context(), id); err != nil {
+			logger.Errorf("failed to revoke tokens of banned user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to ban user"})
+			return
+		}
+		c.JSON(http.StatusOK, user)
+	})
+
+	r.DELETE("/admin/users/:id/ban", func(c *gin.Context) {
+		id := c.Param("id")
+		user, err := userService.SetBanned(c.Request.Context(), id, false)
+		if err != nil {
+			logger.Errorf("failed to unban user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unban user"})
+			return
+		}
+		if user == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusOK, user)
+	})
+
+	// Changes a session's points, moving the user's score and activity by the
+	// difference. The reason is recorded on the session.
+	r.PATCH("/admin/users/:id/sessions/:sessionId", func(c *gin.Context) {
+		var req struct {
+			Points int    `json:"points" binding:"required"`
+			Reason string `json:"reason" binding:"required,max=500"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Points <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "points must be greater than 0"})
+			return
+		}
+		p, _ := utils.CurrentPrincipal(c)
+		session, err := sessionService.AdjustSession(c.Request.Context(), c.Param("id"), c.Param("sessionId"), req.Points, p.UserID, req.Reason)
+		writeSessionCorrection(c, session, err, logger)
+	})
+
+	// Merges sourceUserId into :id. Repeating the request resumes an
+	// interrupted merge.
+	r.POST("/admin/users/:id/merge", func(c *gin.Context) {
+		var req struct {
+			SourceUserID string `json:"sourceUserId" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		p, _ := utils.CurrentPrincipal(c)
+		merge, err := accountService.MergeUsers(c.Request.Context(), req.SourceUserID, c.Param("id"), p.UserID)
+		if err != nil {
+			writeMergeError(c, merge, err, logger)
+			return
+		}
+		c.JSON(http.StatusOK, merge)
+	})
+
+	r.GET("/admin/merges/:sourceId", func(c *gin.Context) {
+		merge, err := accountService.GetMerge(c.Request.Context(), c.Param("sourceId"))
+		if err != nil {
+			logger.Errorf("failed to get merge: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get merge"})
+			return
+		}
+		if merge == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "merge not found"})
+			return
+		}
+		c.JSON(http.StatusOK, merge)
+	})
+}
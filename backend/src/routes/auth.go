@@ -1,21 +1,118 @@
 package routes
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
 	"github.com/Brian-w-m/DevVerse/backend/src/services"
 	"github.com/Brian-w-m/DevVerse/backend/src/utils"
 )
 
-func registerAuth(r *gin.Engine, dynamodbClient *dynamodb.Client, cfg appconfig.Config, logger *utils.Logger) {
-	authService := services.NewAuthService(cfg.JWTSecret)
-	userService := services.NewUserService(dynamodbClient, cfg.DynamoDBTable, cfg.DailyActivityTable)
+const (
+	oauthStateCookie = "devverse_oauth_state"
+	oauthStateTTL    = 10 * time.Minute
+)
+
+// newAuthService builds the AuthService with every configured identity
+// provider.
+func newAuthService(cfg appconfig.Config) *services.AuthService {
+	var providers []services.IdentityProvider
+	if cfg.GitLabBaseURL != "" {
+		providers = append(providers, services.NewGitLabProvider(cfg.GitLabBaseURL, cfg.GitLabClientID))
+	}
+	if cfg.OIDCIssuerURL != "" {
+		providers = append(providers, services.NewOIDCProvider(cfg.OIDCProviderName, cfg.OIDCIssuerURL, cfg.OIDCClientID, cfg.OIDCClientSecret))
+	}
+	return services.NewAuthService(cfg.OAuthStateSecret, services.GitHubConfig{
+		ClientID:     cfg.GitHubClientID,
+		ClientSecret: cfg.GitHubClientSecret,
+		RedirectURL:  cfg.GitHubRedirectURL,
+		OAuthBaseURL: cfg.GitHubOAuthBaseURL,
+		APIBaseURL:   cfg.GitHubAPIBaseURL,
+	}, providers...)
+}
+
+// newTokenService builds the TokenService with the configured lifetimes.
+func newTokenService(store repository.Store, keys *utils.Keyring, cfg appconfig.Config) *services.TokenService {
+	return services.NewTokenService(store, store, keys,
+		time.Duration(cfg.AccessTokenTTLMinutes)*time.Minute,
+		time.Duration(cfg.RefreshTokenTTLHours)*time.Hour)
+}
+
+func registerAuth(r *gin.Engine, store repository.Store, keys *utils.Keyring, cfg appconfig.Config, logger *utils.Logger) {
+	authService := newAuthService(cfg)
+	userService := services.NewUserService(store, store, store)
+	tokenService := newTokenService(store, keys, cfg)
+	accountService := services.NewAccountService(store, store, store, store, store, store, tokenService)
+
+	// loginWithProviderToken verifies the provider's access token, upserts the
+	// user and issues our tokens. On failure it writes the error response
+	// itself.
+	loginWithProviderToken := func(c *gin.Context, ctx context.Context, provider, accessToken string) (*services.TokenPair, *models.User, bool) {
+		identity, err := authService.VerifyIdentity(ctx, provider, accessToken)
+		if errors.Is(err, services.ErrUnknownProvider) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown identity provider"})
+			return nil, nil, false
+		}
+		if err != nil {
+			logger.Errorf("failed to verify %s token: %v", provider, err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid " + provider + " token"})
+			return nil, nil, false
+		}
+
+		// Find or create the user the identity is linked to
+		userID, err := accountService.ResolveLogin(ctx, identity)
+		if err != nil {
+			logger.Errorf("failed to resolve identity: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process user"})
+			return nil, nil, false
+		}
+		user, err := userService.CreateOrUpdateUserFromIdentity(ctx, userID, identity)
+		if err != nil {
+			logger.Errorf("failed to create/update user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process user"})
+			return nil, nil, false
+		}
+
+		// Bootstrap the configured first admins
+		isBootstrapAdmin := identity.Provider == services.ProviderGitHub && slices.Contains(cfg.AdminGitHubIDs, identity.Subject)
+		if isBootstrapAdmin && !utils.HasRole(user.Roles, utils.RoleAdmin) {
+			user, err = userService.GrantRole(ctx, user.ID, utils.RoleAdmin)
+			if err != nil {
+				logger.Errorf("failed to grant bootstrap admin role: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process user"})
+				return nil, nil, false
+			}
+		}
 
-	r.POST("/auth/github", func(c *gin.Context) {
+		// Generate access and refresh tokens
+		tokens, err := tokenService.IssueTokens(ctx, user.ID)
+		if errors.Is(err, services.ErrUserBanned) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "account is banned"})
+			return nil, nil, false
+		}
+		if err != nil {
+			logger.Errorf("failed to generate JWT: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+			return nil, nil, false
+		}
+		return tokens, user, true
+	}
+
+	// POST /auth/github, /auth/gitlab, ... exchange a provider access token
+	// for our tokens.
+	r.POST("/auth/:provider", func(c *gin.Context) {
 		var req struct {
 			AccessToken string `json:"accessToken" binding:"required"`
 		}
@@ -25,42 +122,167 @@ func registerAuth(r *gin.Engine, dynamodbClient *dynamodb.Client, cfg appconfig.
 			return
 		}
 
-		// Verify token with GitHub
-		githubUser, err := authService.VerifyGitHubToken(c.Request.Context(), req.AccessToken)
-		if err != nil {
-			logger.Errorf("failed to verify GitHub token: %v", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid GitHub token"})
+		tokens, user, ok := loginWithProviderToken(c, c.Request.Context(), c.Param("provider"), req.AccessToken)
+		if !ok {
 			return
 		}
 
-		// Convert GitHub ID to string (use as primary ID)
-		githubID := fmt.Sprintf("%d", githubUser.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
+			"expiresAt":    tokens.ExpiresAt,
+			"user":         user,
+		})
+	})
+
+	// Server-side authorization code flow: the browser is sent to GitHub from
+	// here and returns to /auth/github/callback, so the client secret never
+	// leaves the backend. State and the PKCE verifier travel in a signed,
+	// short-lived cookie bound to the browser that started the flow.
+	r.GET("/auth/github/login", func(c *gin.Context) {
+		if cfg.GitHubClientID == "" || cfg.GitHubRedirectURL == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "GitHub OAuth is not configured"})
+			return
+		}
 
-		// Find or create user (using GitHub ID as the ID)
-		user, err := userService.CreateOrUpdateUserByGitHub(
-			c.Request.Context(),
-			githubID,
-			githubUser.Name,
-			githubUser.Email,
-		)
+		authorizeURL, state, err := authService.NewGitHubAuthorization(oauthStateTTL)
 		if err != nil {
-			logger.Errorf("failed to create/update user: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process user"})
+			logger.Errorf("failed to start GitHub authorization: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+			return
+		}
+
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oauthStateCookie, state, int(oauthStateTTL.Seconds()), "/auth/github", "", isSecureRequest(c), true)
+		c.Redirect(http.StatusFound, authorizeURL)
+	})
+
+	r.GET("/auth/github/callback", func(c *gin.Context) {
+		stateCookie, _ := c.Cookie(oauthStateCookie)
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oauthStateCookie, "", -1, "/auth/github", "", isSecureRequest(c), true)
+
+		if errCode := c.Query("error"); errCode != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "GitHub authorization failed: " + errCode})
+			return
+		}
+		code := c.Query("code")
+		if code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+			return
+		}
+
+		verifier, err := authService.VerifyOAuthState(stateCookie, c.Query("state"))
+		if errors.Is(err, services.ErrInvalidOAuthState) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired state"})
 			return
 		}
 
-		// Generate JWT
-		token, err := authService.GenerateJWT(user.ID)
+		accessToken, err := authService.ExchangeGitHubCode(c.Request.Context(), code, verifier)
 		if err != nil {
-			logger.Errorf("failed to generate JWT: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+			logger.Errorf("failed to exchange GitHub code: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "failed to exchange authorization code"})
+			return
+		}
+
+		tokens, user, ok := loginWithProviderToken(c, c.Request.Context(), services.ProviderGitHub, accessToken)
+		if !ok {
 			return
 		}
 
+		if cfg.OAuthSuccessRedirect != "" {
+			// The tokens go in the fragment so they are never sent to a server
+			// or written to access logs.
+			fragment := url.Values{
+				"token":         {tokens.AccessToken},
+				"refresh_token": {tokens.RefreshToken},
+				"expires_at":    {strconv.FormatInt(tokens.ExpiresAt, 10)},
+				"user_id":       {user.ID},
+			}
+			c.Redirect(http.StatusFound, cfg.OAuthSuccessRedirect+"#"+fragment.Encode())
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
-			"token": token,
-			"user":  user,
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
+			"expiresAt":    tokens.ExpiresAt,
+			"user":         user,
 		})
 	})
+
+	r.POST("/auth/refresh", func(c *gin.Context) {
+		var req struct {
+			RefreshToken string `json:"refreshToken" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "refreshToken is required"})
+			return
+		}
+
+		tokens, err := tokenService.Refresh(c.Request.Context(), req.RefreshToken)
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			return
+		}
+		if err != nil {
+			logger.Errorf("failed to refresh token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+			return
+		}
+		c.JSON(http.StatusOK, tokens)
+	})
+
+	// Logout revokes the refresh token family, which also invalidates every
+	// access token issued from it. Either the refresh token or a bearer access
+	// token identifies the family, so logout works with whichever the client
+	// still holds.
+	r.POST("/auth/logout", func(c *gin.Context) {
+		var req struct {
+			RefreshToken string `json:"refreshToken"`
+		}
+		_ = c.ShouldBindJSON(&req)
+
+		var claims jwt.MapClaims
+		if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+			parsed, err := keys.Parse(strings.TrimPrefix(authHeader, "Bearer "))
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+				return
+			}
+			claims = parsed
+		}
+		if req.RefreshToken == "" && claims == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "refreshToken or bearer token is required"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		if req.RefreshToken != "" {
+			if err := tokenService.RevokeRefreshToken(ctx, req.RefreshToken); err != nil {
+				logger.Errorf("failed to revoke refresh token: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+				return
+			}
+		}
+		if claims != nil {
+			if err := tokenService.RevokeAccessClaims(ctx, claims); err != nil {
+				logger.Errorf("failed to revoke access token: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+				return
+			}
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// Public keys for verifying our access tokens, so other services (e.g. the
+	// frontend) need no shared secret. Retired keys stay listed until removed
+	// from the key file.
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	})
 }
 
+func isSecureRequest(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
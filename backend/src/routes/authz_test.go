@@ -0,0 +1,206 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// personalToken returns a personal access token for the user with scopes.
+func (s *testServer) personalToken(userID string, scopes ...string) string {
+	s.t.Helper()
+	token, _, err := s.pats.Create(context.Background(), userID, "test", scopes, time.Hour)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return token
+}
+
+func TestUserRoutesAllowSelfOrAdmin(t *testing.T) {
+	s := newTestServer(t)
+	tokens := map[string]string{
+		"alice": s.login("alice"),
+		"bob":   s.login("bob"),
+		"admin": s.login("admin"),
+		"none":  "",
+	}
+	routes := []struct {
+		method, path, body string
+		adminOnly          bool
+	}{
+		{"GET", "/users/alice", "", false},
+		{"PUT", "/users/alice", `{"name":"Alice"}`, false},
+		{"PATCH", "/users/alice/score/add", `{"increment":1}`, false},
+		{"POST", "/users/alice/sessions", sessionBody("s1", 5), false},
+		{"GET", "/users/alice/sessions", "", false},
+		{"GET", "/users/alice/sessions/s1", "", false},
+		{"GET", "/users/alice/ledger", "", false},
+		{"GET", "/users/alice/streak", "", false},
+		{"GET", "/users/alice/languages", "", false},
+		{"GET", "/users/alice/activity", "", false},
+		{"GET", "/activity/alice", "", false},
+		{"GET", "/users/alice/tokens", "", false},
+		{"PATCH", "/users/alice/score", `{"score":3}`, true},
+		{"PUT", "/admin/users/nobody/ban", "", true},
+		{"DELETE", "/admin/users/nobody/ban", "", true},
+		{"GET", "/admin/admins", "", true},
+	}
+	for _, route := range routes {
+		for _, caller := range []string{"none", "bob", "alice", "admin"} {
+			w := s.do(route.method, route.path, tokens[caller], route.body)
+			switch {
+			case caller == "none":
+				if w.Code != http.StatusUnauthorized {
+					t.Errorf("%s %s without a token: status %d, want 401", route.method, route.path, w.Code)
+				}
+			case caller == "admin", caller == "alice" && !route.adminOnly:
+				if w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden {
+					t.Errorf("%s %s as %s: status %d: %s", route.method, route.path, caller, w.Code, w.Body.String())
+				}
+			default:
+				if w.Code != http.StatusForbidden {
+					t.Errorf("%s %s as %s: status %d, want 403", route.method, route.path, caller, w.Code)
+				}
+			}
+		}
+	}
+
+	if w := s.do("DELETE", "/users/bob", tokens["bob"], ""); w.Code != http.StatusForbidden {
+		t.Errorf("DELETE /users/bob as bob: status %d, want 403", w.Code)
+	}
+	if w := s.do("DELETE", "/users/bob", tokens["admin"], ""); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE /users/bob as admin: status %d, want 204", w.Code)
+	}
+}
+
+func TestPersonalTokenScopes(t *testing.T) {
+	s := newTestServer(t)
+	read := s.personalToken("alice", models.ScopeStatsRead)
+	write := s.personalToken("alice", models.ScopeSessionsWrite)
+	adminPAT := s.personalToken("admin", models.ScopeStatsRead, models.ScopeSessionsWrite)
+
+	tests := []struct {
+		name               string
+		method, path, body string
+		token              string
+		want               int
+	}{
+		{"read with stats:read", "GET", "/users/alice", "", read, http.StatusOK},
+		{"read with sessions:write", "GET", "/users/alice", "", write, http.StatusForbidden},
+		{"record with sessions:write", "POST", "/users/alice/sessions", sessionBody("s1", 5), write, http.StatusCreated},
+		{"record with stats:read", "POST", "/users/alice/sessions", sessionBody("s2", 5), read, http.StatusForbidden},
+		{"another user's data", "GET", "/users/bob", "", read, http.StatusForbidden},
+		{"profile needs a session token", "PUT", "/users/alice", `{"name":"A"}`, read, http.StatusForbidden},
+		{"listing tokens needs a session token", "GET", "/users/alice/tokens", "", read, http.StatusForbidden},
+		{"creating tokens needs a session token", "POST", "/users/alice/tokens", `{"name":"ci","scopes":["stats:read"]}`, read, http.StatusForbidden},
+		{"revoking tokens needs a session token", "DELETE", "/users/alice/tokens/any", "", read, http.StatusForbidden},
+		{"admin rights need a session token", "GET", "/admin/admins", "", adminPAT, http.StatusForbidden},
+		{"admin PAT cannot act on others", "GET", "/users/alice", "", adminPAT, http.StatusForbidden},
+		{"unknown token", "GET", "/users/alice", "", "dvp_unknown", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := s.do(tt.method, tt.path, tt.token, tt.body); w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestManageTokensWithSessionToken(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.login("alice")
+	w := s.do("POST", "/users/alice/tokens", alice, `{"name":"ci","scopes":["stats:read"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Token   string                     `json:"token"`
+		Details models.PersonalAccessToken `json:"details"`
+	}
+	decode(t, w, &created)
+	if w := s.do("GET", "/users/alice", created.Token, ""); w.Code != http.StatusOK {
+		t.Errorf("using the new token: status %d", w.Code)
+	}
+	if w := s.do("POST", "/users/bob/tokens", alice, `{"name":"ci","scopes":["stats:read"]}`); w.Code != http.StatusForbidden {
+		t.Errorf("creating a token for bob: status %d, want 403", w.Code)
+	}
+
+	if w := s.do("GET", "/users/alice/tokens", alice, ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), created.Details.ID) {
+		t.Errorf("list: status %d: %s", w.Code, w.Body.String())
+	}
+	if w := s.do("DELETE", "/users/alice/tokens/"+created.Details.ID, alice, ""); w.Code >= 300 {
+		t.Fatalf("revoke: status %d: %s", w.Code, w.Body.String())
+	}
+	if w := s.do("GET", "/users/alice", created.Token, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("using the revoked token: status %d, want 401", w.Code)
+	}
+}
+
+func TestBannedUserIsLockedOut(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.login("admin")
+	session := s.login("alice")
+	pat := s.personalToken("alice", models.ScopeStatsRead)
+
+	if w := s.do("PUT", "/admin/users/alice/ban", admin, ""); w.Code != http.StatusOK {
+		t.Fatalf("ban: status %d: %s", w.Code, w.Body.String())
+	}
+	for name, token := range map[string]string{"session token": session, "personal token": pat} {
+		if w := s.do("GET", "/users/alice", token, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s while banned: status %d, want 401", name, w.Code)
+		}
+	}
+
+	if w := s.do("DELETE", "/admin/users/alice/ban", admin, ""); w.Code != http.StatusOK {
+		t.Fatalf("unban: status %d: %s", w.Code, w.Body.String())
+	}
+	// Tokens issued before the ban stay revoked; new logins and personal
+	// tokens work again.
+	if w := s.do("GET", "/users/alice", session, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("pre-ban session token after unban: status %d, want 401", w.Code)
+	}
+	if w := s.do("GET", "/users/alice", pat, ""); w.Code != http.StatusOK {
+		t.Errorf("personal token after unban: status %d, want 200", w.Code)
+	}
+	// The ban covers tokens issued up to the end of its second.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	if w := s.do("GET", "/users/alice", s.login("alice"), ""); w.Code != http.StatusOK {
+		t.Errorf("new session token after unban: status %d, want 200", w.Code)
+	}
+}
+
+func TestDemotedAdminLosesAccessImmediately(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.login("admin")
+	if w := s.do("GET", "/users/alice", admin, ""); w.Code != http.StatusOK {
+		t.Fatalf("as admin: status %d", w.Code)
+	}
+	if err := s.store.SetUserRoles(context.Background(), "admin", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/users/alice", "/admin/admins"} {
+		if w := s.do("GET", path, admin, ""); w.Code != http.StatusForbidden {
+			t.Errorf("GET %s after demotion: status %d, want 403", path, w.Code)
+		}
+	}
+}
+
+func TestCreateUserCannotSetRolesOrBan(t *testing.T) {
+	s := newTestServer(t)
+	body := `{"id":"carol","name":"Carol","roles":["admin"],"banned":true}`
+	if w := s.do("POST", "/users", s.login("admin"), body); w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", w.Code, w.Body.String())
+	}
+	user, err := s.store.GetUser(context.Background(), "carol")
+	if err != nil || user == nil {
+		t.Fatalf("GetUser = %v, %v", user, err)
+	}
+	if user.Name != "Carol" || len(user.Roles) != 0 || user.Banned {
+		t.Errorf("created user = %+v, want name only", *user)
+	}
+}
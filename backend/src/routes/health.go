@@ -3,18 +3,15 @@ package routes
 import (
 	"net/http"
 
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/gin-gonic/gin"
 	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
-	"github.com/Brian-w-m/DevVerse/backend/src/services"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
 	"github.com/Brian-w-m/DevVerse/backend/src/utils"
+	"github.com/gin-gonic/gin"
 )
 
-func registerHealth(r *gin.Engine, dynamoClient *dynamodb.Client, cfg appconfig.Config, logger *utils.Logger) {
-	dynamoService := services.NewDynamoDBService(dynamoClient, cfg.DynamoDBTable)
-
+func registerHealth(r *gin.Engine, store repository.Store, cfg appconfig.Config, logger *utils.Logger) {
 	r.GET("/health", func(c *gin.Context) {
-		if err := dynamoService.HealthCheck(c.Request.Context()); err != nil {
+		if err := store.HealthCheck(c.Request.Context()); err != nil {
 			logger.Errorf("health check failed: %v", err)
 			c.JSON(http.StatusServiceUnavailable, gin.H{
 				"status": "unhealthy",
@@ -23,11 +20,32 @@ func registerHealth(r *gin.Engine, dynamoClient *dynamodb.Client, cfg appconfig.
 			return
 		}
 
+		version, err := store.AppliedSchemaVersion(c.Request.Context())
+		if err != nil {
+			logger.Errorf("health check failed: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status": "unhealthy",
+				"error":  err.Error(),
+			})
+			return
+		}
+		if version != repository.SchemaVersion {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":                  "unhealthy",
+				"error":                   "schema version mismatch",
+				"schema_version":          version,
+				"expected_schema_version": repository.SchemaVersion,
+			})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
-			"status":   "ok",
-			"region":   cfg.AWSRegion,
-			"endpoint": cfg.DynamoDBEndpoint,
-			"table":    cfg.DynamoDBTable,
+			"status":         "ok",
+			"schema_version": version,
+			"backend":        cfg.StorageBackend,
+			"region":         cfg.AWSRegion,
+			"endpoint":       cfg.DynamoDBEndpoint,
+			"table":          cfg.DynamoDBTable,
 		})
 	})
-}
\ No newline at end of file
+}
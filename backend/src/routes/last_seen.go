@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/Brian-w-m/DevVerse/backend/src/services"
+	"github.com/Brian-w-m/DevVerse/backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// trackLastSeen records authenticated activity on the caller's LastSeenAt
+// once the request has been handled. Failures are logged and never affect
+// the response. Must run after utils.JWTAuth.
+func trackLastSeen(tracker *services.LastSeenTracker, logger *utils.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		userID := c.GetString("user_id")
+		if userID == "" {
+			return
+		}
+		if err := tracker.Touch(c.Request.Context(), userID); err != nil {
+			logger.Errorf("failed to record last seen: %v", err)
+		}
+	}
+}
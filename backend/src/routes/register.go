@@ -1,26 +1,35 @@
 package routes
 
 import (
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/services"
 	"github.com/Brian-w-m/DevVerse/backend/src/utils"
 )
 
 // Register wires all route groups
-func Register(r *gin.Engine, dynamodbClient *dynamodb.Client, cfg appconfig.Config, logger *utils.Logger) {
-	registerHealth(r, dynamodbClient, cfg, logger)
-	registerAuth(r, dynamodbClient, cfg, logger)
+func Register(r *gin.Engine, store repository.Store, keys *utils.Keyring, cfg appconfig.Config, logger *utils.Logger) {
+	registerHealth(r, store, cfg, logger)
+	registerAuth(r, store, keys, cfg, logger)
 	
 	// Public stats endpoints (no auth required for development)
-	registerStats(r, dynamodbClient, cfg, logger)
-
-	// Public user data endpoints (no auth until Phase 5)
-	registerPublicUserRoutes(r, dynamodbClient, cfg, logger)
+	registerStats(r, store, cfg, logger)
 
-	// Protect remaining user routes with JWT
+	// Protect remaining user routes with JWT or a personal access token
+	pats := services.NewPersonalTokenService(store, store)
+	lastSeen := trackLastSeen(services.NewLastSeenTracker(store, time.Duration(cfg.LastSeenIntervalMinutes)*time.Minute), logger)
 	authGroup := r.Group("/")
-	authGroup.Use(utils.JWTAuth(cfg.JWTSecret))
-	registerUsers(authGroup, dynamodbClient, cfg, logger)
+	authGroup.Use(utils.JWTAuth(keys, store, pats), lastSeen)
+	registerUsers(authGroup, store, cfg, logger)
+	registerTokens(authGroup, store, pats, logger)
+	registerAccounts(authGroup, store, keys, cfg, logger)
+
+	// Admin-only operations; admin rights are checked against storage
+	adminGroup := r.Group("/")
+	adminGroup.Use(utils.JWTAuth(keys, store, pats), lastSeen, utils.NewAuthorizer(store).RequireAdmin())
+	registerAdmin(adminGroup, store, keys, cfg, logger)
 	registerJobs(r, logger)
 }
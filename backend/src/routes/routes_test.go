@@ -0,0 +1,500 @@
+package routes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/services"
+	"github.com/Brian-w-m/DevVerse/backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// testServer is the full router over an in-memory store, with users alice,
+// bob and admin (who holds the admin role).
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	store  *repository.MemoryStore
+	tokens *services.TokenService
+	pats   *services.PersonalTokenService
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	return newTestServerWith(t, nil)
+}
+
+// newTestServerWith is newTestServer with the router's store wrapped by wrap
+// unless it is nil, so that tests can make store calls fail.
+func newTestServerWith(t *testing.T, wrap func(*repository.MemoryStore) repository.Store) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	keys, err := utils.LoadKeyring("", "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := appconfig.Load()
+	cfg.JWTSecret = "test-secret"
+	cfg.OAuthStateSecret = "test-state-secret"
+
+	store := repository.NewMemoryStore()
+	ctx := context.Background()
+	for _, user := range []models.User{
+		{ID: "alice", Name: "Alice"},
+		{ID: "bob", Name: "Bob"},
+		{ID: "admin", Name: "Admin", Roles: []string{utils.RoleAdmin}},
+	} {
+		if err := store.PutUser(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var routed repository.Store = store
+	if wrap != nil {
+		routed = wrap(store)
+	}
+	router := gin.New()
+	Register(router, routed, keys, cfg, utils.NewLogger("error"))
+	return &testServer{
+		t:      t,
+		router: router,
+		store:  store,
+		tokens: newTokenService(store, keys, cfg),
+		pats:   services.NewPersonalTokenService(store, store),
+	}
+}
+
+// login returns a session access token for the user.
+func (s *testServer) login(userID string) string {
+	s.t.Helper()
+	pair, err := s.tokens.IssueTokens(context.Background(), userID)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return pair.AccessToken
+}
+
+// do sends the request, authenticated with token unless it is empty, and
+// returns the response.
+func (s *testServer) do(method, path, token, body string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.doWithKey(method, path, token, "", body)
+}
+
+// doWithKey is do with an Idempotency-Key header unless key is empty.
+func (s *testServer) doWithKey(method, path, token, key, body string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// sessionBody is a valid POST /users/:id/sessions body that ended an hour ago.
+func sessionBody(sessionID string, points int) string {
+	end := time.Now().Add(-time.Hour)
+	return fmt.Sprintf(`{"sessionId":%q,"startedAt":%d,"endedAt":%d,"points":%d,"languageBreakdown":{"go":%d}}`,
+		sessionID, end.Add(-30*time.Minute).Unix(), end.Unix(), points, points)
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, out interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+		t.Fatalf("failed to decode %s: %v", w.Body.String(), err)
+	}
+}
+
+func TestHandlerValidation(t *testing.T) {
+	s := newTestServer(t)
+	alice, admin := s.login("alice"), s.login("admin")
+	if w := s.do("POST", "/users/alice/sessions", alice, sessionBody("recorded", 10)); w.Code != http.StatusCreated {
+		t.Fatalf("recording a session: status %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		token, body  string
+	}{
+		{"list users limit zero", "GET", "/users?limit=0", alice, ""},
+		{"list users limit not a number", "GET", "/users?limit=ten", alice, ""},
+		{"list users bad cursor", "GET", "/users?next=not*base64", alice, ""},
+		{"leaderboard unknown timezone", "GET", "/leaderboard?window=day&tz=Mars/Olympus_Mons", alice, ""},
+		{"unknown timezone", "PATCH", "/users/me", alice, `{"timezone":"Mars/Olympus_Mons"}`},
+		{"malformed profile", "PATCH", "/users/me", alice, `{"name":`},
+		{"score add without increment", "PATCH", "/users/alice/score/add", alice, `{}`},
+		{"session without points", "POST", "/users/alice/sessions", alice, `{"sessionId":"s","points":0}`},
+		{"session for another user", "POST", "/users/alice/sessions", alice, `{"userId":"bob","sessionId":"s","points":5}`},
+		{"sessions limit too large", "GET", "/users/alice/sessions?limit=101", alice, ""},
+		{"sessions unknown sort", "GET", "/users/alice/sessions?sort=duration", alice, ""},
+		{"sessions bad date", "GET", "/users/alice/sessions?from=2024-13-01", alice, ""},
+		{"sessions bad cursor", "GET", "/users/alice/sessions?next=not*base64", alice, ""},
+		{"delete reason too long", "DELETE", "/users/alice/sessions/recorded", alice, fmt.Sprintf(`{"reason":%q}`, strings.Repeat("x", 501))},
+		{"set score without score", "PATCH", "/users/alice/score", admin, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := s.do(tt.method, tt.path, tt.token, tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("status %d, want 400: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRecordSessionIsIdempotentBySessionID(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.login("alice")
+	for i, want := range []int{http.StatusCreated, http.StatusOK} {
+		w := s.do("POST", "/users/alice/sessions", alice, sessionBody("s1", 25))
+		if w.Code != want {
+			t.Fatalf("request %d: status %d, want %d: %s", i+1, w.Code, want, w.Body.String())
+		}
+	}
+	var user models.User
+	decode(t, s.do("GET", "/users/alice", alice, ""), &user)
+	if user.Score != 25 {
+		t.Errorf("score = %d, want 25", user.Score)
+	}
+}
+
+func TestAdminCanResetScoreToZero(t *testing.T) {
+	s := newTestServer(t)
+	alice, admin := s.login("alice"), s.login("admin")
+	if w := s.do("PATCH", "/users/alice/score/add", alice, `{"increment":40}`); w.Code != http.StatusOK {
+		t.Fatalf("adding score: status %d: %s", w.Code, w.Body.String())
+	}
+	if w := s.do("PATCH", "/users/alice/score", admin, `{"score":0}`); w.Code != http.StatusOK {
+		t.Fatalf("resetting score: status %d: %s", w.Code, w.Body.String())
+	}
+	var user models.User
+	decode(t, s.do("GET", "/users/alice", alice, ""), &user)
+	if user.Score != 0 {
+		t.Errorf("score = %d, want 0", user.Score)
+	}
+}
+
+func TestCreateUserStartsAtZeroAndRejectsTakenIDs(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.login("admin")
+	if w := s.do("POST", "/users", admin, `{"id":"carol","name":"Carol","score":500}`); w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", w.Code, w.Body.String())
+	}
+	var user models.User
+	decode(t, s.do("GET", "/users/carol", admin, ""), &user)
+	if user.Score != 0 {
+		t.Errorf("score = %d, want 0", user.Score)
+	}
+
+	if w := s.do("POST", "/users", admin, `{"id":"alice","name":"Imposter"}`); w.Code != http.StatusConflict {
+		t.Errorf("create existing: status %d, want 409", w.Code)
+	}
+	decode(t, s.do("GET", "/users/alice", admin, ""), &user)
+	if user.Name != "Alice" {
+		t.Errorf("existing user's name = %q, want Alice", user.Name)
+	}
+}
+
+// failingScoreStore fails the first AddUserScore call.
+type failingScoreStore struct {
+	*repository.MemoryStore
+	failed bool
+}
+
+func (s *failingScoreStore) AddUserScore(ctx context.Context, id string, increment int, date string, entry models.LedgerEntry) error {
+	if !s.failed {
+		s.failed = true
+		return errors.New("storage unavailable")
+	}
+	return s.MemoryStore.AddUserScore(ctx, id, increment, date, entry)
+}
+
+func (s *testServer) score(userID string) int {
+	s.t.Helper()
+	user, err := s.store.GetUser(context.Background(), userID)
+	if err != nil || user == nil {
+		s.t.Fatalf("GetUser(%s) = %v, %v", userID, user, err)
+	}
+	return user.Score
+}
+
+func TestIdempotencyKeyReplaysTheStoredResponse(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.login("alice")
+	first := s.doWithKey("PATCH", "/users/alice/score/add", alice, "k1", `{"increment":5}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("first request: status %d: %s", first.Code, first.Body.String())
+	}
+	replay := s.doWithKey("PATCH", "/users/alice/score/add", alice, "k1", `{"increment":5}`)
+	if replay.Code != http.StatusOK || replay.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", replay.Code, replay.Body.String(), first.Code, first.Body.String())
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replay is missing Idempotent-Replayed")
+	}
+	if score := s.score("alice"); score != 5 {
+		t.Errorf("score = %d, want 5", score)
+	}
+}
+
+func TestIdempotencyKeyReusedForAnotherRequest(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.login("alice")
+	if w := s.doWithKey("PATCH", "/users/alice/score/add", alice, "k1", `{"increment":5}`); w.Code != http.StatusOK {
+		t.Fatalf("first request: status %d: %s", w.Code, w.Body.String())
+	}
+	if w := s.doWithKey("PATCH", "/users/alice/score/add", alice, "k1", `{"increment":6}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body: status %d, want 422", w.Code)
+	}
+	if score := s.score("alice"); score != 5 {
+		t.Errorf("score = %d, want 5", score)
+	}
+}
+
+func TestIdempotencyKeyInFlight(t *testing.T) {
+	s := newTestServer(t)
+	path, body := "/users/alice/score/add", `{"increment":5}`
+	sum := sha256.Sum256([]byte("PATCH " + path + "\n" + body))
+	// Another request with the same key has claimed it and not finished.
+	idempotency := services.NewIdempotencyService(s.store, time.Hour)
+	if _, err := idempotency.Begin(context.Background(), "alice", "k1", hex.EncodeToString(sum[:])); err != nil {
+		t.Fatal(err)
+	}
+	if w := s.doWithKey("PATCH", path, s.login("alice"), "k1", body); w.Code != http.StatusConflict {
+		t.Errorf("status %d, want 409", w.Code)
+	}
+	if score := s.score("alice"); score != 0 {
+		t.Errorf("score = %d, want 0", score)
+	}
+}
+
+func TestIdempotencyKeyReleasedAfterServerError(t *testing.T) {
+	s := newTestServerWith(t, func(store *repository.MemoryStore) repository.Store {
+		return &failingScoreStore{MemoryStore: store}
+	})
+	alice := s.login("alice")
+	if w := s.doWithKey("PATCH", "/users/alice/score/add", alice, "k1", `{"increment":5}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first request: status %d, want 500", w.Code)
+	}
+	w := s.doWithKey("PATCH", "/users/alice/score/add", alice, "k1", `{"increment":5}`)
+	if w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry: status %d, replayed %q, want a fresh 200", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	if score := s.score("alice"); score != 5 {
+		t.Errorf("score = %d, want 5", score)
+	}
+}
+
+func TestIdempotencyKeysAreScopedPerUser(t *testing.T) {
+	s := newTestServer(t)
+	requests := []struct{ user, body string }{
+		{"alice", `{"increment":5}`},
+		{"bob", `{"increment":7}`},
+	}
+	for _, r := range requests {
+		w := s.doWithKey("PATCH", "/users/"+r.user+"/score/add", s.login(r.user), "shared", r.body)
+		if w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("%s: status %d, replayed %q, want a fresh 200", r.user, w.Code, w.Header().Get("Idempotent-Replayed"))
+		}
+	}
+	if alice, bob := s.score("alice"), s.score("bob"); alice != 5 || bob != 7 {
+		t.Errorf("scores = %d, %d, want 5, 7", alice, bob)
+	}
+}
+
+func TestMergeConflictsReturn409(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.login("admin")
+	if err := s.store.PutUser(context.Background(), models.User{ID: "carol"}); err != nil {
+		t.Fatal(err)
+	}
+	if w := s.do("POST", "/admin/users/alice/merge", admin, `{"sourceUserId":"bob"}`); w.Code != http.StatusOK {
+		t.Fatalf("merge: status %d: %s", w.Code, w.Body.String())
+	}
+	if w := s.do("POST", "/admin/users/carol/merge", admin, `{"sourceUserId":"bob"}`); w.Code != http.StatusConflict {
+		t.Errorf("merging the source into another user: status %d, want 409", w.Code)
+	}
+	if w := s.do("POST", "/admin/users/bob/merge", admin, `{"sourceUserId":"carol"}`); w.Code != http.StatusConflict {
+		t.Errorf("merging into a merged-away user: status %d, want 409", w.Code)
+	}
+	if w := s.do("POST", "/admin/users/alice/merge", admin, `{"sourceUserId":"bob"}`); w.Code != http.StatusOK {
+		t.Errorf("repeating the merge: status %d, want 200", w.Code)
+	}
+}
+
+func TestStreakIsBuiltFromHistory(t *testing.T) {
+	s := newTestServer(t)
+	today := time.Now().UTC()
+	for _, daysAgo := range []int{0, 1, 2, 4, 5, 6, 7} {
+		date := today.AddDate(0, 0, -daysAgo).Format("2006-01-02")
+		if err := s.store.AddDailyActivity(context.Background(), "alice", date, 3, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := s.do("GET", "/users/alice/streak", s.login("alice"), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var streak struct {
+		Streak  int `json:"streak"`
+		Longest int `json:"longest"`
+	}
+	decode(t, w, &streak)
+	if streak.Streak != 3 || streak.Longest != 4 {
+		t.Errorf("streak = %+v, want current 3 and longest 4", streak)
+	}
+}
+
+// activityTotals returns the user's points and session count summed over
+// their daily activity, and their all-time points per language.
+func (s *testServer) activityTotals(userID string) (int, int, []repository.LanguageTotal) {
+	s.t.Helper()
+	ctx := context.Background()
+	rows, err := s.store.ListActivitySince(ctx, userID, "")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	points, sessions := 0, 0
+	for _, row := range rows {
+		points += row.Points
+		sessions += row.SessionCount
+	}
+	languages, err := s.store.ListUserLanguageTotals(ctx, userID, "", "")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return points, sessions, languages
+}
+
+func TestDeletingASessionReversesItsPoints(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.login("alice")
+	for _, id := range []string{"keep", "drop"} {
+		if w := s.do("POST", "/users/alice/sessions", alice, sessionBody(id, 20)); w.Code != http.StatusCreated {
+			t.Fatalf("recording %s: status %d: %s", id, w.Code, w.Body.String())
+		}
+	}
+
+	if w := s.do("DELETE", "/users/alice/sessions/drop", alice, `{"reason":"test run"}`); w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d: %s", w.Code, w.Body.String())
+	}
+	if score := s.score("alice"); score != 20 {
+		t.Errorf("score = %d, want 20", score)
+	}
+	points, sessions, languages := s.activityTotals("alice")
+	if points != 20 || sessions != 1 || !reflect.DeepEqual(languages, []repository.LanguageTotal{{Language: "go", Points: 20}}) {
+		t.Errorf("activity = %d points, %d sessions, languages %v, want 20, 1, go 20", points, sessions, languages)
+	}
+
+	// Deleting again changes nothing.
+	s.do("DELETE", "/users/alice/sessions/drop", alice, "")
+	if score := s.score("alice"); score != 20 {
+		t.Errorf("score after a second delete = %d, want 20", score)
+	}
+}
+
+func TestAdminAdjustingASessionMovesItsPoints(t *testing.T) {
+	s := newTestServer(t)
+	alice, admin := s.login("alice"), s.login("admin")
+	if w := s.do("POST", "/users/alice/sessions", alice, sessionBody("s1", 40)); w.Code != http.StatusCreated {
+		t.Fatalf("recording: status %d: %s", w.Code, w.Body.String())
+	}
+
+	w := s.do("PATCH", "/admin/users/alice/sessions/s1", admin, `{"points":10,"reason":"inflated"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("adjust: status %d: %s", w.Code, w.Body.String())
+	}
+	var session models.Session
+	decode(t, w, &session)
+	if session.Points != 10 {
+		t.Errorf("session points = %d, want 10", session.Points)
+	}
+	if score := s.score("alice"); score != 10 {
+		t.Errorf("score = %d, want 10", score)
+	}
+	points, sessions, languages := s.activityTotals("alice")
+	if points != 10 || sessions != 1 || !reflect.DeepEqual(languages, []repository.LanguageTotal{{Language: "go", Points: 10}}) {
+		t.Errorf("activity = %d points, %d sessions, languages %v, want 10, 1, go 10", points, sessions, languages)
+	}
+
+	if w := s.do("PATCH", "/admin/users/alice/sessions/s1", alice, `{"points":100,"reason":"mine"}`); w.Code != http.StatusForbidden {
+		t.Errorf("adjusting as alice: status %d, want 403", w.Code)
+	}
+	if w := s.do("PATCH", "/admin/users/alice/sessions/missing", admin, `{"points":5,"reason":"x"}`); w.Code != http.StatusNotFound {
+		t.Errorf("adjusting a missing session: status %d, want 404", w.Code)
+	}
+}
+
+func TestEveryScoreChangeIsInTheLedger(t *testing.T) {
+	s := newTestServer(t)
+	alice, admin := s.login("alice"), s.login("admin")
+	steps := []struct {
+		method, path, token, body string
+	}{
+		{"POST", "/users/alice/sessions", alice, sessionBody("s1", 30)},
+		{"PATCH", "/users/alice/score/add", alice, `{"increment":5}`},
+		{"PATCH", "/admin/users/alice/sessions/s1", admin, `{"points":20,"reason":"inflated"}`},
+		{"PATCH", "/users/alice/score", admin, `{"score":100}`},
+		{"DELETE", "/users/alice/sessions/s1", alice, `{"reason":"test run"}`},
+	}
+	for _, step := range steps {
+		if w := s.do(step.method, step.path, step.token, step.body); w.Code >= 300 {
+			t.Fatalf("%s %s: status %d: %s", step.method, step.path, w.Code, w.Body.String())
+		}
+	}
+
+	w := s.do("GET", "/users/alice/ledger", alice, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("ledger: status %d: %s", w.Code, w.Body.String())
+	}
+	var page struct {
+		Entries []models.LedgerEntry `json:"entries"`
+	}
+	decode(t, w, &page)
+
+	type change struct {
+		source, reference, actor string
+		delta                    int
+	}
+	got := map[change]int{}
+	sum := 0
+	for _, entry := range page.Entries {
+		got[change{entry.Source, entry.ReferenceID, entry.ActorID, entry.Delta}]++
+		sum += entry.Delta
+	}
+	want := map[change]int{
+		{models.LedgerSourceSession, "s1", "alice", 30}:     1,
+		{models.LedgerSourceManualAdd, "", "alice", 5}:      1,
+		{models.LedgerSourceCorrection, "s1", "admin", -10}: 1,
+		{models.LedgerSourceAdminSet, "", "admin", 75}:      1,
+		{models.LedgerSourceCorrection, "s1", "alice", -20}: 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ledger = %v, want %v", got, want)
+	}
+	if score := s.score("alice"); sum != score || score != 80 {
+		t.Errorf("ledger sums to %d, score is %d, want both 80", sum, score)
+	}
+}
@@ -1,18 +1,21 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/gin-gonic/gin"
 	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
 	"github.com/Brian-w-m/DevVerse/backend/src/services"
 	"github.com/Brian-w-m/DevVerse/backend/src/utils"
 )
 
-func registerStats(r gin.IRoutes, dynamodbClient *dynamodb.Client, cfg appconfig.Config, logger *utils.Logger) {
-	statsService := services.NewStatsService(dynamodbClient, cfg.DynamoDBTable)
+func registerStats(r gin.IRoutes, store repository.Store, cfg appconfig.Config, logger *utils.Logger) {
+	statsService := services.NewStatsService(store, store, store, store, store, store)
 
 	r.GET("/stats/:id", func(c *gin.Context) {
 		userID := c.Param("id")
@@ -32,27 +35,91 @@ func registerStats(r gin.IRoutes, dynamodbClient *dynamodb.Client, cfg appconfig
 	r.GET("/leaderboard", func(c *gin.Context) {
 		limitStr := c.DefaultQuery("limit", "10")
 		limit, err := strconv.Atoi(limitStr)
-		if err != nil || limit > 100 {
+		if err != nil || limit < 1 || limit > 100 {
 			limit = 10
 		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return
+		}
 
-		leaderboard, err := statsService.GetLeaderboard(c.Request.Context(), limit)
+		window, ok := leaderboardWindow(c)
+		if !ok {
+			return
+		}
+
+		leaderboard, next, err := statsService.GetLeaderboard(c.Request.Context(), window, leaderboardLanguage(c), offset, limit, c.Query("cursor"))
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
 		if err != nil {
 			logger.Errorf("failed to get leaderboard: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get leaderboard"})
 			return
 		}
+		// The body stays a plain array; the cursor for the next page travels in
+		// a header so existing clients are unaffected.
+		if next != "" {
+			c.Header("X-Next-Cursor", next)
+		}
 		c.JSON(http.StatusOK, leaderboard)
 	})
 
-	r.GET("/activity/:id", func(c *gin.Context) {
-		userID := c.Param("id")
-		activity, err := statsService.GetActivityData(c.Request.Context(), userID)
+	r.GET("/leaderboard/:id", func(c *gin.Context) {
+		window, ok := leaderboardWindow(c)
+		if !ok {
+			return
+		}
+		entry, err := statsService.GetUserRank(c.Request.Context(), c.Param("id"), window, leaderboardLanguage(c))
 		if err != nil {
-			logger.Errorf("failed to get activity data: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get activity data"})
+			logger.Errorf("failed to get user rank: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user rank"})
 			return
 		}
-		c.JSON(http.StatusOK, activity)
+		if entry == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusOK, entry)
+	})
+
+	r.GET("/languages", func(c *gin.Context) {
+		window, ok := leaderboardWindow(c)
+		if !ok {
+			return
+		}
+		languages, err := statsService.GetLanguages(c.Request.Context(), window)
+		if err != nil {
+			logger.Errorf("failed to get languages: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get languages"})
+			return
+		}
+		c.JSON(http.StatusOK, languages)
 	})
 }
+
+// leaderboardWindow reads the window, from and to query parameters, and tz,
+// the IANA timezone whose date is today (UTC by default). On error it writes
+// a 400 response and returns false.
+func leaderboardWindow(c *gin.Context) (services.LeaderboardWindow, bool) {
+	tz := c.Query("tz")
+	if err := services.ValidateTimezone(tz); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tz must be an IANA timezone such as Australia/Sydney"})
+		return services.LeaderboardWindow{}, false
+	}
+	loc, _ := time.LoadLocation(tz)
+	window, err := services.NewLeaderboardWindow(c.Query("window"), c.Query("from"), c.Query("to"), time.Now().In(loc))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be day, week, month or all, or use from and to (YYYY-MM-DD, at most 366 days)"})
+		return window, false
+	}
+	return window, true
+}
+
+// leaderboardLanguage reads the language query parameter, matched against the
+// lower-case language IDs that sessions are rolled up under.
+func leaderboardLanguage(c *gin.Context) string {
+	return strings.ToLower(strings.TrimSpace(c.Query("language")))
+}
@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/services"
+	"github.com/Brian-w-m/DevVerse/backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// registerTokens registers personal access token management. Tokens can only
+// be managed with a session token, so a leaked personal token cannot mint
+// more of itself.
+func registerTokens(r gin.IRoutes, store repository.Store, pats *services.PersonalTokenService, logger *utils.Logger) {
+	selfOrAdmin := utils.NewAuthorizer(store).RequireSelfOrAdmin("id")
+	sessionOnly := utils.RequireSessionToken()
+
+	r.POST("/users/:id/tokens", sessionOnly, func(c *gin.Context) {
+		// Only the owner may create tokens; admins act through their own.
+		id := c.Param("id")
+		if p, _ := utils.CurrentPrincipal(c); p.UserID != id {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+
+		var req struct {
+			Name          string   `json:"name" binding:"required"`
+			Scopes        []string `json:"scopes" binding:"required"`
+			ExpiresInDays int      `json:"expiresInDays"` // 0 never expires
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.ExpiresInDays < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiresInDays must not be negative"})
+			return
+		}
+
+		token, record, err := pats.Create(c.Request.Context(), id, req.Name, req.Scopes,
+			time.Duration(req.ExpiresInDays)*24*time.Hour)
+		if errors.Is(err, services.ErrInvalidScope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scopes must be one or more of " + strings.Join(models.KnownScopes, ", ")})
+			return
+		}
+		if err != nil {
+			logger.Errorf("failed to create personal access token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+			return
+		}
+		// The token is only ever returned here; it is stored hashed.
+		c.JSON(http.StatusCreated, gin.H{
+			"token":   token,
+			"details": record,
+		})
+	})
+
+	r.GET("/users/:id/tokens", selfOrAdmin, sessionOnly, func(c *gin.Context) {
+		tokens, err := pats.List(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			logger.Errorf("failed to list personal access tokens: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tokens"})
+			return
+		}
+		if tokens == nil {
+			tokens = []models.PersonalAccessToken{}
+		}
+		c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+	})
+
+	r.DELETE("/users/:id/tokens/:tokenId", selfOrAdmin, sessionOnly, func(c *gin.Context) {
+		deleted, err := pats.Revoke(c.Request.Context(), c.Param("id"), c.Param("tokenId"))
+		if err != nil {
+			logger.Errorf("failed to revoke personal access token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke token"})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
@@ -1,34 +1,59 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/gin-gonic/gin"
 	"github.com/Brian-w-m/DevVerse/backend/src/appconfig"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
 	"github.com/Brian-w-m/DevVerse/backend/src/models"
 	"github.com/Brian-w-m/DevVerse/backend/src/services"
 	"github.com/Brian-w-m/DevVerse/backend/src/utils"
 )
 
-func registerUsers(r gin.IRoutes, dynamodbClient *dynamodb.Client, cfg appconfig.Config, logger *utils.Logger) {
-	userService := services.NewUserService(dynamodbClient, cfg.DynamoDBTable, cfg.DailyActivityTable)
-	sessionService := services.NewSessionService(dynamodbClient, cfg.SessionsTable, cfg.DailyActivityTable)
+func registerUsers(r gin.IRoutes, store repository.Store, cfg appconfig.Config, logger *utils.Logger) {
+	userService := services.NewUserService(store, store, store)
+	sessionService := services.NewSessionService(store, store, store, store, sessionPolicy(cfg))
+	statsService := services.NewStatsService(store, store, store, store, store, store)
+	ledgerService := services.NewLedgerService(store)
+	idempotencyService := services.NewIdempotencyService(store, time.Duration(cfg.IdempotencyTTLHours)*time.Hour)
+	idempotency := idempotent(idempotencyService, logger)
+	selfOrAdmin := utils.NewAuthorizer(store).RequireSelfOrAdmin("id")
+	statsRead := utils.RequireScope(models.ScopeStatsRead)
+	sessionsWrite := utils.RequireScope(models.ScopeSessionsWrite)
 
-	r.GET("/users", func(c *gin.Context) {
-		users, err := userService.ListUsers(c.Request.Context())
+	r.GET("/users", statsRead, func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+
+		users, next, err := userService.ListUsersPage(c.Request.Context(), limit, c.Query("next"))
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next token"})
+			return
+		}
 		if err != nil {
 			logger.Errorf("failed to list users: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list users"})
 			return
 		}
-		c.JSON(http.StatusOK, users)
+		if users == nil {
+			users = []models.User{}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"users": users,
+			"next":  next,
+		})
 	})
 
-	r.GET("/users/:id", func(c *gin.Context) {
-		id := c.Param("id")
-		user, err := userService.GetUserByID(c.Request.Context(), id)
+	// /users/me resolves to the authenticated user
+	r.GET("/users/me", statsRead, func(c *gin.Context) {
+		user, err := userService.GetUserByID(c.Request.Context(), c.GetString("user_id"))
 		if err != nil {
 			logger.Errorf("failed to get user: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
@@ -41,74 +66,88 @@ func registerUsers(r gin.IRoutes, dynamodbClient *dynamodb.Client, cfg appconfig
 		c.JSON(http.StatusOK, user)
 	})
 
-	r.POST("/users", func(c *gin.Context) {
-		var user models.User
-		if err := c.ShouldBindJSON(&user); err != nil {
+	r.PATCH("/users/me", utils.RequireSessionToken(), func(c *gin.Context) {
+		var patch struct {
+			Name     *string `json:"name"`
+			Email    *string `json:"email"`
+			Timezone *string `json:"timezone"`
+		}
+		if err := c.ShouldBindJSON(&patch); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		
-		if err := userService.CreateUser(c.Request.Context(), user); err != nil {
-			logger.Errorf("failed to create user: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		if patch.Timezone != nil && services.ValidateTimezone(*patch.Timezone) != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "timezone must be an IANA name such as Australia/Sydney"})
 			return
 		}
 
-		c.JSON(http.StatusCreated, user)
-	})
-
-	r.PUT("/users/:id", func(c *gin.Context) {
-		id := c.Param("id")
-		var user models.User
-		if err := c.ShouldBindJSON(&user); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		user, err := userService.GetUserByID(c.Request.Context(), c.GetString("user_id"))
+		if err != nil {
+			logger.Errorf("failed to get user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
 			return
 		}
-		user.ID = id
+		if user == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if patch.Name != nil {
+			user.Name = *patch.Name
+		}
+		if patch.Email != nil {
+			user.Email = *patch.Email
+		}
 
-		if err := userService.UpdateUser(c.Request.Context(), user); err != nil {
+		if err := userService.UpdateUser(c.Request.Context(), *user); err != nil {
 			logger.Errorf("failed to update user: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
 			return
 		}
-
+		if patch.Timezone != nil {
+			user.Timezone = *patch.Timezone
+			if err := userService.SetTimezone(c.Request.Context(), user.ID, user.Timezone); err != nil {
+				logger.Errorf("failed to update user timezone: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+				return
+			}
+		}
 		c.JSON(http.StatusOK, user)
 	})
 
-	r.PATCH("/users/:id/score", func(c *gin.Context) {
+	r.GET("/users/:id", selfOrAdmin, statsRead, func(c *gin.Context) {
 		id := c.Param("id")
-
 		user, err := userService.GetUserByID(c.Request.Context(), id)
 		if err != nil {
 			logger.Errorf("failed to get user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
 			return
 		}
 		if user == nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
 		}
+		c.JSON(http.StatusOK, user)
+	})
 
-		var updateReq struct {
-			Score int `json:"score" binding:"required"`
-		}
-		if err := c.ShouldBindJSON(&updateReq); err != nil {
+	r.PUT("/users/:id", selfOrAdmin, utils.RequireSessionToken(), func(c *gin.Context) {
+		id := c.Param("id")
+		var user models.User
+		if err := c.ShouldBindJSON(&user); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		user.ID = id
 
-		if err := userService.UpdateUserScore(c.Request.Context(), id, updateReq.Score); err != nil {
-			logger.Errorf("failed to update user score: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user score"})
+		if err := userService.UpdateUser(c.Request.Context(), user); err != nil {
+			logger.Errorf("failed to update user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"id":    id,
-			"score": updateReq.Score,
-		})
+		c.JSON(http.StatusOK, user)
 	})
 
-	r.PATCH("/users/:id/score/add", func(c *gin.Context) {
+	r.PATCH("/users/:id/score/add", selfOrAdmin, sessionsWrite, idempotency, func(c *gin.Context) {
 		id := c.Param("id")
 
 		user, err := userService.GetUserByID(c.Request.Context(), id)
@@ -130,7 +169,8 @@ func registerUsers(r gin.IRoutes, dynamodbClient *dynamodb.Client, cfg appconfig
 			return
 		}
 
-		if err := userService.AddUserScore(c.Request.Context(), id, addReq.Increment); err != nil {
+		p, _ := utils.CurrentPrincipal(c)
+		if err := userService.AddUserScore(c.Request.Context(), id, addReq.Increment, p.UserID); err != nil {
 			logger.Errorf("failed to add user score: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add user score"})
 			return
@@ -149,7 +189,7 @@ func registerUsers(r gin.IRoutes, dynamodbClient *dynamodb.Client, cfg appconfig
 		})
 	})
 
-	r.POST("/users/:id/sessions", func(c *gin.Context) {
+	r.POST("/users/:id/sessions", selfOrAdmin, sessionsWrite, idempotency, func(c *gin.Context) {
 		var session models.Session
 		if err := c.ShouldBindJSON(&session); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -159,29 +199,133 @@ func registerUsers(r gin.IRoutes, dynamodbClient *dynamodb.Client, cfg appconfig
 			c.JSON(http.StatusBadRequest, gin.H{"error": "points must be greater than 0"})
 			return
 		}
-		userID, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		// Ownership is checked against the path by selfOrAdmin; the body may
+		// omit userId but must not name a different user.
+		id := c.Param("id")
+		if session.UserID == "" {
+			session.UserID = id
+		}
+		if session.UserID != id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "session userId does not match path"})
+			return
+		}
+		p, _ := utils.CurrentPrincipal(c)
+		recorded, err := sessionService.RecordSession(c.Request.Context(), session, p.UserID)
+		if errors.Is(err, services.ErrTooManyLanguages) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "languageBreakdown names too many languages"})
 			return
 		}
-		if session.UserID != userID.(string) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "session does not belong to authenticated user"})
+		if errors.Is(err, services.ErrSessionRejected) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 			return
 		}
-		if err := sessionService.RecordSession(c.Request.Context(), session); err != nil {
+		if err != nil {
 			logger.Errorf("failed to record session: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record session"})
 			return
 		}
-		if err := userService.AddUserScore(c.Request.Context(), session.UserID, session.Points); err != nil {
-			logger.Errorf("failed to add user score: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add user score"})
+		// A replayed SessionID is acknowledged without being counted again.
+		status := http.StatusCreated
+		if !recorded {
+			status = http.StatusOK
+		}
+		c.JSON(status, gin.H{
+			"session":  session,
+			"recorded": recorded,
+		})
+	})
+
+	r.GET("/users/:id/sessions", selfOrAdmin, statsRead, func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+
+		sessions, next, err := sessionService.ListSessions(c.Request.Context(), c.Param("id"), services.SessionQuery{
+			From:     c.Query("from"),
+			To:       c.Query("to"),
+			Language: c.Query("language"),
+			Sort:     c.Query("sort"),
+			Limit:    limit,
+			Next:     c.Query("next"),
+			Deleted:  c.Query("deleted") == "true",
+		})
+		if errors.Is(err, services.ErrInvalidSessionQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be endedAt, startedAt or points, optionally prefixed with -, and from and to must be YYYY-MM-DD"})
+			return
+		}
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next token"})
+			return
+		}
+		if err != nil {
+			logger.Errorf("failed to list sessions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"sessions": sessions,
+			"next":     next,
+		})
+	})
+
+	r.GET("/users/:id/sessions/:sessionId", selfOrAdmin, statsRead, func(c *gin.Context) {
+		session, err := sessionService.GetSession(c.Request.Context(), c.Param("id"), c.Param("sessionId"))
+		if err != nil {
+			logger.Errorf("failed to get session: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get session"})
+			return
+		}
+		if session == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
 			return
 		}
-		c.JSON(http.StatusCreated, session)
+		c.JSON(http.StatusOK, session)
+	})
+
+	// Deleting a session takes its points back out of the user's score and
+	// activity. The session is kept, marked deleted, so replays stay ignored.
+	r.DELETE("/users/:id/sessions/:sessionId", selfOrAdmin, sessionsWrite, func(c *gin.Context) {
+		var req struct {
+			Reason string `json:"reason" binding:"max=500"`
+		}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		p, _ := utils.CurrentPrincipal(c)
+		session, err := sessionService.DeleteSession(c.Request.Context(), c.Param("id"), c.Param("sessionId"), p.UserID, req.Reason)
+		writeSessionCorrection(c, session, err, logger)
 	})
 
-	r.GET("/users/:id/streak", func(c *gin.Context) {
+	// Every change to the user's score, newest first, with what caused it.
+	r.GET("/users/:id/ledger", selfOrAdmin, statsRead, func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+
+		entries, next, err := ledgerService.ListEntries(c.Request.Context(), c.Param("id"), limit, c.Query("next"))
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next token"})
+			return
+		}
+		if err != nil {
+			logger.Errorf("failed to list ledger entries: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list ledger entries"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"entries": entries,
+			"next":    next,
+		})
+	})
+
+	r.GET("/users/:id/streak", selfOrAdmin, statsRead, func(c *gin.Context) {
 		id := c.Param("id")
 		streak, err := sessionService.GetStreak(c.Request.Context(), id)
 		if err != nil {
@@ -190,28 +334,36 @@ func registerUsers(r gin.IRoutes, dynamodbClient *dynamodb.Client, cfg appconfig
 			return
 		}
 		c.Header("Cache-Control", "max-age=60")
-		c.JSON(http.StatusOK, gin.H{"streak": streak})
+		// "streak" stays the current length for existing clients.
+		c.JSON(http.StatusOK, gin.H{
+			"streak":       streak.CurrentLength,
+			"currentStart": streak.CurrentStart,
+			"currentEnd":   streak.CurrentEnd,
+			"longest":      streak.LongestLength,
+			"longestStart": streak.LongestStart,
+			"longestEnd":   streak.LongestEnd,
+		})
 	})
 
-	// /users/:id/activity is intentionally public — see registerPublicUserRoutes
-
-	r.DELETE("/users/:id", func(c *gin.Context) {
-		id := c.Param("id")
-		if err := userService.DeleteUser(c.Request.Context(), id); err != nil {
-			logger.Errorf("failed to delete user: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
+	r.GET("/users/:id/languages", selfOrAdmin, statsRead, func(c *gin.Context) {
+		window, ok := leaderboardWindow(c)
+		if !ok {
 			return
 		}
-
-		c.JSON(http.StatusNoContent, nil)
+		languages, err := statsService.GetUserLanguages(c.Request.Context(), c.Param("id"), window)
+		if err != nil {
+			logger.Errorf("failed to get user languages: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get languages"})
+			return
+		}
+		if languages == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusOK, languages)
 	})
-}
 
-// registerPublicUserRoutes registers endpoints that don't require auth (dev convenience until Phase 5).
-func registerPublicUserRoutes(r gin.IRoutes, dynamodbClient *dynamodb.Client, cfg appconfig.Config, logger *utils.Logger) {
-	sessionService := services.NewSessionService(dynamodbClient, cfg.SessionsTable, cfg.DailyActivityTable)
-
-	r.GET("/users/:id/activity", func(c *gin.Context) {
+	r.GET("/users/:id/activity", selfOrAdmin, statsRead, func(c *gin.Context) {
 		id := c.Param("id")
 		days := c.Query("days")
 		if days == "" {
@@ -234,4 +386,42 @@ func registerPublicUserRoutes(r gin.IRoutes, dynamodbClient *dynamodb.Client, cf
 		}
 		c.JSON(http.StatusOK, activity)
 	})
+
+	r.GET("/activity/:id", selfOrAdmin, statsRead, func(c *gin.Context) {
+		activity, err := statsService.GetActivityData(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			logger.Errorf("failed to get activity data: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get activity data"})
+			return
+		}
+		c.JSON(http.StatusOK, activity)
+	})
+
+	// POST /users, PATCH /users/:id/score and DELETE /users/:id are admin-only — see registerAdmin
+}
+
+// writeSessionCorrection writes the response to a session deletion or
+// adjustment.
+func writeSessionCorrection(c *gin.Context, session *models.Session, err error, logger *utils.Logger) {
+	switch {
+	case errors.Is(err, services.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+	case errors.Is(err, services.ErrSessionContention):
+		c.JSON(http.StatusConflict, gin.H{"error": "session is being updated, try again"})
+	case err != nil:
+		logger.Errorf("failed to correct session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to correct session"})
+	default:
+		c.JSON(http.StatusOK, session)
+	}
+}
+
+// sessionPolicy builds the session timestamp policy from the config.
+func sessionPolicy(cfg appconfig.Config) services.SessionPolicy {
+	return services.SessionPolicy{
+		MaxAge:       time.Duration(cfg.SessionMaxAgeHours) * time.Hour,
+		MaxFuture:    time.Duration(cfg.SessionMaxFutureMinutes) * time.Minute,
+		MaxDuration:  time.Duration(cfg.SessionMaxDurationHours) * time.Hour,
+		AllowUntimed: cfg.SessionAllowUntimed,
+	}
 }
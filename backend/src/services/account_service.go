@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+var (
+	// ErrIdentityLinked is returned when linking an identity that already
+	// belongs to another user; merge that user to take it over.
+	ErrIdentityLinked = errors.New("identity is linked to another user")
+	// ErrLastIdentity is returned when unlinking a user's only identity.
+	ErrLastIdentity = errors.New("cannot unlink the last identity")
+	// ErrInvalidMerge is returned when merging a user into itself or when
+	// either user does not exist.
+	ErrInvalidMerge = errors.New("invalid merge")
+	// ErrMergeConflict is returned when the source is already being merged
+	// into a different user, or the target has been merged away.
+	ErrMergeConflict = errors.New("merge conflicts with another merge")
+)
+
+// maxMergeChain bounds how many completed merges are followed when resolving
+// an old user ID.
+const maxMergeChain = 8
+
+// mergeSessionBatch is how many sessions a merge moves per listing.
+const mergeSessionBatch = 100
+
+// AccountService links login identities to users and merges duplicate users.
+type AccountService struct {
+	users     repository.UserRepository
+	accounts  repository.AccountRepository
+	sessions  repository.SessionRepository
+	activity  repository.ActivityRepository
+	languages repository.LanguageRepository
+	streaks   *StreakService
+	tokens    *TokenService
+}
+
+func NewAccountService(users repository.UserRepository, accounts repository.AccountRepository, sessions repository.SessionRepository, activity repository.ActivityRepository, languages repository.LanguageRepository, streaks repository.StreakRepository, tokens *TokenService) *AccountService {
+	return &AccountService{
+		users:     users,
+		accounts:  accounts,
+		sessions:  sessions,
+		activity:  activity,
+		languages: languages,
+		streaks:   NewStreakService(streaks, activity),
+		tokens:    tokens,
+	}
+}
+
+func newIdentityLink(identity *Identity, userID string) models.IdentityLink {
+	return models.IdentityLink{
+		ID:       identity.LinkID(),
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		UserID:   userID,
+		Login:    identity.Login,
+		LinkedAt: time.Now().Unix(),
+	}
+}
+
+// ResolveLogin returns the ID of the user the identity signs in as. An
+// identity seen for the first time is linked to its default user ID (see
+// Identity.UserID), or to whichever user that ID was merged into.
+func (s *AccountService) ResolveLogin(ctx context.Context, identity *Identity) (string, error) {
+	link, err := s.accounts.GetIdentityLink(ctx, identity.LinkID())
+	if err != nil {
+		return "", err
+	}
+	if link != nil {
+		return link.UserID, nil
+	}
+
+	userID, err := s.followMerges(ctx, identity.UserID())
+	if err != nil {
+		return "", err
+	}
+	created, err := s.accounts.CreateIdentityLink(ctx, newIdentityLink(identity, userID))
+	if err != nil {
+		return "", err
+	}
+	if !created {
+		// A concurrent login or link got there first.
+		link, err := s.accounts.GetIdentityLink(ctx, identity.LinkID())
+		if err != nil {
+			return "", err
+		}
+		if link != nil {
+			return link.UserID, nil
+		}
+	}
+	return userID, nil
+}
+
+// followMerges maps a user ID that has been merged away to the user that
+// survived.
+func (s *AccountService) followMerges(ctx context.Context, userID string) (string, error) {
+	for i := 0; i < maxMergeChain; i++ {
+		merge, err := s.accounts.GetUserMerge(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		if merge == nil {
+			break
+		}
+		userID = merge.TargetID
+	}
+	return userID, nil
+}
+
+// IdentityOwner returns the ID of the user the identity belongs to, or ""
+// if it belongs to no one yet. Identities from before linking existed belong
+// to their default user ID if that user exists.
+func (s *AccountService) IdentityOwner(ctx context.Context, identity *Identity) (string, error) {
+	link, err := s.accounts.GetIdentityLink(ctx, identity.LinkID())
+	if err != nil {
+		return "", err
+	}
+	if link != nil {
+		return link.UserID, nil
+	}
+	userID, err := s.followMerges(ctx, identity.UserID())
+	if err != nil {
+		return "", err
+	}
+	user, err := s.users.GetUser(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", nil
+	}
+	return userID, nil
+}
+
+// LinkIdentity attaches the identity to userID so it signs in as that user.
+// If the identity belongs to another user, it returns that user's ID and
+// ErrIdentityLinked.
+func (s *AccountService) LinkIdentity(ctx context.Context, userID string, identity *Identity) (string, error) {
+	owner, err := s.IdentityOwner(ctx, identity)
+	if err != nil {
+		return "", err
+	}
+	if owner != "" && owner != userID {
+		return owner, ErrIdentityLinked
+	}
+	created, err := s.accounts.CreateIdentityLink(ctx, newIdentityLink(identity, userID))
+	if err != nil {
+		return "", err
+	}
+	if !created {
+		link, err := s.accounts.GetIdentityLink(ctx, identity.LinkID())
+		if err != nil {
+			return "", err
+		}
+		if link != nil && link.UserID != userID {
+			return link.UserID, ErrIdentityLinked
+		}
+	}
+	return userID, nil
+}
+
+func (s *AccountService) ListIdentities(ctx context.Context, userID string) ([]models.IdentityLink, error) {
+	return s.accounts.ListIdentityLinks(ctx, userID)
+}
+
+// UnlinkIdentity detaches one of the user's identities. It reports false if
+// the user has no such identity, and refuses to remove the last one.
+func (s *AccountService) UnlinkIdentity(ctx context.Context, userID, linkID string) (bool, error) {
+	links, err := s.accounts.ListIdentityLinks(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !slices.ContainsFunc(links, func(l models.IdentityLink) bool { return l.ID == linkID }) {
+		return false, nil
+	}
+	if len(links) == 1 {
+		return false, ErrLastIdentity
+	}
+	return s.accounts.DeleteIdentityLink(ctx, userID, linkID)
+}
+
+func (s *AccountService) GetMerge(ctx context.Context, sourceID string) (*models.UserMerge, error) {
+	return s.accounts.GetUserMerge(ctx, sourceID)
+}
+
+// MergeUsers folds sourceID into targetID: the source's tokens are revoked,
+// its identities, sessions and daily activity move to the target, its score
+// is added to the target's and the source user is deleted. Progress is
+// recorded after every step and each step can be repeated safely, so calling
+// MergeUsers again for the same pair resumes an interrupted merge. The target
+// must still exist before each step, so nothing is moved to a user deleted
+// since the merge started. A completed merge is returned unchanged.
+func (s *AccountService) MergeUsers(ctx context.Context, sourceID, targetID, requestedBy string) (*models.UserMerge, error) {
+	if sourceID == "" || sourceID == targetID {
+		return nil, ErrInvalidMerge
+	}
+	targetMerge, err := s.accounts.GetUserMerge(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if targetMerge != nil {
+		return nil, ErrMergeConflict
+	}
+
+	merge, err := s.accounts.GetUserMerge(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if merge == nil {
+		for _, id := range []string{sourceID, targetID} {
+			user, err := s.users.GetUser(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if user == nil {
+				return nil, ErrInvalidMerge
+			}
+		}
+		now := time.Now().Unix()
+		merge = &models.UserMerge{
+			SourceID:    sourceID,
+			TargetID:    targetID,
+			RequestedBy: requestedBy,
+			Step:        models.MergeSteps[0],
+			StartedAt:   now,
+			UpdatedAt:   now,
+		}
+		created, err := s.accounts.CreateUserMerge(ctx, *merge)
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			return nil, ErrMergeConflict
+		}
+	} else if merge.TargetID != targetID {
+		return nil, ErrMergeConflict
+	}
+
+	for merge.Step != models.MergeStepDone {
+		target, err := s.users.GetUser(ctx, targetID)
+		if err != nil {
+			return merge, err
+		}
+		if target == nil {
+			return merge, ErrInvalidMerge
+		}
+		if err := s.runMergeStep(ctx, merge); err != nil {
+			return merge, fmt.Errorf("merge step %s failed: %w", merge.Step, err)
+		}
+		next := slices.Index(models.MergeSteps, merge.Step) + 1
+		merge.Step = models.MergeSteps[next]
+		merge.UpdatedAt = time.Now().Unix()
+		if merge.Step == models.MergeStepDone {
+			merge.CompletedAt = merge.UpdatedAt
+		}
+		if err := s.accounts.PutUserMerge(ctx, *merge); err != nil {
+			return merge, err
+		}
+	}
+	return merge, nil
+}
+
+func (s *AccountService) runMergeStep(ctx context.Context, merge *models.UserMerge) error {
+	source, target := merge.SourceID, merge.TargetID
+	switch merge.Step {
+	case models.MergeStepRevokeTokens:
+		// Revoking first stops the source from earning points mid-merge.
+		return s.tokens.RevokeUser(ctx, source)
+
+	case models.MergeStepIdentities:
+		links, err := s.accounts.ListIdentityLinks(ctx, source)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			if err := s.accounts.SetIdentityLinkUser(ctx, link.ID, target); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case models.MergeStepSessions:
+		for {
+			sessions, err := s.sessions.ListUserSessions(ctx, source, mergeSessionBatch)
+			if err != nil {
+				return err
+			}
+			if len(sessions) == 0 {
+				return nil
+			}
+			for _, session := range sessions {
+				moved, err := s.accounts.MoveSession(ctx, session, target, session.SessionID)
+				if err != nil {
+					return err
+				}
+				if !moved {
+					// Both users recorded the same SessionID; keep both.
+					renamed := session.SessionID + "@" + source
+					if moved, err = s.accounts.MoveSession(ctx, session, target, renamed); err != nil {
+						return err
+					}
+					if !moved {
+						return fmt.Errorf("session %s already exists for %s", renamed, target)
+					}
+				}
+			}
+		}
+
+	case models.MergeStepActivity:
+		rows, err := s.activity.ListActivitySince(ctx, source, "")
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := s.accounts.MoveDailyActivity(ctx, source, row.Date, target); err != nil {
+				return err
+			}
+		}
+		// The moved days can join or lengthen the target's runs.
+		_, err = s.streaks.Rebuild(ctx, target)
+		return err
+
+	case models.MergeStepLanguages:
+		rows, err := s.languages.ListLanguageActivity(ctx, source)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := s.accounts.MoveLanguageActivity(ctx, row, target); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case models.MergeStepScore:
+		entry, err := newLedgerEntry(models.LedgerSourceMerge, "", merge.RequestedBy)
+		if err != nil {
+			return err
+		}
+		return s.accounts.MoveUserScore(ctx, source, target, entry)
+
+	case models.MergeStepDeleteSource:
+		return s.users.DeleteUser(ctx, source)
+	}
+	return fmt.Errorf("unknown merge step %q", merge.Step)
+}
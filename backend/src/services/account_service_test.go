@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+// newMergeFixture returns an account service over a store holding users src
+// and dst. src has sessions s1 and shared and an identity link; dst has its
+// own session named shared.
+func newMergeFixture(t *testing.T) (*AccountService, *repository.MemoryStore) {
+	t.Helper()
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	for _, id := range []string{"src", "dst"} {
+		if err := store.PutUser(ctx, models.User{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sessions := []models.Session{
+		{UserID: "src", SessionID: "s1", Points: 10, Date: "2024-01-01"},
+		{UserID: "src", SessionID: "shared", Points: 3, Date: "2024-01-02"},
+		{UserID: "dst", SessionID: "shared", Points: 5, Date: "2024-01-02"},
+	}
+	for _, session := range sessions {
+		session.LanguageBreakdown = map[string]int{"go": session.Points}
+		session.Days = []models.SessionDay{{Date: session.Date, Points: session.Points, Languages: session.LanguageBreakdown}}
+		entry := models.LedgerEntry{EntryID: session.UserID + session.SessionID, Source: models.LedgerSourceSession}
+		if _, err := store.RecordSession(ctx, session, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.CreateIdentityLink(ctx, models.IdentityLink{ID: "github:1", Provider: ProviderGitHub, Subject: "1", UserID: "src"}); err != nil {
+		t.Fatal(err)
+	}
+	tokens := newTestTokenService(t, store, store)
+	return NewAccountService(store, store, store, store, store, store, tokens), store
+}
+
+// checkMerged checks that everything src had now belongs to dst.
+func checkMerged(t *testing.T, store *repository.MemoryStore) {
+	t.Helper()
+	ctx := context.Background()
+	if user, _ := store.GetUser(ctx, "src"); user != nil {
+		t.Errorf("source still exists: %+v", *user)
+	}
+	user, err := store.GetUser(ctx, "dst")
+	if err != nil || user == nil || user.Score != 18 {
+		t.Fatalf("target = %+v, %v, want score 18", user, err)
+	}
+
+	sessions, err := store.ListUserSessions(ctx, "dst", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, session := range sessions {
+		ids = append(ids, session.SessionID)
+	}
+	sort.Strings(ids)
+	if want := []string{"s1", "shared", "shared@src"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("target sessions = %v, want %v", ids, want)
+	}
+
+	activity, err := store.ListActivitySince(ctx, "dst", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := make(map[string]int)
+	for _, row := range activity {
+		points[row.Date] += row.Points
+	}
+	if want := map[string]int{"2024-01-01": 10, "2024-01-02": 8}; !reflect.DeepEqual(points, want) {
+		t.Errorf("target activity = %v, want %v", points, want)
+	}
+	languages, err := store.ListUserLanguageTotals(ctx, "dst", "", "")
+	if err != nil || !reflect.DeepEqual(languages, []repository.LanguageTotal{{Language: "go", Points: 18}}) {
+		t.Errorf("target languages = %v, %v, want go 18", languages, err)
+	}
+	link, err := store.GetIdentityLink(ctx, "github:1")
+	if err != nil || link == nil || link.UserID != "dst" {
+		t.Errorf("identity link = %+v, %v, want it on dst", link, err)
+	}
+}
+
+func TestMergeUsers(t *testing.T) {
+	s, store := newMergeFixture(t)
+	merge, err := s.MergeUsers(context.Background(), "src", "dst", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merge.Step != models.MergeStepDone || merge.CompletedAt == 0 {
+		t.Errorf("merge = %+v, want it done", *merge)
+	}
+	checkMerged(t, store)
+}
+
+func TestMergeUsersResumesAfterEachStep(t *testing.T) {
+	steps := models.MergeSteps[:len(models.MergeSteps)-1]
+	for i, step := range steps {
+		t.Run(step, func(t *testing.T) {
+			ctx := context.Background()
+			s, store := newMergeFixture(t)
+			// Steps up to and including step ran, but the process stopped
+			// before recording that step finished.
+			merge := models.UserMerge{SourceID: "src", TargetID: "dst", RequestedBy: "admin", Step: models.MergeSteps[0]}
+			if _, err := store.CreateUserMerge(ctx, merge); err != nil {
+				t.Fatal(err)
+			}
+			for _, ran := range steps[:i+1] {
+				merge.Step = ran
+				if err := s.runMergeStep(ctx, &merge); err != nil {
+					t.Fatalf("step %s: %v", ran, err)
+				}
+			}
+			if err := store.PutUserMerge(ctx, merge); err != nil {
+				t.Fatal(err)
+			}
+
+			resumed, err := s.MergeUsers(ctx, "src", "dst", "admin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resumed.Step != models.MergeStepDone {
+				t.Errorf("merge step = %s, want done", resumed.Step)
+			}
+			checkMerged(t, store)
+		})
+	}
+}
+
+func TestMergeUsersStopsWhenTargetIsDeleted(t *testing.T) {
+	ctx := context.Background()
+	s, store := newMergeFixture(t)
+	merge := models.UserMerge{SourceID: "src", TargetID: "dst", RequestedBy: "admin", Step: models.MergeStepSessions}
+	if _, err := store.CreateUserMerge(ctx, merge); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteUser(ctx, "dst"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.MergeUsers(ctx, "src", "dst", "admin"); !errors.Is(err, ErrInvalidMerge) {
+		t.Fatalf("MergeUsers() = %v, want ErrInvalidMerge", err)
+	}
+	if sessions, _ := store.ListUserSessions(ctx, "src", 10); len(sessions) != 2 {
+		t.Errorf("source has %d sessions left, want 2", len(sessions))
+	}
+	if stored, _ := store.GetUserMerge(ctx, "src"); stored == nil || stored.Step != models.MergeStepSessions {
+		t.Errorf("merge = %+v, want it still at the sessions step", stored)
+	}
+}
+
+func TestMergeUsersConflicts(t *testing.T) {
+	ctx := context.Background()
+	s, store := newMergeFixture(t)
+	if err := store.PutUser(ctx, models.User{ID: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.MergeUsers(ctx, "src", "dst", "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		source, target string
+	}{
+		{"source merged into another user", "src", "other"},
+		{"target merged away", "other", "src"},
+	}
+	for _, tt := range tests {
+		if _, err := s.MergeUsers(ctx, tt.source, tt.target, "admin"); !errors.Is(err, ErrMergeConflict) {
+			t.Errorf("%s: MergeUsers() = %v, want ErrMergeConflict", tt.name, err)
+		}
+	}
+	if merge, err := s.MergeUsers(ctx, "src", "dst", "admin"); err != nil || merge.Step != models.MergeStepDone {
+		t.Errorf("repeating a completed merge = %+v, %v", merge, err)
+	}
+}
@@ -2,132 +2,173 @@ package services
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
-
-	"github.com/golang-jwt/jwt/v5"
 )
 
-type AuthService struct {
-	jwtSecret string
+// ErrInvalidOAuthState is returned when the OAuth state cookie is missing,
+// tampered with, expired or does not match the callback's state parameter.
+var ErrInvalidOAuthState = errors.New("invalid oauth state")
+
+// GitHubConfig holds the OAuth app credentials and the GitHub endpoints, which
+// are configurable so a fake GitHub server can be used in tests.
+type GitHubConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	OAuthBaseURL string // e.g. https://github.com
+	APIBaseURL   string // e.g. https://api.github.com
 }
 
-type GitHubUser struct {
-	ID    int    `json:"id"`
-	Login string `json:"login"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+// AuthService verifies logins with the configured identity providers and
+// runs the GitHub OAuth authorization code flow.
+type AuthService struct {
+	stateSecret string
+	github      GitHubConfig
+	providers   map[string]IdentityProvider
 }
 
-type GitHubEmail struct {
-	Email      string `json:"email"`
-	Primary    bool   `json:"primary"`
-	Verified   bool   `json:"verified"`
-	Visibility string `json:"visibility"`
+// NewAuthService registers GitHub and any extra identity providers, keyed by
+// their names. A provider whose name is already taken is ignored. stateSecret
+// signs the OAuth state cookie.
+func NewAuthService(stateSecret string, github GitHubConfig, extra ...IdentityProvider) *AuthService {
+	s := &AuthService{
+		stateSecret: stateSecret,
+		github:      github,
+		providers:   map[string]IdentityProvider{},
+	}
+	for _, p := range append([]IdentityProvider{NewGitHubProvider(github.APIBaseURL)}, extra...) {
+		if _, taken := s.providers[p.Name()]; !taken {
+			s.providers[p.Name()] = p
+		}
+	}
+	return s
 }
 
-func NewAuthService(jwtSecret string) *AuthService {
-	return &AuthService{
-		jwtSecret: jwtSecret,
+// randomToken returns n random bytes encoded as unpadded base64url.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-// VerifyGitHubToken verifies the GitHub access token and returns user info
-func (s *AuthService) VerifyGitHubToken(ctx context.Context, accessToken string) (*GitHubUser, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.github.com/user", nil)
+// NewGitHubAuthorization starts an OAuth authorization code flow with PKCE.
+// It returns the GitHub URL to redirect the browser to and an opaque, signed
+// value that must be handed back to VerifyOAuthState on callback.
+func (s *AuthService) NewGitHubAuthorization(ttl time.Duration) (authorizeURL, stateCookie string, err error) {
+	if s.stateSecret == "" {
+		return "", "", errors.New("no OAuth state secret is configured")
+	}
+	state, err := randomToken(24)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", "", err
 	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("token %s", accessToken))
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	verifier, err := randomToken(32)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call GitHub API: %w", err)
+		return "", "", err
 	}
-	defer resp.Body.Close()
+	challenge := sha256.Sum256([]byte(verifier))
+
+	q := url.Values{}
+	q.Set("client_id", s.github.ClientID)
+	q.Set("redirect_uri", s.github.RedirectURL)
+	q.Set("scope", "read:user user:email")
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	authorizeURL = s.github.OAuthBaseURL + "/login/oauth/authorize?" + q.Encode()
+
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	payload := state + "." + verifier + "." + expires
+	return authorizeURL, payload + "." + s.sign(payload), nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+// VerifyOAuthState checks the signed cookie value against the state returned
+// by GitHub and returns the PKCE code verifier.
+func (s *AuthService) VerifyOAuthState(stateCookie, state string) (string, error) {
+	// Anyone could sign a state with an empty key.
+	if s.stateSecret == "" {
+		return "", ErrInvalidOAuthState
 	}
-
-	var githubUser GitHubUser
-	if err := json.NewDecoder(resp.Body).Decode(&githubUser); err != nil {
-		return nil, fmt.Errorf("failed to decode GitHub response: %w", err)
+	parts := strings.Split(stateCookie, ".")
+	if len(parts) != 4 {
+		return "", ErrInvalidOAuthState
 	}
-
-	// If email is empty (common when the user hides public email), fetch from /user/emails
-	if githubUser.Email == "" {
-		if email, err := s.fetchPrimaryEmail(ctx, accessToken); err == nil {
-			githubUser.Email = email
-		}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(s.sign(payload)), []byte(parts[3])) {
+		return "", ErrInvalidOAuthState
 	}
-
-	return &githubUser, nil
-}
-
-// GenerateJWT generates a JWT token for the user
-func (s *AuthService) GenerateJWT(userID string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
-		"iat":     time.Now().Unix(),
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", ErrInvalidOAuthState
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
-	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+	if state == "" || !hmac.Equal([]byte(parts[0]), []byte(state)) {
+		return "", ErrInvalidOAuthState
 	}
+	return parts[1], nil
+}
 
-	return tokenString, nil
+func (s *AuthService) sign(payload string) string {
+	mac := hmac.New(sha256.New, []byte(s.stateSecret))
+	mac.Write([]byte("oauth-state:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 }
 
-// fetchPrimaryEmail retrieves the user's primary verified email from GitHub
-func (s *AuthService) fetchPrimaryEmail(ctx context.Context, accessToken string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.github.com/user/emails", nil)
+// ExchangeGitHubCode trades an authorization code for a GitHub access token.
+func (s *AuthService) ExchangeGitHubCode(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("client_id", s.github.ClientID)
+	form.Set("client_secret", s.github.ClientSecret)
+	form.Set("code", code)
+	form.Set("redirect_uri", s.github.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.github.OAuthBaseURL+"/login/oauth/access_token", strings.NewReader(form.Encode()))
 	if err != nil {
-		return "", fmt.Errorf("failed to create emails request: %w", err)
+		return "", fmt.Errorf("failed to create token request: %w", err)
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("token %s", accessToken))
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to call GitHub emails API: %w", err)
+		return "", fmt.Errorf("failed to call GitHub token endpoint: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("emails API status %d: %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("GitHub token endpoint returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var emails []GitHubEmail
-	if err := json.NewDecoder(resp.Body).Decode(&emails); err != nil {
-		return "", fmt.Errorf("failed to decode emails: %w", err)
+	// GitHub reports exchange failures with 200 and an error field.
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
 	}
-
-	for _, e := range emails {
-		if e.Primary && e.Verified {
-			return e.Email, nil
-		}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
 	}
-	for _, e := range emails {
-		if e.Verified {
-			return e.Email, nil
-		}
+	if token.Error != "" {
+		return "", fmt.Errorf("GitHub rejected code: %s: %s", token.Error, token.ErrorDescription)
 	}
-	if len(emails) > 0 {
-		return emails[0].Email, nil
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("GitHub token response had no access_token")
 	}
-	return "", nil
+	return token.AccessToken, nil
 }
-
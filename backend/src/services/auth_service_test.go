@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestOAuthState(t *testing.T) {
+	s := NewAuthService("secret", GitHubConfig{ClientID: "id", OAuthBaseURL: "https://github.example"})
+	authorizeURL, cookie, err := s.NewGitHubAuthorization(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authorizeURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := u.Query().Get("state")
+
+	if _, err := s.VerifyOAuthState(cookie, state); err != nil {
+		t.Errorf("VerifyOAuthState() with the issued state = %v", err)
+	}
+	if _, err := s.VerifyOAuthState(cookie, state+"x"); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Errorf("VerifyOAuthState() with another state = %v, want ErrInvalidOAuthState", err)
+	}
+	other := NewAuthService("other", GitHubConfig{})
+	if _, err := other.VerifyOAuthState(cookie, state); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Errorf("VerifyOAuthState() with another secret = %v, want ErrInvalidOAuthState", err)
+	}
+}
+
+func TestOAuthStateWithoutSecret(t *testing.T) {
+	s := NewAuthService("", GitHubConfig{ClientID: "id"})
+	if _, _, err := s.NewGitHubAuthorization(time.Minute); err == nil {
+		t.Error("NewGitHubAuthorization() without a secret succeeded")
+	}
+
+	// A state signed with the empty key must not be accepted either.
+	forger := &AuthService{}
+	payload := "state.verifier.9999999999"
+	if _, err := s.VerifyOAuthState(payload+"."+forger.sign(payload), "state"); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Errorf("VerifyOAuthState() = %v, want ErrInvalidOAuthState", err)
+	}
+}
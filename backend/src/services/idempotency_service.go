@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+var (
+	ErrIdempotencyKeyInUse    = errors.New("idempotency key is in use by a request that has not finished")
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was already used for a different request")
+)
+
+// idempotencyLockTTL bounds how long a request that never completes (e.g. the
+// server crashed mid-request) keeps its key locked.
+const idempotencyLockTTL = time.Minute
+
+// IdempotencyService stores the outcome of requests sent with an
+// Idempotency-Key so that retries replay the original response. Keys are
+// scoped to the authenticated user.
+type IdempotencyService struct {
+	records repository.IdempotencyRepository
+	ttl     time.Duration
+}
+
+func NewIdempotencyService(records repository.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		records: records,
+		ttl:     ttl,
+	}
+}
+
+// Begin claims key for the request identified by requestHash. It returns nil
+// when the caller should process the request, or the stored record whose
+// response should be replayed instead.
+func (s *IdempotencyService) Begin(ctx context.Context, userID, key, requestHash string) (*models.IdempotencyRecord, error) {
+	now := time.Now()
+	lock := models.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(idempotencyLockTTL).Unix(),
+	}
+
+	// Retry once in case the existing record expires between claim and read.
+	for attempt := 0; attempt < 2; attempt++ {
+		claimed, err := s.records.ClaimIdempotencyKey(ctx, lock, now.Unix())
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return nil, nil
+		}
+
+		existing, err := s.records.GetIdempotencyRecord(ctx, userID, key, now.Unix())
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			continue
+		}
+		if existing.RequestHash != requestHash {
+			return nil, ErrIdempotencyKeyMismatch
+		}
+		if existing.StatusCode == 0 {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		return existing, nil
+	}
+	return nil, ErrIdempotencyKeyInUse
+}
+
+// Complete stores the response for replay during the retention window.
+func (s *IdempotencyService) Complete(ctx context.Context, userID, key, requestHash string, status int, contentType string, body []byte) error {
+	return s.records.PutIdempotencyRecord(ctx, models.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		StatusCode:  status,
+		ContentType: contentType,
+		Body:        string(body),
+		ExpiresAt:   time.Now().Add(s.ttl).Unix(),
+	})
+}
+
+// Release frees key so that a failed request can be retried.
+func (s *IdempotencyService) Release(ctx context.Context, userID, key string) error {
+	return s.records.DeleteIdempotencyRecord(ctx, userID, key)
+}
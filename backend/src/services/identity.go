@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrUnknownProvider is returned for an identity provider name that is not
+// configured.
+var ErrUnknownProvider = errors.New("unknown identity provider")
+
+// ProviderGitHub is the name of the built-in GitHub provider.
+const ProviderGitHub = "github"
+
+// Identity is a user's profile as reported by an identity provider.
+type Identity struct {
+	Provider  string // provider name, e.g. "github"
+	Subject   string // the provider's stable user ID
+	Login     string
+	Name      string
+	Email     string
+	AvatarURL string
+}
+
+// UserID is the DevVerse user ID for the identity: the subject namespaced by
+// provider, e.g. "gitlab:1234". GitHub IDs stay bare so users created before
+// other providers existed keep their accounts.
+func (i *Identity) UserID() string {
+	if i.Provider == ProviderGitHub {
+		return i.Subject
+	}
+	return i.Provider + ":" + i.Subject
+}
+
+// LinkID identifies the identity across providers, e.g. "github:42". Unlike
+// UserID it is always namespaced.
+func (i *Identity) LinkID() string {
+	return i.Provider + ":" + i.Subject
+}
+
+// IdentityProvider verifies access tokens issued by an external identity
+// provider and reads the profile of the user they belong to.
+type IdentityProvider interface {
+	// Name is the provider's key in /auth/:provider and in user IDs.
+	Name() string
+	// FetchProfile verifies the access token and returns the profile of the
+	// user it belongs to. An invalid or expired token is an error.
+	FetchProfile(ctx context.Context, accessToken string) (*Identity, error)
+	// FetchVerifiedEmail returns the user's primary verified email, or "" if
+	// the provider has none for them.
+	FetchVerifiedEmail(ctx context.Context, accessToken string) (string, error)
+}
+
+// VerifyIdentity resolves an access token to an identity with the named
+// provider. If the profile has no email, the verified email is looked up;
+// failing to find one is not an error.
+func (s *AuthService) VerifyIdentity(ctx context.Context, provider, accessToken string) (*Identity, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	identity, err := p.FetchProfile(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	identity.Provider = p.Name()
+	if identity.Email == "" {
+		if email, err := p.FetchVerifiedEmail(ctx, accessToken); err == nil {
+			identity.Email = email
+		}
+	}
+	return identity, nil
+}
+
+// getJSON sends a GET with the given Authorization header and decodes a 200
+// response into out.
+func getJSON(ctx context.Context, url, authorization string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned status %d: %s", url, resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", url, err)
+	}
+	return nil
+}
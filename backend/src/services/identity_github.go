@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type GitHubUser struct {
+	ID        int    `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type GitHubEmail struct {
+	Email      string `json:"email"`
+	Primary    bool   `json:"primary"`
+	Verified   bool   `json:"verified"`
+	Visibility string `json:"visibility"`
+}
+
+// GitHubProvider verifies github.com or GitHub Enterprise access tokens.
+type GitHubProvider struct {
+	apiBaseURL string // e.g. https://api.github.com or https://ghe.example.com/api/v3
+}
+
+func NewGitHubProvider(apiBaseURL string) *GitHubProvider {
+	return &GitHubProvider{apiBaseURL: strings.TrimRight(apiBaseURL, "/")}
+}
+
+func (p *GitHubProvider) Name() string {
+	return ProviderGitHub
+}
+
+func (p *GitHubProvider) FetchProfile(ctx context.Context, accessToken string) (*Identity, error) {
+	var githubUser GitHubUser
+	if err := getJSON(ctx, p.apiBaseURL+"/user", "token "+accessToken, &githubUser); err != nil {
+		return nil, fmt.Errorf("failed to fetch GitHub user: %w", err)
+	}
+	return &Identity{
+		Subject:   fmt.Sprintf("%d", githubUser.ID),
+		Login:     githubUser.Login,
+		Name:      githubUser.Name,
+		Email:     githubUser.Email, // public email; often empty
+		AvatarURL: githubUser.AvatarURL,
+	}, nil
+}
+
+// FetchVerifiedEmail prefers the primary verified email and falls back to
+// any verified one. It needs the user:email scope.
+func (p *GitHubProvider) FetchVerifiedEmail(ctx context.Context, accessToken string) (string, error) {
+	var emails []GitHubEmail
+	if err := getJSON(ctx, p.apiBaseURL+"/user/emails", "token "+accessToken, &emails); err != nil {
+		return "", fmt.Errorf("failed to fetch GitHub emails: %w", err)
+	}
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			return e.Email, nil
+		}
+	}
+	for _, e := range emails {
+		if e.Verified {
+			return e.Email, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ProviderGitLab is the name of the GitLab provider.
+const ProviderGitLab = "gitlab"
+
+// GitLabProvider verifies access tokens issued by gitlab.com or a
+// self-hosted GitLab instance.
+type GitLabProvider struct {
+	baseURL  string // e.g. https://gitlab.example.com
+	clientID string // if set, tokens must be issued to this OAuth application
+}
+
+func NewGitLabProvider(baseURL, clientID string) *GitLabProvider {
+	return &GitLabProvider{baseURL: strings.TrimRight(baseURL, "/"), clientID: clientID}
+}
+
+func (p *GitLabProvider) Name() string {
+	return ProviderGitLab
+}
+
+func (p *GitLabProvider) FetchProfile(ctx context.Context, accessToken string) (*Identity, error) {
+	auth := "Bearer " + accessToken
+	if p.clientID != "" {
+		var info struct {
+			Application struct {
+				UID string `json:"uid"`
+			} `json:"application"`
+		}
+		if err := getJSON(ctx, p.baseURL+"/oauth/token/info", auth, &info); err != nil {
+			return nil, fmt.Errorf("failed to verify GitLab token: %w", err)
+		}
+		if info.Application.UID != p.clientID {
+			return nil, errors.New("GitLab token was issued to a different application")
+		}
+	}
+
+	var user struct {
+		ID          int    `json:"id"`
+		Username    string `json:"username"`
+		Name        string `json:"name"`
+		PublicEmail string `json:"public_email"`
+		AvatarURL   string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, p.baseURL+"/api/v4/user", auth, &user); err != nil {
+		return nil, fmt.Errorf("failed to fetch GitLab user: %w", err)
+	}
+	return &Identity{
+		Subject:   strconv.Itoa(user.ID),
+		Login:     user.Username,
+		Name:      user.Name,
+		Email:     user.PublicEmail,
+		AvatarURL: user.AvatarURL,
+	}, nil
+}
+
+// FetchVerifiedEmail returns the first confirmed email. It needs the
+// read_user scope.
+func (p *GitLabProvider) FetchVerifiedEmail(ctx context.Context, accessToken string) (string, error) {
+	var emails []struct {
+		Email       string  `json:"email"`
+		ConfirmedAt *string `json:"confirmed_at"`
+	}
+	if err := getJSON(ctx, p.baseURL+"/api/v4/user/emails", "Bearer "+accessToken, &emails); err != nil {
+		return "", fmt.Errorf("failed to fetch GitLab emails: %w", err)
+	}
+	for _, e := range emails {
+		if e.ConfirmedAt != nil {
+			return e.Email, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDCProvider verifies access tokens from a generic OpenID Connect issuer.
+// Each token is introspected (RFC 7662) to check that it is active and was
+// issued to this server's client, then the user is read from the userinfo
+// endpoint. Both endpoints are found through discovery on first use.
+type OIDCProvider struct {
+	name         string
+	issuerURL    string
+	clientID     string
+	clientSecret string
+
+	mu               sync.Mutex
+	userinfoURL      string
+	introspectionURL string
+}
+
+func NewOIDCProvider(name, issuerURL, clientID, clientSecret string) *OIDCProvider {
+	return &OIDCProvider{
+		name:         name,
+		issuerURL:    strings.TrimRight(issuerURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+	}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+type oidcUserInfo struct {
+	Subject           string `json:"sub"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Picture           string `json:"picture"`
+}
+
+// endpoints reads the discovery document once; a failed lookup is retried
+// on the next login.
+func (p *OIDCProvider) endpoints(ctx context.Context) (userinfo, introspection string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.userinfoURL != "" {
+		return p.userinfoURL, p.introspectionURL, nil
+	}
+
+	var discovery struct {
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+		IntrospectionEndpoint string `json:"introspection_endpoint"`
+	}
+	if err := getJSON(ctx, p.issuerURL+"/.well-known/openid-configuration", "", &discovery); err != nil {
+		return "", "", fmt.Errorf("failed to discover OIDC endpoints: %w", err)
+	}
+	if discovery.UserinfoEndpoint == "" {
+		return "", "", errors.New("OIDC issuer has no userinfo_endpoint")
+	}
+	if discovery.IntrospectionEndpoint == "" {
+		return "", "", errors.New("OIDC issuer has no introspection_endpoint")
+	}
+	p.userinfoURL = discovery.UserinfoEndpoint
+	p.introspectionURL = discovery.IntrospectionEndpoint
+	return p.userinfoURL, p.introspectionURL, nil
+}
+
+// oidcAudience is an introspection "aud", which may be a string or a list.
+type oidcAudience []string
+
+func (a *oidcAudience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = oidcAudience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// introspect checks that accessToken is active and was issued to p.clientID,
+// going by client_id or, for issuers that omit it, aud. It returns the
+// token's subject, which may be empty.
+func (p *OIDCProvider) introspect(ctx context.Context, endpoint, accessToken string) (string, error) {
+	form := url.Values{}
+	form.Set("token", accessToken)
+	form.Set("token_type_hint", "access_token")
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create introspection request: %w", err)
+	}
+	req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call OIDC introspection endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("OIDC introspection endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var token struct {
+		Active   bool         `json:"active"`
+		ClientID string       `json:"client_id"`
+		Audience oidcAudience `json:"aud"`
+		Subject  string       `json:"sub"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode introspection response: %w", err)
+	}
+	if !token.Active {
+		return "", errors.New("OIDC token is not active")
+	}
+	if token.ClientID != p.clientID && (token.ClientID != "" || !slices.Contains(token.Audience, p.clientID)) {
+		return "", errors.New("OIDC token was issued to a different client")
+	}
+	return token.Subject, nil
+}
+
+func (p *OIDCProvider) userInfo(ctx context.Context, accessToken string) (*oidcUserInfo, error) {
+	userinfo, introspection, err := p.endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := p.introspect(ctx, introspection, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	var info oidcUserInfo
+	if err := getJSON(ctx, userinfo, "Bearer "+accessToken, &info); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC userinfo: %w", err)
+	}
+	if info.Subject == "" {
+		return nil, errors.New("OIDC userinfo has no sub")
+	}
+	if subject != "" && subject != info.Subject {
+		return nil, errors.New("OIDC userinfo sub does not match the token")
+	}
+	return &info, nil
+}
+
+func (p *OIDCProvider) FetchProfile(ctx context.Context, accessToken string) (*Identity, error) {
+	info, err := p.userInfo(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	identity := &Identity{
+		Subject:   info.Subject,
+		Login:     info.PreferredUsername,
+		Name:      info.Name,
+		AvatarURL: info.Picture,
+	}
+	if info.EmailVerified {
+		identity.Email = info.Email
+	}
+	return identity, nil
+}
+
+// FetchVerifiedEmail returns the userinfo email if the issuer marks it
+// verified.
+func (p *OIDCProvider) FetchVerifiedEmail(ctx context.Context, accessToken string) (string, error) {
+	info, err := p.userInfo(ctx, accessToken)
+	if err != nil {
+		return "", err
+	}
+	if !info.EmailVerified {
+		return "", nil
+	}
+	return info.Email, nil
+}
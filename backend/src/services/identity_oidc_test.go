@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOIDCProviderChecksTokenClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		introspection map[string]interface{}
+		wantErr       bool
+	}{
+		{"issued to client", map[string]interface{}{"active": true, "client_id": "devverse", "sub": "alice"}, false},
+		{"audience only", map[string]interface{}{"active": true, "aud": []string{"api", "devverse"}}, false},
+		{"other client", map[string]interface{}{"active": true, "client_id": "other", "aud": "devverse", "sub": "alice"}, true},
+		{"other audience", map[string]interface{}{"active": true, "aud": "other"}, true},
+		{"inactive", map[string]interface{}{"active": false}, true},
+		{"other subject", map[string]interface{}{"active": true, "client_id": "devverse", "sub": "mallory"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/.well-known/openid-configuration":
+					json.NewEncoder(w).Encode(map[string]string{
+						"userinfo_endpoint":      server.URL + "/userinfo",
+						"introspection_endpoint": server.URL + "/introspect",
+					})
+				case "/introspect":
+					id, secret, ok := r.BasicAuth()
+					if !ok || id != "devverse" || secret != "s3cret" || r.PostFormValue("token") != "token" {
+						http.Error(w, "unauthorized", http.StatusUnauthorized)
+						return
+					}
+					json.NewEncoder(w).Encode(tt.introspection)
+				case "/userinfo":
+					json.NewEncoder(w).Encode(map[string]interface{}{"sub": "alice", "email": "a@example.com", "email_verified": true})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			p := NewOIDCProvider("oidc", server.URL, "devverse", "s3cret")
+			identity, err := p.FetchProfile(context.Background(), "token")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchProfile error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && identity.Subject != "alice" {
+				t.Errorf("Subject = %q, want alice", identity.Subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+// LastSeenTracker records when users were last active. It remembers when it
+// last wrote each user's LastSeenAt and skips the write until interval has
+// passed, so a busy client costs one write per interval rather than one per
+// request. LastSeenAt is therefore accurate to within interval.
+type LastSeenTracker struct {
+	users    repository.UserRepository
+	interval time.Duration
+
+	mu      sync.Mutex
+	written map[string]time.Time
+	pruneAt int
+}
+
+func NewLastSeenTracker(users repository.UserRepository, interval time.Duration) *LastSeenTracker {
+	return &LastSeenTracker{
+		users:    users,
+		interval: interval,
+		written:  make(map[string]time.Time),
+		pruneAt:  1024,
+	}
+}
+
+// Touch marks the user as seen now.
+func (t *LastSeenTracker) Touch(ctx context.Context, userID string) error {
+	now := time.Now()
+	t.mu.Lock()
+	if last, ok := t.written[userID]; ok && now.Sub(last) < t.interval {
+		t.mu.Unlock()
+		return nil
+	}
+	t.written[userID] = now
+	// Forget stale entries now and then so the map stays about the size of
+	// the recently active users.
+	if len(t.written) > t.pruneAt {
+		for id, last := range t.written {
+			if now.Sub(last) >= t.interval {
+				delete(t.written, id)
+			}
+		}
+		t.pruneAt = max(1024, 2*len(t.written))
+	}
+	t.mu.Unlock()
+
+	return t.users.TouchUserLastSeen(ctx, userID, now.Unix())
+}
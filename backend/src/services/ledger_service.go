@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+// LedgerService reads the score ledger, the append-only record of every
+// change to a user's score.
+type LedgerService struct {
+	ledger repository.LedgerRepository
+}
+
+func NewLedgerService(ledger repository.LedgerRepository) *LedgerService {
+	return &LedgerService{ledger: ledger}
+}
+
+// ListEntries returns up to limit of the user's ledger entries, newest
+// first, and the cursor for the next page, or "" on the last page.
+func (s *LedgerService) ListEntries(ctx context.Context, userID string, limit int, cursor string) ([]models.LedgerEntry, string, error) {
+	return s.ledger.ListLedgerEntries(ctx, userID, limit, cursor)
+}
+
+// newLedgerEntry starts the ledger entry for a score change made now by
+// actorID; the repository fills in the user and the delta. EntryIDs sort by
+// creation time, with a random suffix keeping them unique.
+func newLedgerEntry(source, referenceID, actorID string) (models.LedgerEntry, error) {
+	suffix, err := randomToken(6)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+	now := time.Now()
+	return models.LedgerEntry{
+		EntryID:     fmt.Sprintf("%019d-%s", now.UnixNano(), suffix),
+		Source:      source,
+		ReferenceID: referenceID,
+		ActorID:     actorID,
+		CreatedAt:   now.Unix(),
+	}, nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/utils"
+)
+
+var (
+	// ErrInvalidScope is returned when creating a token with a scope that is
+	// not in models.KnownScopes, or with no scopes at all.
+	ErrInvalidScope = errors.New("invalid scope")
+	// ErrInvalidPersonalToken is returned when a personal access token is
+	// unknown or expired.
+	ErrInvalidPersonalToken = errors.New("invalid personal access token")
+)
+
+// lastUsedGranularity bounds how often verifying a token writes LastUsedAt,
+// so a busy CI job doesn't turn every request into a write.
+const lastUsedGranularity = time.Minute
+
+// PersonalTokenService manages long-lived, scoped tokens for scripts and CI.
+// Only a SHA-256 hash of each token is stored; the token itself is returned
+// once, on creation.
+type PersonalTokenService struct {
+	tokens repository.PersonalTokenRepository
+	users  repository.UserRepository
+}
+
+func NewPersonalTokenService(tokens repository.PersonalTokenRepository, users repository.UserRepository) *PersonalTokenService {
+	return &PersonalTokenService{
+		tokens: tokens,
+		users:  users,
+	}
+}
+
+// Create issues a token for the user. A zero ttl means the token never
+// expires.
+func (s *PersonalTokenService) Create(ctx context.Context, userID, name string, scopes []string, ttl time.Duration) (string, *models.PersonalAccessToken, error) {
+	if len(scopes) == 0 {
+		return "", nil, ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(models.KnownScopes, scope) {
+			return "", nil, ErrInvalidScope
+		}
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	id, err := randomToken(9)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := utils.PersonalTokenPrefix + secret
+	now := time.Now()
+	record := models.PersonalAccessToken{
+		TokenHash: hashRefreshToken(token),
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(scopes))),
+		CreatedAt: now.Unix(),
+	}
+	if ttl > 0 {
+		record.ExpiresAt = now.Add(ttl).Unix()
+	}
+	if err := s.tokens.CreatePersonalAccessToken(ctx, record); err != nil {
+		return "", nil, err
+	}
+	return token, &record, nil
+}
+
+// List returns the user's tokens, oldest first.
+func (s *PersonalTokenService) List(ctx context.Context, userID string) ([]models.PersonalAccessToken, error) {
+	tokens, err := s.tokens.ListPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(tokens, func(i, j int) bool { return tokens[i].CreatedAt < tokens[j].CreatedAt })
+	return tokens, nil
+}
+
+// Revoke deletes one of the user's tokens. It reports false if the user has
+// no token with that ID.
+func (s *PersonalTokenService) Revoke(ctx context.Context, userID, id string) (bool, error) {
+	return s.tokens.DeletePersonalAccessToken(ctx, userID, id)
+}
+
+// VerifyPersonalToken implements utils.PersonalTokenVerifier.
+func (s *PersonalTokenService) VerifyPersonalToken(ctx context.Context, token string) (*utils.Principal, error) {
+	hash := hashRefreshToken(token)
+	record, err := s.tokens.GetPersonalAccessToken(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if record == nil || (record.ExpiresAt != 0 && record.ExpiresAt <= now.Unix()) {
+		return nil, ErrInvalidPersonalToken
+	}
+
+	user, err := s.users.GetUser(ctx, record.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Banned {
+		return nil, ErrInvalidPersonalToken
+	}
+
+	if now.Unix()-record.LastUsedAt >= int64(lastUsedGranularity/time.Second) {
+		// Usage tracking is best effort and must not fail the request.
+		_ = s.tokens.TouchPersonalAccessToken(ctx, hash, now.Unix())
+	}
+	return &utils.Principal{
+		UserID:        record.UserID,
+		Scopes:        record.Scopes,
+		PersonalToken: true,
+	}, nil
+}
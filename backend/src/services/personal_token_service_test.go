@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+func TestVerifyPersonalTokenRejects(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	s := NewPersonalTokenService(store, store)
+	for _, user := range []models.User{{ID: "active"}, {ID: "banned"}, {ID: "deleted"}} {
+		if err := store.PutUser(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired := "dvp_expired"
+	err := store.CreatePersonalAccessToken(ctx, models.PersonalAccessToken{
+		TokenHash: hashRefreshToken(expired), ID: "t1", UserID: "active",
+		Scopes: []string{models.ScopeStatsRead}, CreatedAt: 1, ExpiresAt: time.Now().Unix() - 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	banned, _, err := s.Create(ctx, "banned", "ci", []string{models.ScopeStatsRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetUserBanned(ctx, "banned", true); err != nil {
+		t.Fatal(err)
+	}
+	deleted, _, err := s.Create(ctx, "deleted", "ci", []string{models.ScopeStatsRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteUser(ctx, "deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{
+		"expired":       expired,
+		"banned owner":  banned,
+		"deleted owner": deleted,
+		"unknown":       "dvp_unknown",
+	} {
+		if p, err := s.VerifyPersonalToken(ctx, token); !errors.Is(err, ErrInvalidPersonalToken) {
+			t.Errorf("%s: VerifyPersonalToken() = %+v, %v, want ErrInvalidPersonalToken", name, p, err)
+		}
+	}
+
+	valid, _, err := s.Create(ctx, "active", "ci", []string{models.ScopeStatsRead}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := s.VerifyPersonalToken(ctx, valid)
+	if err != nil || p.UserID != "active" || !p.PersonalToken {
+		t.Errorf("VerifyPersonalToken(valid) = %+v, %v", p, err)
+	}
+}
+
+func TestVerifyPersonalTokenThrottlesLastUsed(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	s := NewPersonalTokenService(store, store)
+	if err := store.PutUser(ctx, models.User{ID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := s.Create(ctx, "u1", "ci", []string{models.ScopeStatsRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := hashRefreshToken(token)
+	lastUsed := func() int64 {
+		t.Helper()
+		record, err := store.GetPersonalAccessToken(ctx, hash)
+		if err != nil || record == nil {
+			t.Fatalf("GetPersonalAccessToken = %v, %v", record, err)
+		}
+		return record.LastUsedAt
+	}
+
+	now := time.Now().Unix()
+	tests := []struct {
+		name     string
+		lastUsed int64
+		touched  bool
+	}{
+		{"used within the granularity", now - 10, false},
+		{"used before it", now - 120, true},
+	}
+	for _, tt := range tests {
+		if err := store.TouchPersonalAccessToken(ctx, hash, tt.lastUsed); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.VerifyPersonalToken(ctx, token); err != nil {
+			t.Fatal(err)
+		}
+		got := lastUsed()
+		if touched := got != tt.lastUsed; touched != tt.touched || (touched && got < now) {
+			t.Errorf("%s: LastUsedAt = %d, was %d, want touched %v", tt.name, got, tt.lastUsed, tt.touched)
+		}
+	}
+}
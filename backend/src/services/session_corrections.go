@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// ErrSessionNotFound is returned when correcting a session the user does not
+// have or has already deleted.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrSessionContention is returned when a correction kept losing races with
+// other writes to the same session.
+var ErrSessionContention = errors.New("session is being updated concurrently")
+
+// maxCorrectionAttempts bounds the read-modify-write retries of a correction.
+const maxCorrectionAttempts = 5
+
+// DeleteSession takes the session's points back out of the user's score and
+// the activity and language rollups, and keeps the session as deleted so a
+// replay of its SessionID is still ignored. actorID and reason are recorded
+// on the session. It returns the deleted session.
+func (s *SessionService) DeleteSession(ctx context.Context, userID, sessionID, actorID, reason string) (*models.Session, error) {
+	return s.correctSession(ctx, userID, sessionID, actorID, reason, func(session models.Session, now int64) models.Session {
+		session.DeletedAt = now
+		return session
+	})
+}
+
+// AdjustSession changes the session's points to points, moving the user's
+// score and rollups by the difference. The days and languages the session
+// was credited to keep their shares of the session. actorID and reason are
+// recorded on the session. It returns the adjusted session.
+func (s *SessionService) AdjustSession(ctx context.Context, userID, sessionID string, points int, actorID, reason string) (*models.Session, error) {
+	return s.correctSession(ctx, userID, sessionID, actorID, reason, func(session models.Session, now int64) models.Session {
+		return scaleSession(session, points)
+	})
+}
+
+// correctSession applies correct to the stored session and records the
+// change, retrying if the session changed in between, then rebuilds the
+// user's streak since a day may have lost all its points.
+func (s *SessionService) correctSession(ctx context.Context, userID, sessionID, actorID, reason string, correct func(models.Session, int64) models.Session) (*models.Session, error) {
+	entry, err := newLedgerEntry(models.LedgerSourceCorrection, sessionID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt < maxCorrectionAttempts; attempt++ {
+		old, err := s.sessions.GetSession(ctx, userID, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if old == nil || old.DeletedAt != 0 {
+			return nil, ErrSessionNotFound
+		}
+
+		now := time.Now().Unix()
+		corrected := correct(*old, now)
+		newPoints := corrected.Points
+		if corrected.DeletedAt != 0 {
+			newPoints = 0
+		}
+		corrected.Corrections = append(slices.Clone(old.Corrections), models.SessionCorrection{
+			At:        now,
+			By:        actorID,
+			Reason:    reason,
+			OldPoints: old.Points,
+			NewPoints: newPoints,
+		})
+
+		saved, err := s.sessions.CorrectSession(ctx, *old, corrected, entry)
+		if err != nil {
+			return nil, err
+		}
+		if !saved {
+			continue
+		}
+		if _, err := s.streaks.Rebuild(ctx, userID); err != nil {
+			return &corrected, err
+		}
+		return &corrected, nil
+	}
+	return nil, ErrSessionContention
+}
+
+// scaleSession returns session with its points set to points and its days
+// and languages scaled to match. Each language's new total is split across
+// days the way its old total was. A session that had no points keeps its
+// languages.
+func scaleSession(session models.Session, points int) models.Session {
+	oldPoints, oldDays := session.Points, session.Days
+	session.Points = points
+
+	weights := make([]int64, len(oldDays))
+	for i, day := range oldDays {
+		weights[i] = int64(day.Points)
+	}
+	if len(oldDays) > 0 {
+		session.Days = make([]models.SessionDay, len(oldDays))
+		for i, share := range apportion(points, weights) {
+			session.Days[i] = models.SessionDay{Date: oldDays[i].Date, Points: share, Languages: oldDays[i].Languages}
+		}
+	}
+	if oldPoints <= 0 {
+		return session
+	}
+
+	breakdown := make(map[string]int, len(session.LanguageBreakdown))
+	for i := range session.Days {
+		session.Days[i].Languages = nil
+	}
+	for language, languagePoints := range session.LanguageBreakdown {
+		total := int(int64(languagePoints) * int64(points) / int64(oldPoints))
+		if total <= 0 {
+			continue
+		}
+		breakdown[language] = total
+		if len(oldDays) == 0 {
+			continue
+		}
+		for i, day := range oldDays {
+			weights[i] = int64(day.Languages[language])
+		}
+		for i, share := range apportion(total, weights) {
+			if share <= 0 {
+				continue
+			}
+			if session.Days[i].Languages == nil {
+				session.Days[i].Languages = make(map[string]int)
+			}
+			session.Days[i].Languages[language] = share
+		}
+	}
+	session.LanguageBreakdown = breakdown
+	return session
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// ErrSessionRejected is wrapped by the errors for sessions whose timestamps
+// the SessionPolicy does not accept.
+var ErrSessionRejected = errors.New("session rejected")
+
+// maxSessionDuration caps SessionPolicy.MaxDuration, so a session spans at
+// most a few days.
+const maxSessionDuration = 48 * time.Hour
+
+// SessionPolicy bounds the timestamps of the sessions RecordSession accepts.
+// A zero MaxAge or MaxFuture disables that check; MaxDuration defaults to,
+// and is capped at, maxSessionDuration. Sessions without timestamps cannot
+// be checked, so they are rejected unless AllowUntimed is set.
+type SessionPolicy struct {
+	MaxAge       time.Duration // how long after it ended a session may be recorded
+	MaxFuture    time.Duration // how far ahead of the server's clock a session may end
+	MaxDuration  time.Duration // how long a session may last
+	AllowUntimed bool          // credit sessions without timestamps to today
+}
+
+func (p SessionPolicy) maxDuration() time.Duration {
+	if p.MaxDuration <= 0 || p.MaxDuration > maxSessionDuration {
+		return maxSessionDuration
+	}
+	return p.MaxDuration
+}
+
+// sessionDays checks the session's timestamps against the policy and splits
+// its points and languages between the days it covered in loc. A session
+// without timestamps is rejected unless the policy allows it, in which case
+// it is credited to today, as sessions were before they were split. Only one
+// timestamp set means the session is an instant.
+func (s *SessionService) sessionDays(session models.Session, loc *time.Location, now time.Time) ([]models.SessionDay, error) {
+	if session.StartedAt == 0 && session.EndedAt == 0 {
+		if !s.policy.AllowUntimed {
+			return nil, fmt.Errorf("%w: startedAt or endedAt is required", ErrSessionRejected)
+		}
+		return []models.SessionDay{{
+			Date:      localDate(now, loc),
+			Points:    session.Points,
+			Languages: session.LanguageBreakdown,
+		}}, nil
+	}
+
+	startedAt, endedAt := session.StartedAt, session.EndedAt
+	if startedAt == 0 {
+		startedAt = endedAt
+	}
+	if endedAt == 0 {
+		endedAt = startedAt
+	}
+	if startedAt < 0 || endedAt < startedAt {
+		return nil, fmt.Errorf("%w: endedAt is before startedAt", ErrSessionRejected)
+	}
+	start, end := time.Unix(startedAt, 0), time.Unix(endedAt, 0)
+	if limit := s.policy.maxDuration(); end.Sub(start) > limit {
+		return nil, fmt.Errorf("%w: session lasts longer than %s", ErrSessionRejected, limit)
+	}
+	if s.policy.MaxFuture > 0 && end.After(now.Add(s.policy.MaxFuture)) {
+		return nil, fmt.Errorf("%w: session ends in the future", ErrSessionRejected)
+	}
+	if s.policy.MaxAge > 0 && end.Before(now.Add(-s.policy.MaxAge)) {
+		return nil, fmt.Errorf("%w: session ended more than %s ago", ErrSessionRejected, s.policy.MaxAge)
+	}
+	return splitSession(start, end, loc, session.Points, session.LanguageBreakdown), nil
+}
+
+// splitSession divides points and each language's points between the days in
+// loc that start..end covers, in proportion to the seconds spent on each.
+// Days left without points are dropped unless every day is.
+func splitSession(start, end time.Time, loc *time.Location, points int, languages map[string]int) []models.SessionDay {
+	var dates []string
+	var seconds []int64
+	for from := start.In(loc); ; {
+		midnight := nextDayStart(from, loc)
+		to := end
+		if midnight.Before(end) {
+			to = midnight
+		}
+		dates = append(dates, from.Format("2006-01-02"))
+		seconds = append(seconds, int64(to.Sub(from)/time.Second))
+		if !midnight.Before(end) {
+			break
+		}
+		from = midnight
+	}
+	if start.Equal(end) {
+		seconds[0] = 1
+	}
+
+	days := make([]models.SessionDay, len(dates))
+	for i, share := range apportion(points, seconds) {
+		days[i] = models.SessionDay{Date: dates[i], Points: share}
+	}
+	for language, languagePoints := range languages {
+		for i, share := range apportion(languagePoints, seconds) {
+			if share <= 0 {
+				continue
+			}
+			if days[i].Languages == nil {
+				days[i].Languages = make(map[string]int)
+			}
+			days[i].Languages[language] = share
+		}
+	}
+
+	kept := make([]models.SessionDay, 0, len(days))
+	for _, day := range days {
+		if day.Points > 0 || len(day.Languages) > 0 {
+			kept = append(kept, day)
+		}
+	}
+	if len(kept) == 0 {
+		return days[:1]
+	}
+	return kept
+}
+
+// nextDayStart returns the first instant of the day after from's in loc.
+// Where DST starts at midnight that day has no 00:00 and time.Date returns
+// the hour before it, still on from's day, so the result is moved forward
+// until it falls on the next day. Offsets are multiples of 15 minutes.
+func nextDayStart(from time.Time, loc *time.Location) time.Time {
+	y, m, d := from.In(loc).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	next := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	for {
+		ny, nm, nd := next.In(loc).Date()
+		if time.Date(ny, nm, nd, 0, 0, 0, 0, time.UTC).After(day) {
+			return next
+		}
+		next = next.Add(15 * time.Minute)
+	}
+}
+
+// apportion splits total in proportion to weights so that the parts add up
+// to total, giving the units left over by rounding down to the largest
+// remainders, earliest first on ties.
+func apportion(total int, weights []int64) []int {
+	var sum int64
+	for _, w := range weights {
+		sum += w
+	}
+	parts := make([]int, len(weights))
+	if sum <= 0 {
+		parts[0] = total
+		return parts
+	}
+
+	remainders := make([]int64, len(weights))
+	assigned := 0
+	for i, w := range weights {
+		share := int64(total) * w
+		parts[i] = int(share / sum)
+		remainders[i] = share % sum
+		assigned += parts[i]
+	}
+	for ; assigned < total; assigned++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		parts[best]++
+		remainders[best] = -1
+	}
+	return parts
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+func TestSplitSessionAcrossDSTAtMidnight(t *testing.T) {
+	// In both zones the clocks jump from 24:00 straight to 01:00, so the
+	// later day has no midnight.
+	tests := []struct {
+		zone       string
+		start, end string // RFC 3339
+		want       []models.SessionDay
+	}{
+		{
+			zone:  "America/Santiago",
+			start: "2024-09-07T22:00:00-04:00",
+			end:   "2024-09-08T02:00:00-03:00",
+			want: []models.SessionDay{
+				{Date: "2024-09-07", Points: 200, Languages: map[string]int{"go": 20}},
+				{Date: "2024-09-08", Points: 100, Languages: map[string]int{"go": 10}},
+			},
+		},
+		{
+			zone:  "America/Havana",
+			start: "2024-03-09T23:00:00-05:00",
+			end:   "2024-03-10T03:00:00-04:00",
+			want: []models.SessionDay{
+				{Date: "2024-03-09", Points: 100, Languages: map[string]int{"go": 10}},
+				{Date: "2024-03-10", Points: 200, Languages: map[string]int{"go": 20}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zone, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.zone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start, _ := time.Parse(time.RFC3339, tt.start)
+			end, _ := time.Parse(time.RFC3339, tt.end)
+
+			done := make(chan []models.SessionDay, 1)
+			go func() { done <- splitSession(start, end, loc, 300, map[string]int{"go": 30}) }()
+			select {
+			case got := <-done:
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("splitSession() = %+v, want %+v", got, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("splitSession did not return")
+			}
+		})
+	}
+}
+
+func TestNextDayStart(t *testing.T) {
+	tests := []struct {
+		zone, from, want string
+	}{
+		{"UTC", "2024-09-07T10:00:00Z", "2024-09-08T00:00:00Z"},
+		{"America/Santiago", "2024-09-07T22:00:00-04:00", "2024-09-08T01:00:00-03:00"},
+		{"America/Santiago", "2024-04-06T22:00:00-03:00", "2024-04-07T00:00:00-04:00"},
+		{"America/Havana", "2024-03-09T12:00:00-05:00", "2024-03-10T01:00:00-04:00"},
+	}
+	for _, tt := range tests {
+		loc, err := time.LoadLocation(tt.zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from, _ := time.Parse(time.RFC3339, tt.from)
+		want, _ := time.Parse(time.RFC3339, tt.want)
+		if got := nextDayStart(from, loc); !got.Equal(want) {
+			t.Errorf("nextDayStart(%s in %s) = %s, want %s", tt.from, tt.zone, got.In(loc).Format(time.RFC3339), tt.want)
+		}
+	}
+}
+
+func TestSessionDaysWithoutTimestamps(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	session := models.Session{Points: 10, LanguageBreakdown: map[string]int{"go": 10}}
+
+	strict := &SessionService{policy: SessionPolicy{}}
+	if _, err := strict.sessionDays(session, time.UTC, now); !errors.Is(err, ErrSessionRejected) {
+		t.Errorf("sessionDays() error = %v, want ErrSessionRejected", err)
+	}
+
+	lenient := &SessionService{policy: SessionPolicy{AllowUntimed: true}}
+	days, err := lenient.sessionDays(session, time.UTC, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.SessionDay{{Date: "2024-05-01", Points: 10, Languages: map[string]int{"go": 10}}}
+	if !reflect.DeepEqual(days, want) {
+		t.Errorf("sessionDays() = %+v, want %+v", days, want)
+	}
+}
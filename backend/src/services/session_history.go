@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+// ErrInvalidSessionQuery is returned for a session history query with an
+// unknown sort or a malformed date.
+var ErrInvalidSessionQuery = errors.New("invalid session query")
+
+// sessionSortFields are the fields ListSessions sorts by. A leading "-" in
+// SessionQuery.Sort sorts in descending order.
+var sessionSortFields = map[string]bool{
+	repository.SessionSortEndedAt:   true,
+	repository.SessionSortStartedAt: true,
+	repository.SessionSortPoints:    true,
+}
+
+// SessionQuery selects and orders a page of a user's sessions.
+type SessionQuery struct {
+	From     string // "YYYY-MM-DD"; only sessions with points on a day from From to To
+	To       string
+	Language string // only sessions with points in this language
+	Sort     string // "endedAt", "startedAt" or "points", optionally prefixed with "-"; default "-endedAt"
+	Limit    int
+	Next     string // cursor returned with the previous page
+	Deleted  bool   // include deleted sessions
+}
+
+// ListSessions returns one page of the user's sessions matching query and
+// the cursor for the next page, or "" on the last page. Pages stay stable
+// while new sessions arrive. Sessions recorded before they were dated are
+// placed on the UTC day they ended.
+func (s *SessionService) ListSessions(ctx context.Context, userID string, query SessionQuery) ([]models.Session, string, error) {
+	field, descending := strings.CutPrefix(query.Sort, "-")
+	if query.Sort == "" {
+		field, descending = repository.SessionSortEndedAt, true
+	}
+	if !sessionSortFields[field] {
+		return nil, "", ErrInvalidSessionQuery
+	}
+	for _, date := range []string{query.From, query.To} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			return nil, "", ErrInvalidSessionQuery
+		}
+	}
+
+	return s.sessions.ListSessions(ctx, userID, repository.SessionQuery{
+		From:       query.From,
+		To:         query.To,
+		Language:   strings.ToLower(strings.TrimSpace(query.Language)),
+		SortBy:     field,
+		Descending: descending,
+		Deleted:    query.Deleted,
+		Limit:      query.Limit,
+		Cursor:     query.Next,
+	})
+}
+
+// sessionPageSize is how many sessions eachSession reads at a time.
+const sessionPageSize = 500
+
+// eachSession calls fn for every session of the user that is not deleted, in
+// the order they ended, reading them a page at a time. It stops at the first
+// error fn returns.
+func eachSession(ctx context.Context, sessions repository.SessionRepository, userID string, fn func(models.Session) error) error {
+	query := repository.SessionQuery{SortBy: repository.SessionSortEndedAt, Limit: sessionPageSize}
+	for {
+		page, next, err := sessions.ListSessions(ctx, userID, query)
+		if err != nil {
+			return err
+		}
+		for _, session := range page {
+			if err := fn(session); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		query.Cursor = next
+	}
+}
+
+// GetSession returns one of the user's sessions, deleted ones included, or
+// nil if it does not exist.
+func (s *SessionService) GetSession(ctx context.Context, userID, sessionID string) (*models.Session, error) {
+	return s.sessions.GetSession(ctx, userID, sessionID)
+}
@@ -3,135 +3,203 @@ package services
 import (
 	"context"
 	"errors"
-	"fmt"
+	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
 )
 
 // SessionService handles all reads and writes for the Sessions and DailyActivity tables.
 type SessionService struct {
-	dynamoClient      *dynamodb.Client
-	sessionsTable     string
-	dailyActivityTable string
+	users    repository.UserRepository
+	sessions repository.SessionRepository
+	activity repository.ActivityRepository
+	streaks  *StreakService
+	policy   SessionPolicy
 }
 
-func NewSessionService(dynamoClient *dynamodb.Client, sessionsTable, dailyActivityTable string) *SessionService {
+func NewSessionService(users repository.UserRepository, sessions repository.SessionRepository, activity repository.ActivityRepository, streaks repository.StreakRepository, policy SessionPolicy) *SessionService {
 	return &SessionService{
-		dynamoClient:       dynamoClient,
-		sessionsTable:      sessionsTable,
-		dailyActivityTable: dailyActivityTable,
+		users:    users,
+		sessions: sessions,
+		activity: activity,
+		streaks:  NewStreakService(streaks, activity),
+		policy:   policy,
 	}
 }
 
-func (s *SessionService) RecordSession(ctx context.Context, session models.Session) error {
-	sessionMap, err := attributevalue.MarshalMap(session)
+// location returns the timezone the user's days are counted in.
+func (s *SessionService) location(ctx context.Context, userID string) (*time.Location, error) {
+	user, err := s.users.GetUser(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("failed to marshal session: %w", err)
+		return nil, err
 	}
-	_, err = s.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(s.sessionsTable),
-		Item: sessionMap,
-		ConditionExpression: aws.String("attribute_not_exists(SessionID)"),
-	})
-	if err != nil {
-		var ccf *types.ConditionalCheckFailedException
-		if errors.As(err, &ccf) {
-			return nil
+	return userLocation(user), nil
+}
+
+// ErrTooManyLanguages is returned for a session whose language breakdown
+// names more than maxSessionLanguages languages.
+var ErrTooManyLanguages = errors.New("too many languages in session")
+
+// maxSessionLanguages bounds the LanguageActivity rows one session writes.
+// With maxSessionDuration it keeps a session within one DynamoDB transaction.
+const maxSessionLanguages = 16
+
+// normalizeLanguages lower-cases and trims language IDs, merging keys that
+// differ only in case, and drops languages without positive points.
+func normalizeLanguages(breakdown map[string]int) (map[string]int, error) {
+	if len(breakdown) == 0 {
+		return breakdown, nil
+	}
+	normalized := make(map[string]int, len(breakdown))
+	for language, points := range breakdown {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if language == "" || points <= 0 {
+			continue
 		}
-		return err
-	}
-	date := time.Now().UTC().Format("2006-01-02")
-	_, err = s.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName: aws.String(s.dailyActivityTable),
-		Key: map[string]types.AttributeValue{
-			"UserID": &types.AttributeValueMemberS{Value: session.UserID},
-			"Date":   &types.AttributeValueMemberS{Value: date},
-		},
-		UpdateExpression: aws.String("ADD #sessionCount :one"),
-		ExpressionAttributeNames: map[string]string{
-			"#sessionCount": "SessionCount",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":one": &types.AttributeValueMemberN{Value: "1"},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to update daily activity: %w", err)
+		normalized[language] += points
+	}
+	if len(normalized) > maxSessionLanguages {
+		return nil, ErrTooManyLanguages
 	}
-	return nil
+	return normalized, nil
 }
 
-func (s *SessionService) GetStreak(ctx context.Context, userID string) (int, error) {
-	result, err := s.dynamoClient.Query(ctx, &dynamodb.QueryInput{
-		TableName: aws.String(s.dailyActivityTable),
-		KeyConditionExpression: aws.String("UserID = :uid"),
-		ScanIndexForward: aws.Bool(false),
-		Limit: aws.Int32(365),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":uid": &types.AttributeValueMemberS{Value: userID},
-		},
-	})
+// RecordSession stores the session and credits its points to the user's score
+// and to the activity and language rollups of the days it covered in the
+// user's timezone (see sessionDays) as a single atomic write, recording
+// actorID as the uploader in the score ledger, then extends their streak. A
+// session the policy rejects returns an error wrapping ErrSessionRejected. A
+// replayed SessionID changes nothing; the returned bool is false in that
+// case.
+func (s *SessionService) RecordSession(ctx context.Context, session models.Session, actorID string) (bool, error) {
+	breakdown, err := normalizeLanguages(session.LanguageBreakdown)
 	if err != nil {
-		return 0, fmt.Errorf("failed to query daily activity: %w", err)
+		return false, err
 	}
-	var dailyActivities []models.DailyActivity
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &dailyActivities)
+	session.LanguageBreakdown = breakdown
+	session.DeletedAt, session.Corrections = 0, nil
+
+	loc, err := s.location(ctx, session.UserID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to unmarshal daily activities: %w", err)
+		return false, err
 	}
-	streak := 0
-	pointsMap := make(map[string]bool)
-	for _, activity := range dailyActivities {
-		if activity.Points > 0 {
-			pointsMap[activity.Date] = true
+	days, err := s.sessionDays(session, loc, time.Now())
+	if err != nil {
+		return false, err
+	}
+	session.Days, session.Date = days, days[0].Date
+
+	entry, err := newLedgerEntry(models.LedgerSourceSession, session.SessionID, actorID)
+	if err != nil {
+		return false, err
+	}
+	recorded, err := s.sessions.RecordSession(ctx, session, entry)
+	if err != nil {
+		return false, err
+	}
+	// The streak is updated on replays too, so retrying a session whose
+	// streak update failed repairs it, from the days the stored session was
+	// credited to.
+	if !recorded {
+		stored, err := s.sessions.GetSession(ctx, session.UserID, session.SessionID)
+		if err != nil {
+			return false, err
 		}
+		if stored == nil || stored.DeletedAt != 0 {
+			return false, nil
+		}
+		days = stored.Days
 	}
-	check := time.Now().UTC().Format("2006-01-02")
-	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
-	if !pointsMap[check] {
-		check = yesterday
+	for _, day := range days {
+		if day.Points <= 0 {
+			continue
+		}
+		if err := s.streaks.RecordDay(ctx, session.UserID, day.Date); err != nil {
+			return recorded, err
+		}
 	}
-	for check != "" && pointsMap[check] {
-		streak++
-		t, _ := time.Parse("2006-01-02", check)
-		check = t.AddDate(0, 0, -1).Format("2006-01-02")
+	return recorded, nil
+}
+
+// GetStreak returns the user's persisted streak; see StreakService.GetStreak.
+func (s *SessionService) GetStreak(ctx context.Context, userID string) (*models.Streak, error) {
+	loc, err := s.location(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
-	return streak, nil
+	return s.streaks.GetStreak(ctx, userID, loc)
 }
 
-func (s *SessionService) GetActivity(ctx context.Context, userID string, days int) ([]models.DailyActivity, error) {
-	startDate := time.Now().UTC().AddDate(0, 0, -(days-1)).Format("2006-01-02")
-	result, err := s.dynamoClient.Query(ctx, &dynamodb.QueryInput{
-		TableName: aws.String(s.dailyActivityTable),
-		KeyConditionExpression: aws.String("UserID = :uid AND #date >= :start"),
-		ExpressionAttributeNames: map[string]string{
-			"#date": "Date",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":uid":   &types.AttributeValueMemberS{Value: userID},
-			":start": &types.AttributeValueMemberS{Value: startDate},
-		},
-		ScanIndexForward: aws.Bool(true),
+// RebucketSessions moves the points and language rollups of the user's
+// sessions recorded before sessions were dated, when days were counted in
+// UTC, from the UTC day each session ended to that day in the user's
+// timezone, and dates the sessions so a rerun leaves them alone. Deleted
+// sessions are left alone too. Sessions without an end time, or whose UTC
+// day no longer holds their points, are skipped. Points added through
+// AddUserScore before then record no time of day and stay on their UTC day.
+// It returns how many sessions changed day and how many were skipped.
+func (s *SessionService) RebucketSessions(ctx context.Context, userID string) (int, int, error) {
+	loc, err := s.location(ctx, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	moved, skipped := 0, 0
+	err = eachSession(ctx, s.sessions, userID, func(session models.Session) error {
+		if session.Date != "" {
+			return nil
+		}
+		if session.EndedAt <= 0 {
+			skipped++
+			return nil
+		}
+		ended := time.Unix(session.EndedAt, 0)
+		from, to := localDate(ended, time.UTC), localDate(ended, loc)
+		redated, err := s.sessions.RedateSession(ctx, session, from, to)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !redated:
+			skipped++
+		case from != to:
+			moved++
+		}
+		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to query daily activity: %w", err)
+		return moved, skipped, err
+	}
+
+	if moved > 0 {
+		if _, err := s.streaks.Rebuild(ctx, userID); err != nil {
+			return moved, skipped, err
+		}
+	}
+	return moved, skipped, nil
+}
+
+// GetActivity returns the user's activity for each of the last days days in
+// their timezone, oldest first.
+func (s *SessionService) GetActivity(ctx context.Context, userID string, days int) ([]models.DailyActivity, error) {
+	loc, err := s.location(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
-	var dailyActivities []models.DailyActivity
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &dailyActivities)
+	now := time.Now().In(loc)
+	startDate := now.AddDate(0, 0, -(days-1)).Format("2006-01-02")
+	dailyActivities, err := s.activity.ListActivitySince(ctx, userID, startDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal daily activities: %w", err)
+		return nil, err
 	}
 	activityMap := make(map[string]models.DailyActivity)
 	for _, activity := range dailyActivities {
 		activityMap[activity.Date] = activity
 	}
 	startTime, _ := time.Parse("2006-01-02", startDate)
-	endTime, _ := time.Parse("2006-01-02", time.Now().UTC().Format("2006-01-02"))
+	endTime, _ := time.Parse("2006-01-02", now.Format("2006-01-02"))
 	for t := startTime; !t.After(endTime); t = t.AddDate(0, 0, 1) {
 		if _, exists := activityMap[t.Format("2006-01-02")]; !exists {
 			activityMap[t.Format("2006-01-02")] = models.DailyActivity{
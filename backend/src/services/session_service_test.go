@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+func TestRebucketSessionsReadsEverySession(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	if err := store.PutUser(ctx, models.User{ID: "u1", Timezone: "Asia/Tokyo"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Undated sessions from before days were counted in the user's
+	// timezone, more than fit in one page. Each ends at 20:00 UTC, which is
+	// already the next day in Tokyo.
+	const count = 2*sessionPageSize + 7
+	start := time.Date(2023, 1, 1, 20, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		ended := start.AddDate(0, 0, i)
+		session := models.Session{UserID: "u1", SessionID: fmt.Sprintf("s%04d", i), StartedAt: ended.Unix() - 60, EndedAt: ended.Unix(), Points: 1}
+		if _, err := store.RecordSession(ctx, session, models.LedgerEntry{EntryID: session.SessionID}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.AddDailyActivity(ctx, "u1", ended.Format("2006-01-02"), 1, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := NewSessionService(store, store, store, store, SessionPolicy{})
+	moved, skipped, err := service.RebucketSessions(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != count || skipped != 0 {
+		t.Errorf("RebucketSessions() = %d moved, %d skipped, want %d moved, 0 skipped", moved, skipped, count)
+	}
+
+	// A rerun leaves the now dated sessions alone.
+	moved, skipped, err = service.RebucketSessions(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 0 || skipped != 0 {
+		t.Errorf("second RebucketSessions() = %d moved, %d skipped, want 0, 0", moved, skipped)
+	}
+}
+
+func TestStreakRebuildFromHistory(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	for _, row := range []models.DailyActivity{
+		{Date: "2024-01-03", Points: 1},
+		{Date: "2024-01-01", Points: 4},
+		{Date: "2024-01-02", Points: 2},
+		{Date: "2024-01-05", Points: 3},
+		{Date: "2024-01-06", Points: 5},
+		{Date: "2024-01-07", Points: 0},
+	} {
+		if err := store.AddDailyActivity(ctx, "u1", row.Date, row.Points, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	streaks := NewStreakService(store, store)
+	want := models.Streak{
+		UserID:       "u1",
+		CurrentStart: "2024-01-05", CurrentEnd: "2024-01-06", CurrentLength: 2,
+		LongestStart: "2024-01-01", LongestEnd: "2024-01-03", LongestLength: 3,
+	}
+	same := func(got models.Streak) bool {
+		got.UpdatedAt, got.Version = 0, 0
+		return got == want
+	}
+
+	// The backfill is a rebuild per user and is safe to rerun.
+	for run := 1; run <= 2; run++ {
+		streak, err := streaks.Rebuild(ctx, "u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !same(*streak) || streak.Version != run {
+			t.Errorf("rebuild %d = %+v, want %+v at version %d", run, *streak, want, run)
+		}
+		stored, err := store.GetStreak(ctx, "u1")
+		if err != nil || stored == nil || !same(*stored) {
+			t.Errorf("stored streak after rebuild %d = %+v, %v", run, stored, err)
+		}
+	}
+}
+
+func TestStreakRecordDay(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	streaks := NewStreakService(store, store)
+	record := func(date string) *models.Streak {
+		t.Helper()
+		if err := store.AddDailyActivity(ctx, "u1", date, 1, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := streaks.RecordDay(ctx, "u1", date); err != nil {
+			t.Fatal(err)
+		}
+		streak, err := store.GetStreak(ctx, "u1")
+		if err != nil || streak == nil {
+			t.Fatalf("GetStreak = %v, %v", streak, err)
+		}
+		return streak
+	}
+
+	tests := []struct {
+		date                   string
+		currentStart, longest  string
+		current, longestLength int
+	}{
+		{"2024-01-01", "2024-01-01", "2024-01-01", 1, 1},
+		{"2024-01-02", "2024-01-01", "2024-01-01", 2, 2},
+		{"2024-01-02", "2024-01-01", "2024-01-01", 2, 2},
+		{"2024-01-05", "2024-01-05", "2024-01-01", 1, 2},
+		{"2024-01-06", "2024-01-05", "2024-01-01", 2, 2},
+		{"2024-01-07", "2024-01-05", "2024-01-05", 3, 3},
+		// A late day before the current run rebuilds and joins the runs.
+		{"2024-01-04", "2024-01-04", "2024-01-04", 4, 4},
+		{"2024-01-03", "2024-01-01", "2024-01-01", 7, 7},
+	}
+	for _, tt := range tests {
+		streak := record(tt.date)
+		if streak.CurrentStart != tt.currentStart || streak.CurrentLength != tt.current ||
+			streak.LongestStart != tt.longest || streak.LongestLength != tt.longestLength {
+			t.Errorf("after %s: streak = %+v", tt.date, *streak)
+		}
+	}
+}
@@ -2,128 +2,421 @@ package services
 
 import (
 	"context"
-	"math"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
 )
 
 type StatsService struct {
-	dynamoClient *dynamodb.Client
-	table        string
+	users       repository.UserRepository
+	leaderboard repository.LeaderboardRepository
+	sessions    repository.SessionRepository
+	activity    repository.ActivityRepository
+	languages   repository.LanguageRepository
+	streaks     *StreakService
 }
 
-func NewStatsService(dynamoClient *dynamodb.Client, tableName string) *StatsService {
+func NewStatsService(users repository.UserRepository, leaderboard repository.LeaderboardRepository, sessions repository.SessionRepository, activity repository.ActivityRepository, languages repository.LanguageRepository, streaks repository.StreakRepository) *StatsService {
 	return &StatsService{
-		dynamoClient: dynamoClient,
-		table:        tableName,
+		users:       users,
+		leaderboard: leaderboard,
+		sessions:    sessions,
+		activity:    activity,
+		languages:   languages,
+		streaks:     NewStreakService(streaks, activity),
 	}
 }
 
-// GetUserStats returns aggregated stats for a single user
+// GetUserStats returns aggregated stats for a single user, computed from the
+// DailyActivity and Sessions tables. Edits are points earned; days are
+// counted in the user's timezone.
 func (s *StatsService) GetUserStats(ctx context.Context, userID string) (*models.UserStats, error) {
-	// For now, just fetch the user. In production, you'd calculate edits today/week from a separate activity log
-	result, err := s.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
-		TableName: &s.table,
-		Key: map[string]types.AttributeValue{
-			"ID": &types.AttributeValueMemberS{Value: userID},
-		},
-	})
+	user, err := s.users.GetUser(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	if result.Item == nil {
+	if user == nil {
 		return nil, nil
 	}
 
-	var user models.User
-	err = attributevalue.UnmarshalMap(result.Item, &user)
+	days, err := s.activity.ListActivitySince(ctx, userID, "")
 	if err != nil {
 		return nil, err
 	}
+	loc := userLocation(user)
+	now := time.Now().In(loc)
+	today := now.Format("2006-01-02")
+	weekStart := now.AddDate(0, 0, -6).Format("2006-01-02")
 
-	// Calculate simple metrics (in production use a separate activity log table)
 	stats := &models.UserStats{
-		ID:            user.ID,
-		Name:          user.Name,
-		Email:         user.Email,
-		Score:         user.Score,
-		EditsToday:    int(math.Ceil(float64(user.Score) * 0.15)),     // Estimate
-		EditsThisWeek: int(math.Ceil(float64(user.Score) * 0.35)),     // Estimate
-		CurrentStreak: int(math.Ceil(float64(user.Score) / 100.0)),    // Estimate
-		LongestStreak: int(math.Ceil(float64(user.Score) / 80.0)),     // Estimate
-		LastActivityAt: time.Now().Format(time.RFC3339),
+		ID:    user.ID,
+		Name:  user.Name,
+		Email: user.Email,
+		Score: user.Score,
+	}
+	streak, err := s.streaks.GetStreak(ctx, userID, loc)
+	if err != nil {
+		return nil, err
 	}
+	stats.CurrentStreak, stats.LongestStreak = streak.CurrentLength, streak.LongestLength
 
-	return stats, nil
-}
+	var lastActivity time.Time
+	for _, day := range days {
+		if day.Date == today {
+			stats.EditsToday += day.Points
+		}
+		if day.Date >= weekStart {
+			stats.EditsThisWeek += day.Points
+		}
+		if t, err := time.Parse("2006-01-02", day.Date); err == nil && day.Points > 0 && t.After(lastActivity) {
+			lastActivity = t
+		}
+	}
 
-// GetLeaderboard returns top users this week
-func (s *StatsService) GetLeaderboard(ctx context.Context, limit int) ([]models.LeaderboardEntry, error) {
-	// Scan all users and sort by score
-	result, err := s.dynamoClient.Scan(ctx, &dynamodb.ScanInput{
-		TableName: &s.table,
+	var seconds int64
+	languages := make(map[string]int)
+	err = eachSession(ctx, s.sessions, userID, func(session models.Session) error {
+		stats.TotalSessions++
+		if session.EndedAt > session.StartedAt && session.StartedAt > 0 {
+			seconds += session.EndedAt - session.StartedAt
+		}
+		if ended := time.Unix(session.EndedAt, 0).UTC(); session.EndedAt > 0 && ended.After(lastActivity) {
+			lastActivity = ended
+		}
+		for language, points := range session.LanguageBreakdown {
+			languages[language] += points
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	stats.TotalMinutes = int(seconds / 60)
+	stats.TopLanguage = topLanguage(languages)
+	if !lastActivity.IsZero() {
+		stats.LastActivityAt = lastActivity.Format(time.RFC3339)
+	}
+
+	return stats, nil
+}
+
+// topLanguage returns the language with the most points, breaking ties by
+// name, or "" if there are none.
+func topLanguage(languages map[string]int) string {
+	top, best := "", 0
+	for language, points := range languages {
+		if points > best || (points == best && points > 0 && language < top) {
+			top, best = language, points
+		}
+	}
+	return top
+}
+
+// ErrInvalidWindow is returned for a leaderboard window that is unknown,
+// malformed or longer than maxWindowDays.
+var ErrInvalidWindow = errors.New("invalid leaderboard window")
+
+// maxWindowDays bounds a custom leaderboard date range.
+const maxWindowDays = 366
+
+// LeaderboardWindow limits a leaderboard to points earned from From to To
+// inclusive ("YYYY-MM-DD"). The dates select activity rows by label, and each
+// user's rows are dated in that user's timezone, so a window covers every
+// user's own calendar days rather than one span of UTC time. The zero value
+// ranks by lifetime score.
+type LeaderboardWindow struct {
+	From string
+	To   string
+}
+
+// AllTime reports whether w ranks by lifetime score.
+func (w LeaderboardWindow) AllTime() bool {
+	return w.From == ""
+}
+
+// NewLeaderboardWindow resolves a named window, where "day" is today, "week"
+// the last 7 days, "month" the last 30 days and "all" or "" all time, or, if
+// from is set, the range from..to with to defaulting to today. Today is now's
+// date in now's location, so passing the viewer's local time lines the window
+// up with the local days that their activity rows are dated by.
+func NewLeaderboardWindow(name, from, to string, now time.Time) (LeaderboardWindow, error) {
+	today := now.Format("2006-01-02")
+	if from == "" && to == "" {
+		days := map[string]int{"day": 1, "week": 7, "month": 30}
+		switch {
+		case name == "" || name == "all":
+			return LeaderboardWindow{}, nil
+		case days[name] > 0:
+			return LeaderboardWindow{From: now.AddDate(0, 0, 1-days[name]).Format("2006-01-02"), To: today}, nil
+		}
+		return LeaderboardWindow{}, ErrInvalidWindow
+	}
+	if name != "" || from == "" {
+		return LeaderboardWindow{}, ErrInvalidWindow
+	}
+	if to == "" {
+		to = today
+	}
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return LeaderboardWindow{}, ErrInvalidWindow
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil || end.Before(start) || end.Sub(start) >= maxWindowDays*24*time.Hour {
+		return LeaderboardWindow{}, ErrInvalidWindow
+	}
+	return LeaderboardWindow{From: from, To: to}, nil
+}
+
+// GetLeaderboard returns one page of users ranked by lifetime score or, for
+// a windowed or single-language leaderboard, by points earned in the window
+// and language (reported as the entry's score), starting at cursor if set and
+// otherwise at offset, plus the cursor for the next page.
+func (s *StatsService) GetLeaderboard(ctx context.Context, window LeaderboardWindow, language string, offset, limit int, cursor string) ([]models.LeaderboardEntry, string, error) {
+	if !window.AllTime() || language != "" {
+		return s.getWindowLeaderboard(ctx, window, language, offset, limit, cursor)
+	}
+
+	page, err := s.leaderboard.ListTopUsers(ctx, offset, limit, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	streaks, err := s.streaks.CurrentStreaks(ctx, page.Users)
+	if err != nil {
+		return nil, "", err
+	}
+
+	leaderboard := make([]models.LeaderboardEntry, len(page.Users))
+	for i, user := range page.Users {
+		leaderboard[i] = models.LeaderboardEntry{
+			Rank:   page.Offset + i + 1,
+			ID:     user.ID,
+			Name:   user.Name,
+			Email:  user.Email,
+			Score:  user.Score,
+			Streak: streaks[user.ID],
+		}
+	}
+
+	return leaderboard, page.Next, nil
+}
+
+// getWindowLeaderboard pages through the window's point totals. Totals are
+// recomputed on every request, so the cursor only records a position. Users
+// deleted since earning points are left out.
+func (s *StatsService) getWindowLeaderboard(ctx context.Context, window LeaderboardWindow, language string, offset, limit int, cursor string) ([]models.LeaderboardEntry, string, error) {
+	if cursor != "" {
+		position, err := decodeWindowCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		offset = position + 1
+	}
+
+	totals, err := s.pointTotals(ctx, window, language)
+	if err != nil {
+		return nil, "", err
+	}
+	if offset >= len(totals) {
+		return []models.LeaderboardEntry{}, "", nil
+	}
+	end := offset + limit
+	if end > len(totals) {
+		end = len(totals)
+	}
+	pageTotals := totals[offset:end]
 
-	var users []models.User
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &users)
+	ids := make([]string, len(pageTotals))
+	for i, total := range pageTotals {
+		ids[i] = total.UserID
+	}
+	users, err := s.users.GetUsers(ctx, ids)
 	if err != nil {
+		return nil, "", err
+	}
+	byID := make(map[string]models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+	streaks, err := s.streaks.CurrentStreaks(ctx, users)
+	if err != nil {
+		return nil, "", err
+	}
+
+	leaderboard := make([]models.LeaderboardEntry, 0, len(pageTotals))
+	for i, total := range pageTotals {
+		user, ok := byID[total.UserID]
+		if !ok {
+			continue
+		}
+		leaderboard = append(leaderboard, models.LeaderboardEntry{
+			Rank:   offset + i + 1,
+			ID:     user.ID,
+			Name:   user.Name,
+			Email:  user.Email,
+			Score:  total.Points,
+			Streak: streaks[user.ID],
+		})
+	}
+
+	next := ""
+	if end < len(totals) {
+		next = encodeWindowCursor(end - 1)
+	}
+	return leaderboard, next, nil
+}
+
+// pointTotals ranks users by points earned in the window, counting only
+// language if it is set.
+func (s *StatsService) pointTotals(ctx context.Context, window LeaderboardWindow, language string) ([]repository.PointsTotal, error) {
+	if language != "" {
+		return s.languages.ListLanguagePointTotals(ctx, language, window.From, window.To)
+	}
+	return s.leaderboard.ListPointTotals(ctx, window.From, window.To)
+}
+
+func encodeWindowCursor(position int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("w:" + strconv.Itoa(position)))
+}
+
+func decodeWindowCursor(cursor string) (int, error) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(key), "w:") {
+		return 0, repository.ErrInvalidCursor
+	}
+	position, err := strconv.Atoi(strings.TrimPrefix(string(key), "w:"))
+	if err != nil || position < 0 {
+		return 0, repository.ErrInvalidCursor
+	}
+	return position, nil
+}
+
+// GetUserRank returns the user's entry on the window's leaderboard, limited
+// to language if it is set. Tied users share the best rank. Returns nil if
+// the user does not exist.
+func (s *StatsService) GetUserRank(ctx context.Context, userID string, window LeaderboardWindow, language string) (*models.LeaderboardEntry, error) {
+	user, err := s.users.GetUser(ctx, userID)
+	if err != nil || user == nil {
 		return nil, err
 	}
 
-	// Sort by score (descending)
-	for i := 0; i < len(users)-1; i++ {
-		for j := i + 1; j < len(users); j++ {
-			if users[j].Score > users[i].Score {
-				users[i], users[j] = users[j], users[i]
+	score, above := user.Score, 0
+	if window.AllTime() && language == "" {
+		if above, err = s.leaderboard.CountUsersAbove(ctx, user.Score); err != nil {
+			return nil, err
+		}
+	} else {
+		totals, err := s.pointTotals(ctx, window, language)
+		if err != nil {
+			return nil, err
+		}
+		score = 0
+		for _, total := range totals {
+			if total.UserID == userID {
+				score = total.Points
+			}
+		}
+		for _, total := range totals {
+			if total.Points > score {
+				above++
 			}
 		}
 	}
 
-	// Take top N
-	if limit > len(users) {
-		limit = len(users)
+	streaks, err := s.streaks.CurrentStreaks(ctx, []models.User{*user})
+	if err != nil {
+		return nil, err
 	}
 
-	leaderboard := make([]models.LeaderboardEntry, limit)
-	for i := 0; i < limit; i++ {
-		leaderboard[i] = models.LeaderboardEntry{
-			Rank:   i + 1,
-			ID:     users[i].ID,
-			Name:   users[i].Name,
-			Email:  users[i].Email,
-			Score:  users[i].Score,
-			Streak: int(math.Ceil(float64(users[i].Score) / 100.0)),
-		}
+	return &models.LeaderboardEntry{
+		Rank:   above + 1,
+		ID:     user.ID,
+		Name:   user.Name,
+		Email:  user.Email,
+		Score:  score,
+		Streak: streaks[userID],
+	}, nil
+}
+
+// GetUserLanguages returns the user's points per language earned in the
+// window. Returns nil if the user does not exist.
+func (s *StatsService) GetUserLanguages(ctx context.Context, userID string, window LeaderboardWindow) (*models.LanguageStats, error) {
+	user, err := s.users.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	totals, err := s.languages.ListUserLanguageTotals(ctx, userID, window.From, window.To)
+	if err != nil {
+		return nil, err
+	}
+	return languageStats(window, totals), nil
+}
+
+// GetLanguages returns the points per language earned by all users in the
+// window.
+func (s *StatsService) GetLanguages(ctx context.Context, window LeaderboardWindow) (*models.LanguageStats, error) {
+	totals, err := s.languages.ListLanguageTotals(ctx, window.From, window.To)
+	if err != nil {
+		return nil, err
 	}
+	return languageStats(window, totals), nil
+}
 
-	return leaderboard, nil
+func languageStats(window LeaderboardWindow, totals []repository.LanguageTotal) *models.LanguageStats {
+	stats := &models.LanguageStats{
+		From:      window.From,
+		To:        window.To,
+		Languages: make([]models.LanguageShare, len(totals)),
+	}
+	for _, total := range totals {
+		stats.Total += total.Points
+	}
+	for i, total := range totals {
+		stats.Languages[i] = models.LanguageShare{
+			Language: total.Language,
+			Points:   total.Points,
+			Share:    float64(total.Points) / float64(stats.Total),
+		}
+	}
+	return stats
 }
 
-// GetActivityData returns activity for the past 7 days
+// GetActivityData returns points earned on each of the past 7 days in the
+// user's timezone, oldest first.
 func (s *StatsService) GetActivityData(ctx context.Context, userID string) (*models.ActivityData, error) {
-	// Generate mock activity data for last 7 days
+	user, err := s.users.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(userLocation(user))
+	rows, err := s.activity.ListActivitySince(ctx, userID, now.AddDate(0, 0, -6).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	points := make(map[string]int, len(rows))
+	for _, row := range rows {
+		points[row.Date] += row.Points
+	}
+
 	activity := &models.ActivityData{
 		Days:          []models.ActivityDay{},
 		TotalThisWeek: 0,
 	}
 
 	for i := 6; i >= 0; i-- {
-		date := time.Now().AddDate(0, 0, -i)
-		// Mock: random activity (in production, fetch from activity log)
-		count := int((date.Unix() % 150) + 10)
+		date := now.AddDate(0, 0, -i).Format("2006-01-02")
 		activity.Days = append(activity.Days, models.ActivityDay{
-			Date:  date.Format("2006-01-02"),
-			Count: count,
+			Date:  date,
+			Count: points[date],
 		})
-		activity.TotalThisWeek += count
+		activity.TotalThisWeek += points[date]
 	}
 
 	return activity, nil
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+func TestGetUserStatsCountsEverySession(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	if err := store.PutUser(ctx, models.User{ID: "u1", Name: "Ada"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// More sessions than fit in one page, one of them deleted.
+	const count = 2*sessionPageSize + 7
+	for i := 0; i < count; i++ {
+		ended := int64(1_700_000_000 + i*3600)
+		session := models.Session{
+			UserID:            "u1",
+			SessionID:         fmt.Sprintf("s%04d", i),
+			StartedAt:         ended - 120,
+			EndedAt:           ended,
+			Points:            1,
+			LanguageBreakdown: map[string]int{"go": 1},
+		}
+		if i == 3 {
+			session.DeletedAt = ended
+		}
+		if _, err := store.RecordSession(ctx, session, models.LedgerEntry{EntryID: session.SessionID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := NewStatsService(store, store, store, store, store, store).GetUserStats(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalSessions != count-1 {
+		t.Errorf("TotalSessions = %d, want %d", stats.TotalSessions, count-1)
+	}
+	if stats.TotalMinutes != 2*(count-1) {
+		t.Errorf("TotalMinutes = %d, want %d", stats.TotalMinutes, 2*(count-1))
+	}
+	if stats.TopLanguage != "go" {
+		t.Errorf("TopLanguage = %q, want go", stats.TopLanguage)
+	}
+}
+
+func TestNewLeaderboardWindowUsesNowsDate(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 09:30 on 2 May in Sydney is still 1 May in UTC.
+	now := time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		now  time.Time
+		want LeaderboardWindow
+	}{
+		{"utc", now, LeaderboardWindow{From: "2024-04-25", To: "2024-05-01"}},
+		{"sydney", now.In(sydney), LeaderboardWindow{From: "2024-04-26", To: "2024-05-02"}},
+	}
+	for _, tt := range tests {
+		got, err := NewLeaderboardWindow("week", "", "", tt.now)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: NewLeaderboardWindow(week) = %+v, %v, want %+v", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+)
+
+// ErrStreakContention is returned when a streak could not be saved because
+// other writes to it kept winning.
+var ErrStreakContention = errors.New("streak is being updated concurrently")
+
+// maxStreakAttempts bounds the read-modify-write retries of a streak update.
+const maxStreakAttempts = 5
+
+// StreakService maintains the persisted streak of each user. Days with
+// points normally extend the stored streak in place; anything it cannot
+// apply incrementally, such as a day earlier than the current run, is
+// handled by rebuilding the streak from DailyActivity.
+type StreakService struct {
+	streaks  repository.StreakRepository
+	activity repository.ActivityRepository
+}
+
+func NewStreakService(streaks repository.StreakRepository, activity repository.ActivityRepository) *StreakService {
+	return &StreakService{
+		streaks:  streaks,
+		activity: activity,
+	}
+}
+
+// GetStreak returns the user's streak with the current run cleared if it has
+// lapsed by today in loc, the user's timezone. A user without a stored
+// streak has it built from their history.
+func (s *StreakService) GetStreak(ctx context.Context, userID string, loc *time.Location) (*models.Streak, error) {
+	streak, err := s.streaks.GetStreak(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if streak == nil {
+		if streak, err = s.Rebuild(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	if !streakActive(streak, time.Now().In(loc)) {
+		streak.CurrentStart, streak.CurrentEnd, streak.CurrentLength = "", "", 0
+	}
+	return streak, nil
+}
+
+// CurrentStreaks returns the current streak length, in each user's timezone,
+// of those of users that have one, keyed by user ID. Users without a stored
+// streak are left out rather than rebuilt.
+func (s *StreakService) CurrentStreaks(ctx context.Context, users []models.User) (map[string]int, error) {
+	ids := make([]string, len(users))
+	locations := make(map[string]*time.Location, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+		locations[users[i].ID] = userLocation(&users[i])
+	}
+	streaks, err := s.streaks.GetStreaks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	current := make(map[string]int, len(streaks))
+	for i := range streaks {
+		if streakActive(&streaks[i], now.In(locations[streaks[i].UserID])) {
+			current[streaks[i].UserID] = streaks[i].CurrentLength
+		}
+	}
+	return current, nil
+}
+
+// RecordDay notes that the user earned points on date ("YYYY-MM-DD"). The
+// points must already be in DailyActivity. Recording a day twice is harmless.
+func (s *StreakService) RecordDay(ctx context.Context, userID, date string) error {
+	for attempt := 0; attempt < maxStreakAttempts; attempt++ {
+		streak, err := s.streaks.GetStreak(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if streak == nil || date < streak.CurrentStart {
+			_, err := s.Rebuild(ctx, userID)
+			return err
+		}
+		if date <= streak.CurrentEnd {
+			return nil
+		}
+
+		version := streak.Version
+		if nextDay(streak.CurrentEnd) == date {
+			streak.CurrentLength++
+		} else {
+			streak.CurrentStart, streak.CurrentLength = date, 1
+		}
+		streak.CurrentEnd = date
+		if streak.CurrentLength > streak.LongestLength {
+			streak.LongestStart, streak.LongestEnd, streak.LongestLength =
+				streak.CurrentStart, streak.CurrentEnd, streak.CurrentLength
+		}
+		streak.UpdatedAt = time.Now().Unix()
+
+		saved, err := s.streaks.SaveStreak(ctx, *streak, version)
+		if err != nil || saved {
+			return err
+		}
+	}
+	return ErrStreakContention
+}
+
+// Rebuild recomputes the user's streak from their whole DailyActivity
+// history and stores it.
+func (s *StreakService) Rebuild(ctx context.Context, userID string) (*models.Streak, error) {
+	for attempt := 0; attempt < maxStreakAttempts; attempt++ {
+		// Reading the stored version first means a day recorded while the
+		// history is being read makes the save fail and the rebuild retry.
+		stored, err := s.streaks.GetStreak(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		version := 0
+		if stored != nil {
+			version = stored.Version
+		}
+
+		rows, err := s.activity.ListActivitySince(ctx, userID, "")
+		if err != nil {
+			return nil, err
+		}
+		streak := buildStreak(userID, rows)
+		streak.UpdatedAt = time.Now().Unix()
+
+		saved, err := s.streaks.SaveStreak(ctx, streak, version)
+		if err != nil {
+			return nil, err
+		}
+		if saved {
+			streak.Version = version + 1
+			return &streak, nil
+		}
+	}
+	return nil, ErrStreakContention
+}
+
+// buildStreak computes streak state from DailyActivity rows in any order.
+// Ties for the longest run go to the earliest.
+func buildStreak(userID string, rows []models.DailyActivity) models.Streak {
+	seen := make(map[string]bool)
+	var dates []string
+	for _, activity := range rows {
+		if activity.Points > 0 && !seen[activity.Date] {
+			seen[activity.Date] = true
+			dates = append(dates, activity.Date)
+		}
+	}
+	sort.Strings(dates)
+
+	streak := models.Streak{UserID: userID}
+	for _, date := range dates {
+		if streak.CurrentLength > 0 && nextDay(streak.CurrentEnd) == date {
+			streak.CurrentLength++
+		} else {
+			streak.CurrentStart, streak.CurrentLength = date, 1
+		}
+		streak.CurrentEnd = date
+		if streak.CurrentLength > streak.LongestLength {
+			streak.LongestStart, streak.LongestEnd, streak.LongestLength =
+				streak.CurrentStart, streak.CurrentEnd, streak.CurrentLength
+		}
+	}
+	return streak
+}
+
+// streakActive reports whether the current run reaches today or yesterday,
+// taking now's location as the user's timezone.
+func streakActive(streak *models.Streak, now time.Time) bool {
+	return streak.CurrentLength > 0 && streak.CurrentEnd >= now.AddDate(0, 0, -1).Format("2006-01-02")
+}
+
+// nextDay returns the "YYYY-MM-DD" date after date, or "" if date is invalid.
+func nextDay(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return ""
+	}
+	return t.AddDate(0, 0, 1).Format("2006-01-02")
+}
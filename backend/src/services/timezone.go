@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"time"
+	// Embedded so timezones resolve on hosts without a zoneinfo database.
+	_ "time/tzdata"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+)
+
+// ErrInvalidTimezone is returned for a timezone that is not an IANA name.
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// ValidateTimezone checks that name is an IANA timezone such as
+// "Australia/Sydney". The empty name stands for UTC.
+func ValidateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if name == "Local" {
+		return ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
+// userLocation returns the timezone the user's days are counted in: UTC for
+// a missing user or an unset or unknown timezone.
+func userLocation(user *models.User) *time.Location {
+	if user == nil || user.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// localDate returns the "YYYY-MM-DD" date of t in loc.
+func localDate(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01-02")
+}
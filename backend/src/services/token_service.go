@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown,
+	// expired, revoked or has already been used.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrUserBanned is returned when issuing tokens to a banned user.
+	ErrUserBanned = errors.New("user is banned")
+)
+
+// TokenPair is the credential set handed to a client at login and on refresh.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresAt    int64  `json:"expiresAt"` // access token expiry, Unix seconds
+}
+
+// TokenService issues access JWTs and rotating refresh tokens. Every access
+// token carries a jti and the family of the refresh token chain it belongs to,
+// so either can be revoked before the token expires.
+type TokenService struct {
+	tokens     repository.TokenRepository
+	users      repository.UserRepository
+	keys       *utils.Keyring
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenService(tokens repository.TokenRepository, users repository.UserRepository, keys *utils.Keyring, accessTTL, refreshTTL time.Duration) *TokenService {
+	return &TokenService{
+		tokens:     tokens,
+		users:      users,
+		keys:       keys,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueTokens starts a new refresh token family for the user, e.g. on login.
+func (s *TokenService) IssueTokens(ctx context.Context, userID string) (*TokenPair, error) {
+	roles, err := s.activeRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	family, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	refresh, record, err := s.newRefreshToken(userID, family, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.CreateRefreshToken(ctx, record); err != nil {
+		return nil, err
+	}
+	return s.pair(userID, family, refresh, roles)
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented token
+// is single-use: presenting it again revokes its whole family, logging out
+// both the legitimate client and whoever else holds a copy.
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	now := time.Now()
+	hash := hashRefreshToken(refreshToken)
+	current, err := s.tokens.GetRefreshToken(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.ExpiresAt <= now.Unix() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	revoked, err := s.tokens.IsTokenRevoked(ctx, now.Unix(), current.CreatedAt, current.FamilyID, utils.UserRevocationID(current.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidRefreshToken
+	}
+	if current.UsedAt != 0 {
+		if err := s.revokeFamily(ctx, current.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	roles, err := s.activeRoles(ctx, current.UserID)
+	if errors.Is(err, ErrUserBanned) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, next, err := s.newRefreshToken(current.UserID, current.FamilyID, now)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := s.tokens.RotateRefreshToken(ctx, hash, next, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Lost a race with another use of the same token: that is reuse too.
+		if err := s.revokeFamily(ctx, current.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.pair(current.UserID, current.FamilyID, refresh, roles)
+}
+
+// RevokeRefreshToken revokes the family of the given refresh token, and with
+// it every access token issued from that family. Unknown tokens are ignored.
+func (s *TokenService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	current, err := s.tokens.GetRefreshToken(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return nil
+	}
+	return s.revokeFamily(ctx, current.FamilyID, time.Now())
+}
+
+// RevokeAccessClaims revokes the access token with the given verified claims
+// until it expires, along with the refresh token family it was issued from.
+func (s *TokenService) RevokeAccessClaims(ctx context.Context, claims jwt.MapClaims) error {
+	now := time.Now()
+	if family, ok := claims["fam"].(string); ok && family != "" {
+		if err := s.revokeFamily(ctx, family, now); err != nil {
+			return err
+		}
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil
+	}
+	expiresAt := now.Add(s.accessTTL).Unix()
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		expiresAt = exp.Unix()
+	}
+	return s.tokens.RevokeToken(ctx, models.RevokedToken{ID: jti, ExpiresAt: expiresAt})
+}
+
+// revokeFamily keeps the revocation for a full refresh TTL, by which time
+// every token issued from the family has expired.
+func (s *TokenService) revokeFamily(ctx context.Context, family string, now time.Time) error {
+	return s.tokens.RevokeToken(ctx, models.RevokedToken{
+		ID:        family,
+		ExpiresAt: now.Add(s.refreshTTL).Unix(),
+	})
+}
+
+func (s *TokenService) newRefreshToken(userID, family string, now time.Time) (string, models.RefreshToken, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", models.RefreshToken{}, err
+	}
+	return token, models.RefreshToken{
+		TokenHash: hashRefreshToken(token),
+		UserID:    userID,
+		FamilyID:  family,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(s.refreshTTL).Unix(),
+	}, nil
+}
+
+// RevokeUser invalidates every access and refresh token the user has been
+// issued so far, e.g. on ban. Banned users cannot obtain new tokens, and the
+// revocation stays in place after an unban, so the user has to sign in again.
+// It only needs to outlive the longest-lived token.
+func (s *TokenService) RevokeUser(ctx context.Context, userID string) error {
+	now := time.Now()
+	return s.tokens.RevokeToken(ctx, models.RevokedToken{
+		ID:        utils.UserRevocationID(userID),
+		ExpiresAt: now.Add(s.refreshTTL).Unix(),
+		// Issue times are whole seconds, so this also covers tokens issued
+		// earlier in the same second.
+		IssuedBefore: now.Unix() + 1,
+	})
+}
+
+// activeRoles returns the user's stored roles, or ErrUserBanned if the user
+// is banned.
+func (s *TokenService) activeRoles(ctx context.Context, userID string) ([]string, error) {
+	user, err := s.users.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+	if user.Banned {
+		return nil, ErrUserBanned
+	}
+	return user.Roles, nil
+}
+
+func (s *TokenService) pair(userID, family, refreshToken string, roles []string) (*TokenPair, error) {
+	accessToken, expiresAt, err := s.GenerateJWT(userID, family, roles)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// GenerateJWT signs an access token for the user in the given refresh token
+// family with the active key and returns it with its expiry. roles are
+// informational for clients; servers re-check admin rights from storage.
+func (s *TokenService) GenerateJWT(userID, family string, roles []string) (string, int64, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", 0, err
+	}
+	now := time.Now()
+	exp := now.Add(s.accessTTL).Unix()
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"jti":     jti,
+		"fam":     family,
+		"exp":     exp,
+		"iat":     now.Unix(),
+	}
+	if len(roles) > 0 {
+		claims["roles"] = roles
+	}
+
+	tokenString, err := s.keys.Sign(claims)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return tokenString, exp, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/utils"
+)
+
+func newTestTokenService(t *testing.T, tokens repository.TokenRepository, users repository.UserRepository) *TokenService {
+	t.Helper()
+	keys, err := utils.LoadKeyring("", "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTokenService(tokens, users, keys, 15*time.Minute, time.Hour)
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	s := newTestTokenService(t, store, store)
+
+	first, err := s.IssueTokens(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := s.IssueTokens(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("reusing a refresh token = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refreshing after reuse = %v, want the family revoked", err)
+	}
+	if _, err := s.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Errorf("refreshing another family = %v", err)
+	}
+}
+
+// racingTokenStore lets another request rotate the refresh token between
+// Refresh reading it and rotating it.
+type racingTokenStore struct {
+	*repository.MemoryStore
+	race func(hash string)
+}
+
+func (s *racingTokenStore) RotateRefreshToken(ctx context.Context, hash string, next models.RefreshToken, now int64) (bool, error) {
+	if s.race != nil {
+		race := s.race
+		s.race = nil
+		race(hash)
+	}
+	return s.MemoryStore.RotateRefreshToken(ctx, hash, next, now)
+}
+
+func TestRefreshLosingAConcurrentUseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	store := &racingTokenStore{MemoryStore: repository.NewMemoryStore()}
+	s := newTestTokenService(t, store, store)
+
+	pair, err := s.IssueTokens(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := store.GetRefreshToken(ctx, hashRefreshToken(pair.RefreshToken))
+	if err != nil || current == nil {
+		t.Fatalf("GetRefreshToken = %v, %v", current, err)
+	}
+	var winner string
+	store.race = func(hash string) {
+		token, next, err := s.newRefreshToken("u1", current.FamilyID, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := store.MemoryStore.RotateRefreshToken(ctx, hash, next, time.Now().Unix()); err != nil || !ok {
+			t.Fatalf("winning RotateRefreshToken = %v, %v", ok, err)
+		}
+		winner = token
+	}
+
+	if _, err := s.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("losing Refresh = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := s.Refresh(ctx, winner); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refreshing the winner's token = %v, want the family revoked", err)
+	}
+}
+
+func TestConcurrentRefreshesIssueAtMostOnePair(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	s := newTestTokenService(t, store, store)
+	pair, err := s.IssueTokens(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan *TokenPair, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			next, err := s.Refresh(ctx, pair.RefreshToken)
+			if err != nil && !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Error(err)
+			}
+			results <- next
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var issued []*TokenPair
+	for next := range results {
+		if next != nil {
+			issued = append(issued, next)
+		}
+	}
+	if len(issued) > 1 {
+		t.Fatalf("%d refreshes succeeded, want at most 1", len(issued))
+	}
+	// The other uses were reuse, so the family is revoked either way.
+	for _, next := range issued {
+		if _, err := s.Refresh(ctx, next.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("refreshing the issued pair = %v, want the family revoked", err)
+		}
+	}
+}
@@ -2,267 +2,227 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/Brian-w-m/DevVerse/backend/src/repository"
+	"github.com/Brian-w-m/DevVerse/backend/src/utils"
 )
 
+// ErrUserExists is returned by CreateUser when the ID is taken.
+var ErrUserExists = errors.New("user already exists")
+
 type UserService struct {
-	dynamoClient       *dynamodb.Client
-	table              string
-	dailyActivityTable string
+	users    repository.UserRepository
+	activity repository.ActivityRepository
+	streaks  *StreakService
 }
 
-func NewUserService(dynamoClient *dynamodb.Client, tableName, dailyActivityTable string) *UserService {
+func NewUserService(users repository.UserRepository, activity repository.ActivityRepository, streaks repository.StreakRepository) *UserService {
 	return &UserService{
-		dynamoClient:       dynamoClient,
-		table:              tableName,
-		dailyActivityTable: dailyActivityTable,
+		users:    users,
+		activity: activity,
+		streaks:  NewStreakService(streaks, activity),
 	}
 }
 
 func (s *UserService) ListUsers(ctx context.Context) ([]models.User, error) {
-	result, err := s.dynamoClient.Scan(ctx, &dynamodb.ScanInput{
-		TableName: aws.String(s.table),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to scan users: %w", err)
-	}
-
-	var users []models.User
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &users)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal users: %w", err)
-	}
+	return s.users.ListUsers(ctx)
+}
 
-	return users, nil
+// ListUsersPage returns one page of users and the cursor for the next page.
+func (s *UserService) ListUsersPage(ctx context.Context, limit int, cursor string) ([]models.User, string, error) {
+	return s.users.ListUsersPage(ctx, limit, cursor)
 }
 
 func (s *UserService) GetUserByID(ctx context.Context, id string) (*models.User, error) {
-	key, err := attributevalue.MarshalMap(map[string]string{
-		"ID": id,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal key: %w", err)
-	}
-
-	result, err := s.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
-		TableName: aws.String(s.table),
-		Key:       key,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-
-	if result.Item == nil {
-		return nil, nil // User not found
-	}
-
-	var user models.User
-	err = attributevalue.UnmarshalMap(result.Item, &user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal user: %w", err)
-	}
-
-	return &user, nil
+	return s.users.GetUser(ctx, id)
 }
 
+// CreateUser stores a new user with a score of 0; score changes go through
+// UpdateUserScore and AddUserScore so that each one is in the ledger.
 func (s *UserService) CreateUser(ctx context.Context, user models.User) error {
-	item, err := attributevalue.MarshalMap(user)
-	if err != nil {
-		return fmt.Errorf("failed to marshal user: %w", err)
+	user.Score = 0
+	if user.CreatedAt == 0 {
+		user.CreatedAt = time.Now().Unix()
 	}
-
-	_, err = s.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(s.table),
-		Item:      item,
-	})
+	created, err := s.users.CreateUser(ctx, user)
 	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+		return err
+	}
+	if !created {
+		return ErrUserExists
 	}
-
 	return nil
 }
 
 func (s *UserService) UpdateUser(ctx context.Context, user models.User) error {
-	key, err := attributevalue.MarshalMap(map[string]string{
-		"ID": user.ID,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to marshal key: %w", err)
-	}
-
-	updateExpr := "SET #name = :name, #email = :email"
-	_, err = s.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName: aws.String(s.table),
-		Key:       key,
-		UpdateExpression: aws.String(updateExpr),
-		ExpressionAttributeNames: map[string]string{
-			"#name":  "Name",
-			"#email": "Email",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":name":  &types.AttributeValueMemberS{Value: user.Name},
-			":email": &types.AttributeValueMemberS{Value: user.Email},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
-	}
-
-	return nil
+	return s.users.UpdateUserProfile(ctx, user.ID, user.Name, user.Email)
 }
 
-func (s *UserService) UpdateUserScore(ctx context.Context, id string, score int) error {
-	key, err := attributevalue.MarshalMap(map[string]string{
-		"ID": id,
-	})
+// UpdateUserScore overwrites the user's score, recording actorID as having
+// set it.
+func (s *UserService) UpdateUserScore(ctx context.Context, id string, score int, actorID string) error {
+	entry, err := newLedgerEntry(models.LedgerSourceAdminSet, "", actorID)
 	if err != nil {
-		return fmt.Errorf("failed to marshal key: %w", err)
+		return err
 	}
+	return s.users.SetUserScore(ctx, id, score, entry)
+}
 
-	updateExpr := "SET #score = :score"
-	_, err = s.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName: aws.String(s.table),
-		Key: key,
-		UpdateExpression: aws.String(updateExpr),
-		ExpressionAttributeNames: map[string]string{
-			"#score": "Score",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":score": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", score)},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to update user score: %w", err)
+// SetTimezone sets the IANA timezone the user's days are counted in; the
+// empty name resets it to UTC. Days already recorded keep their dates.
+func (s *UserService) SetTimezone(ctx context.Context, id, timezone string) error {
+	if err := ValidateTimezone(timezone); err != nil {
+		return err
 	}
-
-	return nil
+	return s.users.SetUserTimezone(ctx, id, timezone)
 }
 
-func (s *UserService) AddUserScore(ctx context.Context, id string, increment int) error {
-	date := time.Now().UTC().Format("2006-01-02")
-	key, err := attributevalue.MarshalMap(map[string]string{
-		"ID": id,
-	})
+// AddUserScore adds increment to the user's score and to today's activity,
+// where today is the current date in the user's timezone, recording actorID
+// as having added it.
+func (s *UserService) AddUserScore(ctx context.Context, id string, increment int, actorID string) error {
+	user, err := s.users.GetUser(ctx, id)
 	if err != nil {
-		return fmt.Errorf("failed to marshal key: %w", err)
+		return err
 	}
-
-	updateExpr := "ADD #score :increment"
-	_, err = s.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName: aws.String(s.table),
-		Key: key,
-		UpdateExpression: aws.String(updateExpr),
-		ExpressionAttributeNames: map[string]string{
-			"#score": "Score",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":increment": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", increment)},
-		},
-	})
+	date := localDate(time.Now(), userLocation(user))
+	entry, err := newLedgerEntry(models.LedgerSourceManualAdd, "", actorID)
 	if err != nil {
-		return fmt.Errorf("failed to add user score: %w", err)
+		return err
 	}
-
-	key, err = attributevalue.MarshalMap(map[string]string{
-		"UserID": id,
-		"Date": date,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to marshal key: %w", err)
+	if err := s.users.AddUserScore(ctx, id, increment, date, entry); err != nil {
+		return err
 	}
-	
-	updateExpr = "ADD #points :increment"
-	_, err = s.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName: aws.String(s.dailyActivityTable),
-		Key: key,
-		UpdateExpression: aws.String(updateExpr),
-		ExpressionAttributeNames: map[string]string{
-			"#points": "Points",
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":increment": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", increment)},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to upsert daily activity: %w", err)
+	if increment <= 0 {
+		return nil
 	}
-
-	return nil
+	return s.streaks.RecordDay(ctx, id, date)
 }
 
-// CreateOrUpdateUserByGitHub creates or updates a user using GitHub ID as the primary ID
-func (s *UserService) CreateOrUpdateUserByGitHub(ctx context.Context, githubID string, name, email string) (*models.User, error) {
+// CreateOrUpdateUserFromIdentity creates user id from their identity
+// provider profile (see AccountService.ResolveLogin for choosing id), or
+// refreshes an existing user's GitHub login and avatar. Name and email are
+// only taken from the profile when the user is created. Logging in counts as
+// being seen.
+func (s *UserService) CreateOrUpdateUserFromIdentity(ctx context.Context, id string, identity *Identity) (*models.User, error) {
+	githubLogin := ""
+	if identity.Provider == ProviderGitHub {
+		githubLogin = identity.Login
+	}
+	now := time.Now().Unix()
+
 	// Check if user exists
-	user, err := s.GetUserByID(ctx, githubID)
+	user, err := s.users.GetUser(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	if user != nil {
-		// Update existing user (name and email might have changed)
-		user.Name = name
-		user.Email = email
-		item, err := attributevalue.MarshalMap(user)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal user: %w", err)
+		// Only the fields the provider owns are written, so changes made to
+		// the user since it was read, and name or email edits, are kept.
+		if err := s.users.UpdateUserIdentity(ctx, id, githubLogin, identity.AvatarURL, now); err != nil {
+			return nil, err
 		}
-
-		_, err = s.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
-			TableName: aws.String(s.table),
-			Item:      item,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to update user: %w", err)
+		if githubLogin != "" {
+			user.GithubLogin = githubLogin
 		}
+		user.AvatarURL = identity.AvatarURL
+		if user.CreatedAt == 0 {
+			user.CreatedAt = now
+		}
+		user.LastSeenAt = now
 		return user, nil
 	}
 
-	// Create new user with GitHub ID as the ID
 	newUser := models.User{
-		ID:    githubID, // Use GitHub ID directly as the primary ID
-		Name:  name,
-		Email: email,
-		Score: 0,
+		ID:          id,
+		Name:        identity.Name,
+		Email:       identity.Email,
+		Score:       0,
+		GithubLogin: githubLogin,
+		AvatarURL:   identity.AvatarURL,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+	}
+	if err := s.users.PutUser(ctx, newUser); err != nil {
+		return nil, err
 	}
 
-	item, err := attributevalue.MarshalMap(newUser)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal user: %w", err)
-	}
+	return &newUser, nil
+}
 
-	_, err = s.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(s.table),
-		Item:      item,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+// GrantRole adds role to the user's roles. It returns nil if the user does
+// not exist.
+func (s *UserService) GrantRole(ctx context.Context, id, role string) (*models.User, error) {
+	user, err := s.users.GetUser(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	if utils.HasRole(user.Roles, role) {
+		return user, nil
+	}
+	user.Roles = append(user.Roles, role)
+	if err := s.users.SetUserRoles(ctx, id, user.Roles); err != nil {
+		return nil, err
 	}
+	return user, nil
+}
 
-	return &newUser, nil
+// RevokeRole removes role from the user's roles. It returns nil if the user
+// does not exist.
+func (s *UserService) RevokeRole(ctx context.Context, id, role string) (*models.User, error) {
+	user, err := s.users.GetUser(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	roles := make([]string, 0, len(user.Roles))
+	for _, r := range user.Roles {
+		if r != role {
+			roles = append(roles, r)
+		}
+	}
+	if len(roles) == len(user.Roles) {
+		return user, nil
+	}
+	user.Roles = roles
+	if err := s.users.SetUserRoles(ctx, id, roles); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-func (s *UserService) DeleteUser(ctx context.Context, id string) error {
-	key, err := attributevalue.MarshalMap(map[string]string{
-		"ID": id,
-	})
+// ListUsersWithRole scans every user; it is meant for small admin listings.
+func (s *UserService) ListUsersWithRole(ctx context.Context, role string) ([]models.User, error) {
+	all, err := s.users.ListUsers(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to marshal key: %w", err)
+		return nil, err
 	}
+	users := []models.User{}
+	for _, u := range all {
+		if utils.HasRole(u.Roles, role) {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
 
-	_, err = s.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: aws.String(s.table),
-		Key:       key,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+// SetBanned bans or unbans the user. It returns nil if the user does not
+// exist.
+func (s *UserService) SetBanned(ctx context.Context, id string, banned bool) (*models.User, error) {
+	user, err := s.users.GetUser(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	if err := s.users.SetUserBanned(ctx, id, banned); err != nil {
+		return nil, err
 	}
+	user.Banned = banned
+	return user, nil
+}
 
-	return nil
-}
\ No newline at end of file
+func (s *UserService) DeleteUser(ctx context.Context, id string) error {
+	return s.users.DeleteUser(ctx, id)
+}
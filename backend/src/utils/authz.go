@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Brian-w-m/DevVerse/backend/src/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RoleAdmin is the role that may act on any user's resources and use the
+// admin API.
+const RoleAdmin = "admin"
+
+// Principal is the authenticated caller as established by JWTAuth. Roles are
+// those in the token when it was issued. Scopes apply only to personal
+// access tokens; session tokens carry every scope.
+type Principal struct {
+	UserID        string
+	Roles         []string
+	Scopes        []string
+	PersonalToken bool
+}
+
+// HasScope reports whether the principal may use scope.
+func (p Principal) HasScope(scope string) bool {
+	return !p.PersonalToken || HasRole(p.Scopes, scope)
+}
+
+// HasRole reports whether the principal holds role.
+func (p Principal) HasRole(role string) bool {
+	return HasRole(p.Roles, role)
+}
+
+// HasRole reports whether roles contains role.
+func HasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentPrincipal returns the principal set by JWTAuth, if any.
+func CurrentPrincipal(c *gin.Context) (Principal, bool) {
+	v, ok := c.Get("principal")
+	if !ok {
+		return Principal{}, false
+	}
+	p, ok := v.(Principal)
+	return p, ok
+}
+
+// UserLookup reads the stored user; it returns (nil, nil) if none exists.
+type UserLookup interface {
+	GetUser(ctx context.Context, id string) (*models.User, error)
+}
+
+// Authorizer holds the access policies for user-scoped and admin routes.
+// Admin rights are read from storage rather than trusted from the token, so
+// demoting an admin takes effect immediately.
+type Authorizer struct {
+	users UserLookup
+}
+
+func NewAuthorizer(users UserLookup) *Authorizer {
+	return &Authorizer{users: users}
+}
+
+// IsAdmin reports whether the principal's stored user holds the admin role.
+// Personal access tokens never carry admin rights.
+func (a *Authorizer) IsAdmin(ctx context.Context, p Principal) (bool, error) {
+	if p.UserID == "" || p.PersonalToken {
+		return false, nil
+	}
+	user, err := a.users.GetUser(ctx, p.UserID)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && !user.Banned && HasRole(user.Roles, RoleAdmin), nil
+}
+
+// CanAccessUser is the policy for user-scoped resources: a principal may act
+// on its own user and admins may act on anyone.
+func (a *Authorizer) CanAccessUser(ctx context.Context, p Principal, userID string) (bool, error) {
+	if p.UserID != "" && p.UserID == userID {
+		return true, nil
+	}
+	return a.IsAdmin(ctx, p)
+}
+
+// RequireSelfOrAdmin allows the request only if the path parameter param
+// names the authenticated user or the caller is an admin. Must run after
+// JWTAuth.
+func (a *Authorizer) RequireSelfOrAdmin(param string) gin.HandlerFunc {
+	return a.require(func(c *gin.Context, p Principal) (bool, error) {
+		return a.CanAccessUser(c.Request.Context(), p, c.Param(param))
+	})
+}
+
+// RequireAdmin allows the request only if the caller is an admin. Must run
+// after JWTAuth.
+func (a *Authorizer) RequireAdmin() gin.HandlerFunc {
+	return a.require(func(c *gin.Context, p Principal) (bool, error) {
+		return a.IsAdmin(c.Request.Context(), p)
+	})
+}
+
+// RequireScope allows the request only if the caller's token grants scope.
+// Must run after JWTAuth.
+func RequireScope(scope string) gin.HandlerFunc {
+	return requirePrincipal(func(p Principal) bool { return p.HasScope(scope) })
+}
+
+// RequireSessionToken rejects personal access tokens, for routes that manage
+// the account itself. Must run after JWTAuth.
+func RequireSessionToken() gin.HandlerFunc {
+	return requirePrincipal(func(p Principal) bool { return !p.PersonalToken })
+}
+
+func requirePrincipal(allowed func(Principal) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := CurrentPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if !allowed(p) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token lacks the required scope"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func (a *Authorizer) require(allowed func(*gin.Context, Principal) (bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := CurrentPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		ok, err := allowed(c, p)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to authorize request"})
+			return
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,14 +1,40 @@
 package utils
 
 import (
+	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func JWTAuth(secret string) gin.HandlerFunc {
+// TokenRevocations reports whether an access token, identified by its jti, by
+// the refresh token family it was issued from or by its user, and issued at
+// issuedAt, has been revoked.
+type TokenRevocations interface {
+	IsTokenRevoked(ctx context.Context, now, issuedAt int64, ids ...string) (bool, error)
+}
+
+// UserRevocationID is the revocation ID covering the tokens a user was issued
+// before being banned.
+func UserRevocationID(userID string) string {
+	return "user:" + userID
+}
+
+// PersonalTokenPrefix marks a bearer value as a personal access token rather
+// than a JWT.
+const PersonalTokenPrefix = "dvp_"
+
+// PersonalTokenVerifier resolves a personal access token to its principal. It
+// returns an error if the token is unknown, expired or its user is banned.
+type PersonalTokenVerifier interface {
+	VerifyPersonalToken(ctx context.Context, token string) (*Principal, error)
+}
+
+// JWTAuth authenticates the request from a bearer access token, or from a
+// personal access token when pats is non-nil.
+func JWTAuth(keys *Keyring, revocations TokenRevocations, pats PersonalTokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if !strings.HasPrefix(authHeader, "Bearer ") {
@@ -16,23 +42,76 @@ func JWTAuth(secret string) gin.HandlerFunc {
 			return
 		}
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrTokenInvalidClaims
-			}
-			return []byte(secret), nil
-		})
-		if err != nil || !token.Valid {
+		if strings.HasPrefix(tokenStr, PersonalTokenPrefix) {
+			personalTokenAuth(c, tokenStr, pats)
+			return
+		}
+		claims, err := keys.Parse(tokenStr)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		// Tokens without a jti predate revocation support and cannot be revoked.
+		jti, _ := claims["jti"].(string)
+		if jti == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			if userID, ok := claims["user_id"].(string); ok {
-				c.Set("user_id", userID)
+		ids := []string{jti}
+		if family, ok := claims["fam"].(string); ok && family != "" {
+			ids = append(ids, family)
+		}
+		if userID, ok := claims["user_id"].(string); ok && userID != "" {
+			ids = append(ids, UserRevocationID(userID))
+		}
+		// A token without an iat is treated as older than any revocation.
+		var issuedAt int64
+		if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+			issuedAt = iat.Unix()
+		}
+		revoked, err := revocations.IsTokenRevoked(c.Request.Context(), time.Now().Unix(), issuedAt, ids...)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify token"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+			return
+		}
+
+		principal := Principal{}
+		if userID, ok := claims["user_id"].(string); ok {
+			principal.UserID = userID
+			c.Set("user_id", userID)
+		}
+		if roles, ok := claims["roles"].([]interface{}); ok {
+			for _, role := range roles {
+				if r, ok := role.(string); ok {
+					principal.Roles = append(principal.Roles, r)
+				}
 			}
 		}
+		c.Set("principal", principal)
+		c.Set("token_id", jti)
 		c.Next()
 	}
 }
 
-
+// personalTokenAuth needs no revocation check: the verifier refuses tokens of
+// banned users itself, and a user's personal access tokens work again once
+// they are unbanned.
+func personalTokenAuth(c *gin.Context, token string, pats PersonalTokenVerifier) {
+	if pats == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	principal, err := pats.VerifyPersonalToken(c.Request.Context(), token)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	c.Set("user_id", principal.UserID)
+	c.Set("principal", *principal)
+	c.Next()
+}
@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultKeyID is the kid of the key built from JWT_SECRET when no key file
+// is configured. Tokens without a kid header are verified against the key
+// with this ID, so a key file can keep accepting them by listing the old
+// secret under "default".
+const DefaultKeyID = "default"
+
+// KeyFile is the JSON layout of JWT_KEYS_FILE. Active names the key new
+// tokens are signed with; every other key is used only for verification.
+// Key file paths are resolved relative to the key file.
+//
+//	{
+//	  "active": "2026-10",
+//	  "keys": [
+//	    {"kid": "2026-10", "alg": "EdDSA", "private_key_file": "2026-10.pem"},
+//	    {"kid": "2026-04", "alg": "RS256", "public_key_file": "2026-04.pub.pem"},
+//	    {"kid": "default", "alg": "HS256", "secret": "old JWT_SECRET"}
+//	  ]
+//	}
+type KeyFile struct {
+	Active string         `json:"active"`
+	Keys   []KeyFileEntry `json:"keys"`
+}
+
+type KeyFileEntry struct {
+	ID             string `json:"kid"`
+	Algorithm      string `json:"alg"` // HS256, RS256 or EdDSA
+	Secret         string `json:"secret,omitempty"`
+	PrivateKeyFile string `json:"private_key_file,omitempty"`
+	PublicKeyFile  string `json:"public_key_file,omitempty"`
+}
+
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{} // nil for verification-only keys
+	verifyKey interface{}
+}
+
+// Keyring holds the keys used to sign and verify access tokens. It is safe
+// for concurrent use, and Reload swaps in a new key set without a restart.
+type Keyring struct {
+	path   string
+	secret string
+
+	mu     sync.RWMutex
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+// LoadKeyring reads the key file at path, or builds a single HS256 key from
+// secret when path is empty.
+func LoadKeyring(path, secret string) (*Keyring, error) {
+	k := &Keyring{path: path, secret: secret}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload re-reads the key file. On error the current keys are kept.
+func (k *Keyring) Reload() error {
+	var (
+		active *signingKey
+		keys   map[string]*signingKey
+		err    error
+	)
+	if k.path == "" {
+		if k.secret == "" {
+			return errors.New("JWT_SECRET or JWT_KEYS_FILE must be set")
+		}
+		active = &signingKey{
+			id:        DefaultKeyID,
+			method:    jwt.SigningMethodHS256,
+			signKey:   []byte(k.secret),
+			verifyKey: []byte(k.secret),
+		}
+		keys = map[string]*signingKey{DefaultKeyID: active}
+	} else if active, keys, err = readKeyFile(k.path); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.active = active
+	k.keys = keys
+	return nil
+}
+
+func readKeyFile(path string) (*signingKey, map[string]*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	var file KeyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	keys := make(map[string]*signingKey, len(file.Keys))
+	for _, entry := range file.Keys {
+		if entry.ID == "" {
+			return nil, nil, errors.New("key file entry is missing kid")
+		}
+		if _, dup := keys[entry.ID]; dup {
+			return nil, nil, fmt.Errorf("duplicate kid %q in key file", entry.ID)
+		}
+		key, err := parseKeyEntry(entry, dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("key %q: %w", entry.ID, err)
+		}
+		keys[entry.ID] = key
+	}
+
+	active, ok := keys[file.Active]
+	if !ok {
+		return nil, nil, fmt.Errorf("active key %q is not in the key file", file.Active)
+	}
+	if active.signKey == nil {
+		return nil, nil, fmt.Errorf("active key %q has no private key", file.Active)
+	}
+	return active, keys, nil
+}
+
+func parseKeyEntry(entry KeyFileEntry, dir string) (*signingKey, error) {
+	readPEM := func(name string) ([]byte, error) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		return os.ReadFile(name)
+	}
+
+	key := &signingKey{id: entry.ID}
+	switch entry.Algorithm {
+	case "HS256":
+		if entry.Secret == "" {
+			return nil, errors.New("HS256 key needs a secret")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.signKey = []byte(entry.Secret)
+		key.verifyKey = []byte(entry.Secret)
+
+	case "RS256":
+		key.method = jwt.SigningMethodRS256
+		if entry.PrivateKeyFile != "" {
+			pem, err := readPEM(entry.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = private
+			key.verifyKey = &private.PublicKey
+		} else if entry.PublicKeyFile != "" {
+			pem, err := readPEM(entry.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			if key.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, errors.New("RS256 key needs private_key_file or public_key_file")
+		}
+
+	case "EdDSA":
+		key.method = jwt.SigningMethodEdDSA
+		if entry.PrivateKeyFile != "" {
+			pem, err := readPEM(entry.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = private
+			key.verifyKey = private.(crypto.Signer).Public()
+		} else if entry.PublicKeyFile != "" {
+			pem, err := readPEM(entry.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			if key.verifyKey, err = jwt.ParseEdPublicKeyFromPEM(pem); err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, errors.New("EdDSA key needs private_key_file or public_key_file")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported alg %q", entry.Algorithm)
+	}
+	return key, nil
+}
+
+// Sign signs claims with the active key and sets the kid header.
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	active := k.active
+	k.mu.RUnlock()
+
+	token := jwt.NewWithClaims(active.method, claims)
+	token.Header["kid"] = active.id
+	return token.SignedString(active.signKey)
+}
+
+// Parse validates the signature and expiry of tokenStr against the key named
+// by its kid header and returns its claims.
+func (k *Keyring) Parse(tokenStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			kid = DefaultKeyID
+		}
+		k.mu.RLock()
+		key, ok := k.keys[kid]
+		k.mu.RUnlock()
+		// Checking the algorithm against the key stops an attacker from
+		// presenting a public key as an HMAC secret.
+		if !ok || t.Method.Alg() != key.method.Alg() {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return key.verifyKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+// JWKS returns the public keys as a JSON Web Key Set. HMAC keys are secret
+// and never published.
+func (k *Keyring) JWKS() map[string]interface{} {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	jwks := make([]map[string]string, 0, len(ids))
+	for _, id := range ids {
+		key := k.keys[id]
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, map[string]string{
+				"kty": "RSA",
+				"kid": key.id,
+				"use": "sig",
+				"alg": key.method.Alg(),
+				"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks = append(jwks, map[string]string{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"kid": key.id,
+				"use": "sig",
+				"alg": key.method.Alg(),
+				"x":   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	return map[string]interface{}{"keys": jwks}
+}
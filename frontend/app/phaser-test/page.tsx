@@ -175,7 +175,10 @@ export default function PhaserGamePage() {
     (async () => {
       let totalPts = 0;
       try {
-        const res = await fetch(`${BACKEND_URL}/users/${encodeURIComponent(userId)}/activity?days=90`);
+        const token = localStorage.getItem('devverse.jwt');
+        const res = await fetch(`${BACKEND_URL}/users/${encodeURIComponent(userId)}/activity?days=90`, {
+          headers: token ? { Authorization: `Bearer ${token}` } : {},
+        });
         if (res.ok) {
           const rows = await res.json() as Array<{ points: number }>;
           totalPts = rows.reduce((s, r) => s + (r.points ?? 0), 0);